@@ -1,24 +1,42 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gomarketplace_api/config"
+	"gomarketplace_api/internal/core"
+	coreservices "gomarketplace_api/internal/core/services"
+	corestorage "gomarketplace_api/internal/core/storage"
+	ozonapp "gomarketplace_api/internal/ozon/app"
+	"gomarketplace_api/internal/suppliers/wholesaler/adapter"
 	wsapp "gomarketplace_api/internal/suppliers/wholesaler/app"
 	"gomarketplace_api/internal/suppliers/wholesaler/app/web"
 	"gomarketplace_api/internal/suppliers/wholesaler/app/web/handlers/h"
 	"gomarketplace_api/internal/suppliers/wholesaler/business"
 	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
 	wbapp "gomarketplace_api/internal/wildberries/app"
+	"gomarketplace_api/internal/wildberries/business/services/update"
+	yandexapp "gomarketplace_api/internal/yandex/app"
 	metrics2 "gomarketplace_api/metrics"
+	"gomarketplace_api/pkg/dbconnect/migration"
 	"gomarketplace_api/pkg/dbconnect/postgres"
 	logger2 "gomarketplace_api/pkg/logger"
+	"gomarketplace_api/pkg/scheduler"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"sync"
+	"syscall"
+	"time"
 )
 
+// defaultShutdownTimeout - сколько ждать завершения задач после SIGTERM, если не задано в конфиге.
+const defaultShutdownTimeout = time.Minute
+
 func main() {
 	runtime.GOMAXPROCS(6)
 	logger := logger2.NewLogger(os.Stdout, "[MainGoroutine]")
@@ -31,26 +49,44 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	wbConfig := appCfg.Wildberries
 	pgConfig := appCfg.Postgres
-
-	wg := sync.WaitGroup{}
-
 	writer := os.Stdout
-	wg.Add(1)
 
 	metrics()
 
-	go func() {
-		con := postgres.NewPgConnector(pgConfig)
-		wserver := wsapp.NewWServer(con)
-		wserver.Run()
-		defer wg.Done()
-	}()
+	wserver := wsapp.NewWServer(postgres.NewPgConnector(pgConfig), appCfg.Imports, appCfg.Fetchers).WithQuality(appCfg.Quality)
+	if err := wserver.Migrate(); err != nil {
+		logger.Log("Wholesaler migrations failed: %v", err)
+		os.Exit(1)
+	}
+	importDB, err := postgres.NewPgConnector(pgConfig).Connect()
+	if err != nil {
+		logger.Log("Database not connected: %v", err)
+		os.Exit(1)
+	}
+	defer importDB.Close()
+	importRunner, err := wserver.ImportRunner(ctx, importDB)
+	if err != nil {
+		logger.Log("Supplier imports not started: %v", err)
+		os.Exit(1)
+	}
 
-	wg.Wait()
+	supplierStatus, err := suppliersSync(ctx, appCfg, writer)
+	if err != nil {
+		logger.Log("Suppliers sync not started: %v", err)
+	}
 
-	wg.Add(2)
+	// обработчики, которые меняют данные, подключаются к локальному API планировщика, а не к :8081
+	var (
+		priceQuarantineHandler *h.PriceQuarantineHandler
+		qualityHandler         *h.QualityHandler
+	)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
 	go func() {
 		con := postgres.NewPgConnector(pgConfig)
 		db, err := con.Connect()
@@ -64,29 +100,179 @@ func main() {
 		prodService := business.NewProductService(prodRepo)
 
 		mediaHandler := h.NewMediaHandler(mediaRepo)
-		priceHandler := h.NewPriceHandler(db)
+		profiles, err := business.LoadPricingProfiles(appCfg.Pricing, repositories.NewPricingProfileRepository(db))
+		if err != nil {
+			logger.Log("Pricing profiles not loaded, using defaults: %v", err)
+			profiles = business.NewPricingProfiles()
+		}
+		priceHandler := h.NewPriceHandler(db, profiles)
 		sizeHandler := h.NewSizeHandler(db, writer)
 		brandHandler := h.NewBrandHandler(brandRepo)
 		barcodesHandler := h.NewBarcodeHandler(db)
 		idsHandler := h.NewWholesalerIdsHandler(db)
 		appellationsHandler := h.NewAppellationHandler(prodService)
 		descriptionsHandler := h.NewDescriptionsHandler(prodService)
+		stocksHandler := h.NewStocksHandler(db)
+		priceHistoryHandler := h.NewPriceHistoryHandler(db)
+		priceQuarantineHandler = h.NewPriceQuarantineHandler(db)
+		changesHandler := h.NewChangesHandler(db)
+		importRunsHandler := h.NewImportRunsHandler(db)
+		qualityHandler = h.NewQualityHandler(business.NewQualityService(repositories.NewProductQualityRepository(db), appCfg.Quality))
 		wg.Done()
-		web.SetupRoutes(mediaHandler, priceHandler, sizeHandler, brandHandler, barcodesHandler, idsHandler, appellationsHandler, descriptionsHandler)
+		web.SetupRoutes(mediaHandler, priceHandler, sizeHandler, brandHandler, barcodesHandler, idsHandler, appellationsHandler,
+			descriptionsHandler, stocksHandler, priceHistoryHandler, priceQuarantineHandler, changesHandler, importRunsHandler, qualityHandler)
 	}()
-
 	wg.Wait()
 
-	wg.Add(1)
+	wbserver := wbapp.NewWbServer(postgres.NewPgConnector(pgConfig), *wbConfig, writer).WithMarginGuard(appCfg.MarginGuard, appCfg.Pricing)
+	previewHandler, closePreview, err := wbPreview(wbserver, wbConfig.DryRun)
+	if err != nil {
+		logger.Log("WB dry-run not started: %v", err)
+		os.Exit(1)
+	}
+	defer closePreview()
+	if err := wbserver.Init(); err != nil {
+		logger.Log("WB server not started: %v", err)
+		os.Exit(1)
+	}
+	defer wbserver.Close()
+
+	jobs := []scheduler.Job{
+		{Name: "supplier_import", Run: wserver.ImportWith(importDB, importRunner)},
+		{Name: "wb_nomenclatures", Run: wbserver.SyncNomenclatures},
+		{Name: "wb_characteristics", Run: wbserver.RefreshCharacteristics},
+		{Name: "wb_media", Run: wbserver.UpdateMedia},
+		{Name: "wb_naming", Run: wbserver.UpdateNames},
+		{Name: "wb_prices", Run: wbserver.SyncPrices},
+		{Name: "wb_stocks", Run: wbserver.SyncStocks},
+	}
+	if appCfg.Ozon != nil {
+		ozonServer := ozonapp.NewOzonServer(postgres.NewPgConnector(pgConfig), *appCfg.Ozon, "http://localhost:8081", writer).
+			WithMarginGuard(appCfg.MarginGuard, appCfg.Pricing)
+		jobs = append(jobs, scheduler.Job{Name: "ozon_sync", Run: ozonServer.Sync})
+	}
+	if appCfg.Yandex != nil {
+		yandexServer := yandexapp.NewYandexServer(postgres.NewPgConnector(pgConfig), *appCfg.Yandex, "http://localhost:8081", writer).
+			WithMarginGuard(appCfg.MarginGuard, appCfg.Pricing)
+		jobs = append(jobs, scheduler.Job{Name: "yandex_sync", Run: yandexServer.Sync})
+	}
+
+	sched, err := newScheduler(ctx, appCfg, jobs, writer)
+	if err != nil {
+		logger.Log("Scheduler not started: %v", err)
+		os.Exit(1)
+	}
+
+	schedCfg := appCfg.Scheduler
+	if schedCfg == nil {
+		schedCfg = &config.SchedulerConfig{}
+	}
+	listen := schedCfg.Listen
+	if listen == "" {
+		listen = "127.0.0.1:8082"
+	}
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/api/scheduler/", scheduler.NewHandler(sched))
+	runsHandler := wbserver.RunsHandler()
+	adminMux.Handle("/api/wildberries/runs", runsHandler)
+	adminMux.Handle("/api/wildberries/runs/failures", runsHandler)
+	adminMux.HandleFunc("/api/price/quarantine/resolve", priceQuarantineHandler.ServeResolve)
+	adminMux.HandleFunc("/api/quality/run", qualityHandler.ServeRun)
+	if supplierStatus != nil {
+		adminMux.Handle("/api/suppliers/status", supplierStatus)
+	}
+	if previewHandler != nil {
+		adminMux.Handle("/api/wildberries/preview", previewHandler)
+	}
+	adminServer := &http.Server{Addr: listen, Handler: adminMux}
 	go func() {
-		con := postgres.NewPgConnector(pgConfig)
-		wbserver := wbapp.NewWbServer(con, *wbConfig, writer)
-		wbserver.Run()
-		defer wg.Done()
+		logger.Log("Starting scheduler API on %s", listen)
+		if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log("Scheduler API failed: %v", err)
+		}
 	}()
-	wg.Wait()
 
-	os.Exit(0)
+	<-ctx.Done()
+	logger.Log("Shutdown signal received, waiting for running jobs")
+
+	shutdownTimeout := schedCfg.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := adminServer.Shutdown(shutdownCtx); err != nil {
+		logger.Log("Scheduler API shutdown failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		sched.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		logger.Log("All jobs finished, bye")
+	case <-shutdownCtx.Done():
+		logger.Log("Jobs did not finish in %s, exiting", shutdownTimeout)
+	}
+}
+
+// wbPreview включает dry-run обновлений карточек WB из конфига. Без файла записи хранятся в памяти,
+// и возвращается обработчик для их просмотра по HTTP.
+func wbPreview(wbserver *wbapp.WildberriesServer, cfg config.WildberriesDryRunConfig) (http.Handler, func(), error) {
+	if !cfg.Enabled {
+		return nil, func() {}, nil
+	}
+	if cfg.File != "" {
+		sink, err := update.NewJSONLinesPreviewSink(cfg.File)
+		if err != nil {
+			return nil, nil, err
+		}
+		wbserver.WithPreview(sink)
+		return nil, func() { sink.Close() }, nil
+	}
+	sink := update.NewMemoryPreviewSink(cfg.Keep)
+	wbserver.WithPreview(sink)
+	return sink, func() {}, nil
+}
+
+// newScheduler регистрирует задачи с расписаниями из секции scheduler конфига и запускает планировщик.
+// Состояние задач хранится в core.scheduler_jobs.
+func newScheduler(ctx context.Context, appCfg *config.AppConfig, jobs []scheduler.Job, writer io.Writer) (*scheduler.Scheduler, error) {
+	db, err := postgres.NewPgConnector(appCfg.Postgres).Connect()
+	if err != nil {
+		return nil, err
+	}
+	if err := (&core.CoreSchedulerJobs{}).UpMigration(db); err != nil {
+		return nil, fmt.Errorf("core migration failed: %w", err)
+	}
+
+	var jobConfigs map[string]config.SchedulerJobConfig
+	if appCfg.Scheduler != nil {
+		jobConfigs = appCfg.Scheduler.Jobs
+	}
+
+	sched := scheduler.New(corestorage.NewSchedulerRepository(db), writer)
+	registered := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		jobCfg := jobConfigs[job.Name]
+		job.Spec, job.Timeout = jobCfg.Schedule, jobCfg.Timeout
+		if err := sched.Register(job); err != nil {
+			return nil, err
+		}
+		registered[job.Name] = true
+	}
+	for name := range jobConfigs {
+		if !registered[name] {
+			log.Printf("Scheduler: unknown job '%s' in config, ignored", name)
+		}
+	}
+
+	if err := sched.Start(ctx); err != nil {
+		return nil, err
+	}
+	return sched, nil
 }
 
 func metrics() {
@@ -99,3 +285,43 @@ func metrics() {
 		}
 	}()
 }
+
+// suppliersSync синхронизирует всех поставщиков из конфига один раз и запускает фоновый цикл синхронизации до отмены ctx.
+func suppliersSync(ctx context.Context, appCfg *config.AppConfig, writer io.Writer) (http.Handler, error) {
+	con := postgres.NewPgConnector(appCfg.Postgres)
+	db, err := con.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, _migration := range []migration.MigrationInterface{
+		&core.CoreSuppliers{},
+		&core.CoreSupplierSyncStatus{},
+		&core.CoreProducts{},
+		&core.CoreProductsSupplierArticul{},
+		&core.CoreProductGroups{},
+	} {
+		if err := _migration.UpMigration(db); err != nil {
+			return nil, fmt.Errorf("core migration failed: %w", err)
+		}
+	}
+
+	registry := coreservices.NewSupplierRegistry()
+	if err := registry.Register(adapter.AdapterName, adapter.NewFactory(writer)); err != nil {
+		return nil, err
+	}
+
+	suppliers, err := registry.Build(db, appCfg.Suppliers)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := coreservices.NewMatchingService(corestorage.NewProductGroupRepository(db), writer)
+	syncService := coreservices.NewSupplierSyncService(suppliers, corestorage.NewSupplierRepository(db), matcher, writer)
+	if err := syncService.SyncAll(ctx); err != nil {
+		log.Printf("Suppliers sync finished with errors: %v", err)
+	}
+	go syncService.Run(ctx)
+
+	return syncService.StatusHandler(), nil
+}
@@ -0,0 +1,302 @@
+// gomarket - разовые операции обслуживания карточек Wildberries.
+//
+//	gomarket <команда> [флаги]
+//
+// Команды: categories, charcs, upload-products, update-names, update-by-category, sync-nomenclatures, check-search-engine, rollback.
+// Операции с карточками берут данные поставщика из HTTP API приложения (http://localhost:8081),
+// поэтому оно должно быть запущено. Результат выводится в stdout в JSON, журнал - в stderr.
+// С -dry-run запросы к WB с изменениями карточек пишутся в -preview-file и не отправляются.
+// Обновления карточек получают ID запуска (run_id в результате; run_ids - если запусков несколько),
+// по которому их можно откатить:
+// gomarket rollback -run <run_id> или gomarket rollback -nm-id <nmID> -version <версия>.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gomarketplace_api/config"
+	wbapp "gomarketplace_api/internal/wildberries/app"
+	request2 "gomarketplace_api/internal/wildberries/business/models/dto/request"
+	"gomarketplace_api/internal/wildberries/business/services/update"
+	"gomarketplace_api/pkg/dbconnect/postgres"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// command - подкоманда CLI. run возвращает счётчики результата и ID запусков обновления карточек,
+// если команда начинает несколько запусков; для остальных ID берётся из server.LastRunID.
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error)
+}
+
+var commands = []command{
+	{
+		name:  "categories",
+		usage: "загрузить предметы WB в wildberries.categories",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			count, err := server.SyncCategories(ctx)
+			return map[string]int{"categories": count}, nil, err
+		},
+	},
+	{
+		name:  "charcs",
+		usage: "обновить характеристики категорий (-object-ids - только этих категорий)",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			count, err := server.LoadCharacteristics(ctx, opts.objectIDs)
+			return map[string]int{"categories": count}, nil, err
+		},
+	},
+	{
+		name:  "upload-products",
+		usage: "создать карточки товаров, которых ещё нет на WB, в категориях -object-ids",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			if len(opts.objectIDs) == 0 {
+				return nil, nil, fmt.Errorf("-object-ids is required")
+			}
+			counts := map[string]int{}
+			for _, categoryID := range opts.objectIDs {
+				count, err := server.UploadProducts(ctx, categoryID, float32(opts.accuracy))
+				if err != nil {
+					return counts, nil, fmt.Errorf("category %d: %w", categoryID, err)
+				}
+				counts[strconv.Itoa(categoryID)] = count
+				counts["uploaded"] += count
+			}
+			return counts, nil, nil
+		},
+	},
+	{
+		name:  "update-names",
+		usage: "обновить наименования и описания карточек",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			count, err := server.RenameCards(ctx, opts.settings())
+			return map[string]int{"updated": count}, nil, err
+		},
+	},
+	{
+		name:  "update-by-category",
+		usage: "обновить наименования и габариты упаковки карточек категорий -object-ids",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			if len(opts.objectIDs) == 0 {
+				return nil, nil, fmt.Errorf("-object-ids is required")
+			}
+			update, err := server.UpdateCategoryCards(ctx, opts.settings())
+			return map[string]int{"names_updated": update.Names, "packages_updated": update.Packages}, update.RunIDs, err
+		},
+	},
+	{
+		name:  "sync-nomenclatures",
+		usage: "загрузить номенклатуры WB в wildberries.nomenclatures",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			count, err := server.UploadNomenclatures(ctx, opts.settings())
+			return map[string]int{"nomenclatures": count}, nil, err
+		},
+	},
+	{
+		name:  "check-search-engine",
+		usage: "посчитать номенклатуры WB по фильтру",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			count, err := server.CountNomenclatures(opts.settings())
+			return map[string]int{"nomenclatures": count}, nil, err
+		},
+	},
+	{
+		name:  "rollback",
+		usage: "вернуть карточки запуска -run к версиям до него или карточку -nm-id к версии -version",
+		run: func(ctx context.Context, server *wbapp.WildberriesServer, opts *options) (map[string]int, []string, error) {
+			var count int
+			var err error
+			switch {
+			case opts.runID != "":
+				count, err = server.RollbackRun(ctx, opts.runID)
+			case opts.nmID != 0 && opts.version != 0:
+				count, err = server.RollbackCard(ctx, opts.nmID, opts.version)
+			default:
+				return nil, nil, fmt.Errorf("-run or -nm-id with -version is required")
+			}
+			return map[string]int{"rolled_back": count}, nil, err
+		},
+	},
+}
+
+// options - общие флаги команд: фильтр request.Settings и режим выполнения.
+type options struct {
+	configPath  string
+	objectIDs   []int
+	brands      []string
+	withPhoto   int
+	textSearch  string
+	limit       int
+	accuracy    float64
+	dryRun      bool
+	previewFile string
+	runID       string
+	nmID        int
+	version     int
+}
+
+func (o *options) settings() request2.Settings {
+	brands := o.brands
+	if brands == nil {
+		brands = []string{}
+	}
+	objectIDs := o.objectIDs
+	if objectIDs == nil {
+		objectIDs = []int{}
+	}
+	return request2.Settings{
+		Sort:   request2.Sort{Ascending: false},
+		Filter: request2.Filter{WithPhoto: o.withPhoto, TagIDs: []int{}, TextSearch: o.textSearch, AllowedCategoriesOnly: true, ObjectIDs: objectIDs, Brands: brands, ImtID: 0},
+		Cursor: request2.Cursor{Limit: o.limit},
+	}
+}
+
+// result - вывод команды в stdout.
+type result struct {
+	Command string `json:"command"`
+	DryRun  bool   `json:"dry_run"`
+	RunID   string `json:"run_id,omitempty"`
+	// RunIDs - ID всех запусков, если команда начинает несколько (update-by-category: наименования и упаковка)
+	RunIDs []string       `json:"run_ids,omitempty"`
+	Counts map[string]int `json:"counts"`
+	Error  string         `json:"error,omitempty"`
+}
+
+func main() {
+	log.SetOutput(os.Stderr)
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	opts, err := parseFlags(cmd.name, os.Args[2:])
+	if err != nil {
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	res := result{Command: cmd.name, DryRun: opts.dryRun}
+	res.RunID, res.RunIDs, res.Counts, err = execute(ctx, cmd, opts)
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(res); err != nil {
+		log.Printf("Failed to encode result: %v", err)
+	}
+	if res.Error != "" {
+		os.Exit(1)
+	}
+}
+
+// execute выполняет команду и возвращает ID последнего запуска обновления карточек (если был),
+// ID всех запусков команды, если их несколько, и счётчики.
+func execute(ctx context.Context, cmd *command, opts *options) (string, []string, map[string]int, error) {
+	appCfg, err := (&config.AppConfig{}).LoadConfig(opts.configPath)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("load config %s: %w", opts.configPath, err)
+	}
+	if appCfg.Wildberries == nil {
+		return "", nil, nil, fmt.Errorf("wildberries section is missing in %s", opts.configPath)
+	}
+
+	server := wbapp.NewWbServer(postgres.NewPgConnector(appCfg.Postgres), *appCfg.Wildberries, os.Stderr).
+		WithMarginGuard(appCfg.MarginGuard, appCfg.Pricing)
+	if opts.dryRun {
+		sink, err := update.NewJSONLinesPreviewSink(opts.previewFile)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		defer sink.Close()
+		server.WithPreview(sink)
+	}
+	if err := server.Init(); err != nil {
+		return "", nil, nil, err
+	}
+	defer server.Close()
+
+	counts, runIDs, err := cmd.run(ctx, server, opts)
+	if preview := server.Preview(); preview != nil {
+		if counts == nil {
+			counts = map[string]int{}
+		}
+		batches, cards, invalid := preview.Stats()
+		counts["preview_requests"], counts["preview_cards"], counts["preview_invalid"] = int(batches), int(cards), int(invalid)
+	}
+	return server.LastRunID(), runIDs, counts, err
+}
+
+func parseFlags(name string, args []string) (*options, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.configPath, "config", "config/config.yaml", "путь к config.yaml")
+	objectIDs := fs.String("object-ids", "", "ID категорий WB через запятую")
+	brands := fs.String("brands", "", "бренды через запятую")
+	fs.IntVar(&opts.withPhoto, "with-photo", -1, "фильтр по фото: -1 - все, 0 - без фото, 1 - с фото")
+	fs.StringVar(&opts.textSearch, "text", "", "поиск по артикулу, nmID или наименованию")
+	fs.IntVar(&opts.limit, "limit", 10000, "размер страницы выборки карточек")
+	fs.Float64Var(&opts.accuracy, "accuracy", 0.3, "upload-products: допустимая доля несовпадения категории")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "подготовить и проверить запросы, ничего не отправляя в WB и не записывая в БД")
+	fs.StringVar(&opts.runID, "run", "", "rollback: ID запуска обновления")
+	fs.IntVar(&opts.nmID, "nm-id", 0, "rollback: nmID карточки")
+	fs.IntVar(&opts.version, "version", 0, "rollback: версия карточки из wildberries.nomenclatures_history")
+	fs.StringVar(&opts.previewFile, "preview-file", "wb-preview.jsonl", "dry-run: JSON-lines файл для запросов к WB и изменений карточек")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, part := range splitList(*objectIDs) {
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -object-ids value %q\n", part)
+			return nil, err
+		}
+		opts.objectIDs = append(opts.objectIDs, id)
+	}
+	opts.brands = splitList(*brands)
+	return opts, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gomarket <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-22s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w, "\nRun 'gomarket <command> -h' for flags.")
+}
@@ -0,0 +1,126 @@
+package values
+
+import (
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+)
+
+// PricingProfile - параметры расчёта цены PriceEngine. Профиль выбирается по площадке и категории
+// товара поставщика; пустые Marketplace и Category означают "любая".
+type PricingProfile struct {
+	Name        string `yaml:"name" json:"name"`
+	Marketplace string `yaml:"marketplace" json:"marketplace"`
+	Category    string `yaml:"category" json:"category"`
+
+	// MarkupTiers - наценка (желаемая прибыль) в % от закупочной цены по диапазонам закупочной цены.
+	MarkupTiers []MarkupTier `yaml:"markup_tiers" json:"markup_tiers"`
+	// MinProfit - минимальная прибыль с товара в рублях.
+	MinProfit float64 `yaml:"min_profit" json:"min_profit"`
+	// CommissionPercent - комиссия площадки в % от цены продажи.
+	CommissionPercent float64 `yaml:"commission_percent" json:"commission_percent"`
+	// Logistics - стоимость доставки в % от цены без доставки, ограниченная снизу и сверху.
+	Logistics LogisticsFee `yaml:"logistics" json:"logistics"`
+	// AcquiringFee - эквайринг, руб.
+	AcquiringFee float64 `yaml:"acquiring_fee" json:"acquiring_fee"`
+	// HandlingFee - обработка отправления, руб.
+	HandlingFee float64 `yaml:"handling_fee" json:"handling_fee"`
+	// PriceCoefficient - итоговый множитель цены продажи.
+	PriceCoefficient float64 `yaml:"price_coefficient" json:"price_coefficient"`
+	// PreDiscountMarkupPercent - насколько цена до скидки (Y) выше цены продажи (Z), %.
+	PreDiscountMarkupPercent float64 `yaml:"pre_discount_markup_percent" json:"pre_discount_markup_percent"`
+	// PremiumDiscount - скидка для премиум-цены (Ozon Premium).
+	PremiumDiscount PremiumDiscount `yaml:"premium_discount" json:"premium_discount"`
+	// Jitter - детерминированная добавка к прибыли, чтобы цены не выглядели одинаково. nil - без добавки.
+	Jitter *Jitter `yaml:"jitter" json:"jitter,omitempty"`
+
+	// present - ключи, заданные в YAML или JSON профиля: по ним заданный 0 отличается от незаданного
+	present map[string]bool
+}
+
+// Has сообщает, задан ли параметр key (ключ YAML/JSON, например min_profit) в разобранном профиле.
+func (p PricingProfile) Has(key string) bool {
+	return p.present[key]
+}
+
+func (p *PricingProfile) UnmarshalYAML(node *yaml.Node) error {
+	type plain PricingProfile
+	if err := node.Decode((*plain)(p)); err != nil {
+		return err
+	}
+	var keys map[string]yaml.Node
+	if err := node.Decode(&keys); err != nil {
+		return err
+	}
+	p.present = make(map[string]bool, len(keys))
+	for key := range keys {
+		p.present[key] = true
+	}
+	return nil
+}
+
+func (p *PricingProfile) UnmarshalJSON(data []byte) error {
+	type plain PricingProfile
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	p.present = make(map[string]bool, len(keys))
+	for key := range keys {
+		p.present[key] = true
+	}
+	return nil
+}
+
+// MarkupTier - наценка для закупочных цен до UpTo включительно. UpTo = 0 - без верхней границы.
+type MarkupTier struct {
+	UpTo    float64 `yaml:"up_to" json:"up_to"`
+	Percent float64 `yaml:"percent" json:"percent"`
+}
+
+type LogisticsFee struct {
+	Percent float64 `yaml:"percent" json:"percent"`
+	Min     float64 `yaml:"min" json:"min"`
+	Max     float64 `yaml:"max" json:"max"`
+}
+
+type PremiumDiscount struct {
+	Percent float64 `yaml:"percent" json:"percent"`
+	Min     float64 `yaml:"min" json:"min"`
+	Max     float64 `yaml:"max" json:"max"`
+	// MaxProfitShare - если скидка больше этой доли прибыли, премиум-цена не выставляется.
+	MaxProfitShare float64 `yaml:"max_profit_share" json:"max_profit_share"`
+}
+
+// Jitter добавляет к прибыли от 1 до Max рублей. Значение зависит только от Seed, товара и закупочной цены.
+type Jitter struct {
+	Max  int   `yaml:"max" json:"max"`
+	Seed int64 `yaml:"seed" json:"seed"`
+}
+
+// DefaultPricingProfile - профиль, совпадающий с прежними захардкоженными коэффициентами PriceEngine.
+func DefaultPricingProfile() PricingProfile {
+	return PricingProfile{
+		Name: "default",
+		MarkupTiers: []MarkupTier{
+			{UpTo: 100, Percent: 38}, {UpTo: 200, Percent: 37}, {UpTo: 400, Percent: 34},
+			{UpTo: 700, Percent: 29}, {UpTo: 1000, Percent: 26}, {UpTo: 1300, Percent: 25},
+			{UpTo: 1600, Percent: 25}, {UpTo: 1900, Percent: 25}, {UpTo: 2300, Percent: 24},
+			{UpTo: 2700, Percent: 22}, {UpTo: 3000, Percent: 22}, {UpTo: 4000, Percent: 21},
+			{UpTo: 5000, Percent: 21}, {UpTo: 6000, Percent: 21}, {UpTo: 7000, Percent: 21},
+			{UpTo: 8000, Percent: 20}, {UpTo: 9000, Percent: 20}, {UpTo: 10000, Percent: 19},
+			{UpTo: 12000, Percent: 18}, {UpTo: 14000, Percent: 17}, {UpTo: 16000, Percent: 16},
+			{UpTo: 18000, Percent: 15}, {UpTo: 20000, Percent: 14}, {UpTo: 0, Percent: 13},
+		},
+		MinProfit:         30,
+		CommissionPercent: 23,
+		Logistics:         LogisticsFee{Percent: 5, Min: 55, Max: 205},
+		AcquiringFee:      20,
+		HandlingFee:       25,
+		PriceCoefficient:  1.05,
+		PremiumDiscount:   PremiumDiscount{Percent: 5, Min: 20, Max: 500, MaxProfitShare: 0.5},
+		Jitter:            &Jitter{Max: 10},
+	}
+}
@@ -9,10 +9,22 @@ type WildberriesValues struct {
 	PackageLength int `yaml:"package-length"`
 }
 
+type OzonValues struct {
+	DescriptionCategoryID int64  `yaml:"description-category-id"`
+	TypeID                int64  `yaml:"type-id"`
+	Vat                   string `yaml:"vat"`
+	PackageHeight         int    `yaml:"package-height"` // мм
+	PackageWidth          int    `yaml:"package-width"`  // мм
+	PackageDepth          int    `yaml:"package-depth"`  // мм
+	PackageWeight         int    `yaml:"package-weight"` // г
+}
+
 type WildberriesBannedBrands struct {
 	BannedBrands []string `yaml:"banned"`
 }
 
 type Identity struct {
 	Code int `yaml:"code"`
+	// Supplier - поставщик из registry.supplier, из товаров которого строятся карточки; пустой - wholesaler
+	Supplier string `yaml:"supplier"`
 }
@@ -0,0 +1,61 @@
+package models
+
+import "encoding/json"
+
+// ChannelCard - карточка товара на площадке продаж в независимом от площадки виде.
+type ChannelCard struct {
+	Channel    string   `json:"channel"`
+	ExternalID string   `json:"external_id"` // идентификатор карточки на площадке (nmID для WB)
+	VendorCode string   `json:"vendor_code"`
+	GlobalID   int      `json:"global_id"` // 0, если vendor code не наш
+	CategoryID int      `json:"category_id"`
+	Title      string   `json:"title"`
+	Brand      string   `json:"brand"`
+	Barcodes   []string `json:"barcodes"`
+	UpdatedAt  string   `json:"updated_at"`
+	// Raw - исходная карточка площадки. Нужна реализациям, которые при обновлении
+	// должны отправлять карточку целиком (например, WB затирает неуказанные поля).
+	Raw json.RawMessage `json:"-"`
+}
+
+// ContentUpdate описывает изменение контента карточки. Пустые поля не меняются.
+type ContentUpdate struct {
+	Card        ChannelCard
+	Title       string
+	Description string
+	Brand       string
+}
+
+// PriceUpdate - цена товара для выгрузки. Price - цена до скидки, DiscountedPrice - цена продажи.
+type PriceUpdate struct {
+	GlobalID        int
+	ExternalID      string
+	Price           int
+	DiscountedPrice int
+}
+
+// StockUpdate - остаток товара на складе площадки. SKU - баркод или offer_id, в зависимости от площадки.
+type StockUpdate struct {
+	GlobalID int
+	SKU      string
+	Quantity int
+}
+
+// ChannelCategory - категория площадки. Дерево собирается по ParentID.
+type ChannelCategory struct {
+	ID         int    `json:"id"`
+	ParentID   int    `json:"parent_id"`
+	Name       string `json:"name"`
+	ParentName string `json:"parent_name"`
+}
+
+// PushResult - отчёт о выгрузке цен или остатков. Failed: ключ (SKU или ExternalID) -> причина.
+type PushResult struct {
+	Changed   int               `json:"changed"`
+	Unchanged int               `json:"unchanged"`
+	Failed    map[string]string `json:"failed"`
+}
+
+func NewPushResult() *PushResult {
+	return &PushResult{Failed: make(map[string]string)}
+}
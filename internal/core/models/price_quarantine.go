@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	QuarantineStatusPending  = "pending"
+	QuarantineStatusApproved = "approved"
+	QuarantineStatusRejected = "rejected"
+	QuarantineStatusApplied  = "applied" // одобренная цена выгружена на площадку
+)
+
+// QuarantinedPrice - цена, задержанная MarginGuard до ручного решения.
+// Price и DiscountedPrice - цена до скидки и цена продажи, которые собирались выгрузить.
+type QuarantinedPrice struct {
+	ID              int64      `json:"id"`
+	Marketplace     string     `json:"marketplace"`
+	GlobalID        int        `json:"global_id"`
+	ExternalID      string     `json:"external_id"`
+	Price           int        `json:"price"`
+	DiscountedPrice int        `json:"discounted_price"`
+	PurchasePrice   int        `json:"purchase_price"`
+	Profit          float64    `json:"profit"`
+	MarginPercent   float64    `json:"margin_percent"`
+	LastPrice       *int       `json:"last_price,omitempty"`
+	Reason          string     `json:"reason"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+}
@@ -8,12 +8,67 @@ import (
 // Product представляет товар, агрегируемый из разных источников (поставщиков).
 // Эта структура отражает общую информацию о товаре и хранится в таблице core.products.
 type Product struct {
+	// ID - global_id товара. Адаптеры поставщиков, кроме легаси (wholesaler), берут его из registry
+	// (ArticularService.InternalArticuls), чтобы ID разных поставщиков не совпадали.
 	ID         string `json:"id"`
 	SupplierID int    `json:"supplier_id"`
 	// BaseData содержит базовую информацию о товаре в формате JSON.
 	// Здесь могут храниться общие для всех поставщиков поля (например, название, описание, цена и пр.).
 	// Для работы с динамическими данными используем json.RawMessage или map[string]interface{}.
+	// Формат документа описан в ProductBaseData.
 	BaseData  json.RawMessage `db:"base_data" json:"base_data"`
 	CreatedAt time.Time       `db:"created_at" json:"created_at"` // Дата и время создания записи.
 	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"` // Дата и время последнего обновления.
 }
+
+// ProductCollision - товар, пропущенный при записи в core.products: его global_id уже занят
+// другим поставщиком (OwnerID) или артикулом (OwnerArticul).
+type ProductCollision struct {
+	GlobalID        int
+	SupplierID      int
+	SupplierArticul string
+	OwnerID         int
+	OwnerArticul    string
+}
+
+// ProductBaseData описывает документ, который адаптеры поставщиков кладут в core.products.base_data.
+// Все адаптеры обязаны заполнять его одинаково, чтобы маркетплейсы не зависели от формата конкретного поставщика.
+type ProductBaseData struct {
+	SupplierArticul string        `json:"supplier_articul"` // артикул товара у поставщика
+	Title           string        `json:"title"`
+	Description     string        `json:"description,omitempty"`
+	Model           string        `json:"model,omitempty"`
+	Category        string        `json:"category,omitempty"`
+	Brand           string        `json:"brand,omitempty"`
+	Country         string        `json:"country,omitempty"`
+	ProductType     string        `json:"product_type,omitempty"`
+	Features        string        `json:"features,omitempty"`
+	Sex             string        `json:"sex,omitempty"`
+	Color           string        `json:"color,omitempty"`
+	Material        string        `json:"material,omitempty"`
+	Barcodes        []string      `json:"barcodes"`
+	Media           []string      `json:"media"`
+	Sizes           []ProductSize `json:"sizes"`
+	Price           int           `json:"price"`  // закупочная цена в рублях
+	Stocks          int           `json:"stocks"` // остаток у поставщика
+}
+
+// ProductSize - размерная характеристика товара (длина, диаметр, вес и т.д.).
+type ProductSize struct {
+	Descriptor string  `json:"descriptor"`
+	Type       string  `json:"type"`
+	Value      float64 `json:"value"`
+	Unit       string  `json:"unit"`
+}
+
+// Decode разбирает BaseData товара.
+func (p *Product) Decode() (*ProductBaseData, error) {
+	var data ProductBaseData
+	if len(p.BaseData) == 0 {
+		return &data, nil
+	}
+	if err := json.Unmarshal(p.BaseData, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
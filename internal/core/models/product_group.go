@@ -0,0 +1,16 @@
+package models
+
+const (
+	MatchTypeBarcode    = "barcode"
+	MatchTypeBrandModel = "brand_model"
+)
+
+// ProductGroup объединяет предложения разных поставщиков, которые являются одним и тем же товаром.
+// В базе данных хранится в таблицах core.product_groups и core.product_group_members.
+type ProductGroup struct {
+	ID                int    `json:"id"`
+	MatchKey          string `json:"match_key"`  // баркод или нормализованные бренд+модель, по которым собрана группа
+	MatchType         string `json:"match_type"` // MatchTypeBarcode или MatchTypeBrandModel
+	PreferredGlobalID int    `json:"preferred_global_id"`
+	Members           []int  `json:"members"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	SyncStatusRunning = "running"
+	SyncStatusSuccess = "success"
+	SyncStatusFailed  = "failed"
+)
+
+// SupplierSyncStatus - состояние последней синхронизации поставщика (таблица core.supplier_sync_status).
+type SupplierSyncStatus struct {
+	SupplierID    int        `json:"supplier_id"`
+	SupplierName  string     `json:"supplier_name"`
+	Status        string     `json:"status"`
+	ProductsCount int        `json:"products_count"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+}
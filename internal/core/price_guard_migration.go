@@ -0,0 +1,50 @@
+package core
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type CorePriceGuard struct{}
+
+// UpMigration создаёт таблицы проверки цен перед выгрузкой на площадки:
+// core.prices_pushed - последняя выгруженная цена продажи товара на площадке,
+// core.price_quarantine - цены, задержанные проверкой маржи до ручного решения.
+func (m *CorePriceGuard) UpMigration(db *sql.DB) error {
+	query := `
+    CREATE SCHEMA IF NOT EXISTS core;
+
+    CREATE TABLE IF NOT EXISTS core.prices_pushed (
+        marketplace VARCHAR(64) NOT NULL,
+        global_id INT NOT NULL,
+        price INT NOT NULL,
+        pushed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        PRIMARY KEY (marketplace, global_id)
+    );
+
+    CREATE TABLE IF NOT EXISTS core.price_quarantine (
+        id BIGSERIAL PRIMARY KEY,
+        marketplace VARCHAR(64) NOT NULL,
+        global_id INT NOT NULL,
+        external_id VARCHAR(255) NOT NULL DEFAULT '',
+        price INT NOT NULL,
+        discounted_price INT NOT NULL,
+        purchase_price INT NOT NULL DEFAULT 0,
+        profit NUMERIC(12, 2) NOT NULL DEFAULT 0,
+        margin_percent NUMERIC(8, 2) NOT NULL DEFAULT 0,
+        last_price INT,
+        reason TEXT NOT NULL,
+        status VARCHAR(16) NOT NULL DEFAULT 'pending',
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        resolved_at TIMESTAMP WITH TIME ZONE
+    );
+
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_price_quarantine_pending
+        ON core.price_quarantine (marketplace, global_id) WHERE status = 'pending';
+    CREATE INDEX IF NOT EXISTS idx_price_quarantine_status ON core.price_quarantine (status, marketplace);`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create price guard tables: %w", err)
+	}
+	return nil
+}
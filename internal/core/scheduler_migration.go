@@ -0,0 +1,30 @@
+package core
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type CoreSchedulerJobs struct{}
+
+// UpMigration создаёт core.scheduler_jobs - состояние последнего запуска задач планировщика.
+// По started_at планировщик после перезапуска понимает, пропущен ли запуск по расписанию.
+func (m *CoreSchedulerJobs) UpMigration(db *sql.DB) error {
+	query := `
+    CREATE SCHEMA IF NOT EXISTS core;
+
+    CREATE TABLE IF NOT EXISTS core.scheduler_jobs (
+        job_name VARCHAR(128) PRIMARY KEY,
+        status VARCHAR(32) NOT NULL,
+        trigger VARCHAR(32) NOT NULL,
+        error TEXT,
+        started_at TIMESTAMP WITH TIME ZONE,
+        finished_at TIMESTAMP WITH TIME ZONE,
+        last_success_at TIMESTAMP WITH TIME ZONE
+    );`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create core.scheduler_jobs table: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gomarketplace_api/internal/core/models"
+)
+
+// ErrNotSupported возвращается каналом, если площадка не поддерживает операцию.
+var ErrNotSupported = errors.New("operation is not supported by channel")
+
+// Channel определяет операции площадки продаж, на которые опирается оркестрация синхронизации.
+type Channel interface {
+	// Name возвращает имя площадки (wildberries, ozon, ...).
+	Name() string
+
+	// ListCards отправляет в канал все карточки продавца и закрывает его по завершении.
+	ListCards(ctx context.Context, cardCh chan<- models.ChannelCard) error
+
+	// CreateCards создаёт карточки для товаров с указанными global_id в категории площадки.
+	// Возвращает количество отправленных карточек.
+	CreateCards(ctx context.Context, categoryID int, globalIDs []int) (int, error)
+
+	// UpdateContent обновляет контент существующих карточек. Возвращает количество обновлённых.
+	UpdateContent(ctx context.Context, updates []models.ContentUpdate) (int, error)
+
+	// PushPrices выгружает цены.
+	PushPrices(ctx context.Context, prices []models.PriceUpdate) (*models.PushResult, error)
+
+	// PushStocks выгружает остатки.
+	PushStocks(ctx context.Context, stocks []models.StockUpdate) (*models.PushResult, error)
+
+	// Categories возвращает дерево категорий площадки плоским списком.
+	Categories(ctx context.Context) ([]models.ChannelCategory, error)
+}
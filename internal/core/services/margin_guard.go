@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gomarketplace_api/config"
+	"gomarketplace_api/internal/core/models"
+	"gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/internal/suppliers/wholesaler/business"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"math"
+)
+
+// MarginGuard проверяет цены перед выгрузкой на площадку: пересчитывает чистую прибыль от закупочной
+// цены wholesaler.price по профилю цен площадки и сравнивает цену продажи с последней выгруженной.
+// Цены, не прошедшие проверку, попадают в карантин и выгружаются только после ручного одобрения.
+// nil *MarginGuard пропускает все цены.
+type MarginGuard struct {
+	repo     *storage.PriceGuardRepository
+	profiles *business.PricingProfiles
+	cfg      config.MarginGuardConfig
+	log      logger.Logger
+}
+
+func NewMarginGuard(repo *storage.PriceGuardRepository, profiles *business.PricingProfiles, cfg config.MarginGuardConfig, writer io.Writer) *MarginGuard {
+	if profiles == nil {
+		profiles = business.NewPricingProfiles()
+	}
+	return &MarginGuard{
+		repo:     repo,
+		profiles: profiles,
+		cfg:      cfg,
+		log:      logger.NewLogger(writer, "[MarginGuard]"),
+	}
+}
+
+// LoadMarginGuard создаёт проверку по конфигу приложения. Возвращает nil, если проверка выключена.
+func LoadMarginGuard(db *sql.DB, cfg *config.MarginGuardConfig, pricing *config.PricingConfig, writer io.Writer) (*MarginGuard, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	profiles, err := business.LoadPricingProfiles(pricing, repositories.NewPricingProfileRepository(db))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pricing profiles: %w", err)
+	}
+	return NewMarginGuard(storage.NewPriceGuardRepository(db), profiles, *cfg, writer), nil
+}
+
+// Evaluate делит обновления на допустимые и задержанные, ничего не сохраняя.
+func (g *MarginGuard) Evaluate(ctx context.Context, marketplace string, updates []models.PriceUpdate) ([]models.PriceUpdate, []models.QuarantinedPrice, error) {
+	if g == nil || len(updates) == 0 {
+		return updates, nil, nil
+	}
+
+	ids := make([]int, 0, len(updates))
+	for _, update := range updates {
+		ids = append(ids, update.GlobalID)
+	}
+	purchases, err := g.repo.GetPurchases(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	lastPushed, err := g.repo.GetLastPushed(ctx, marketplace, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	approved, err := g.repo.GetResolved(ctx, marketplace, ids, models.QuarantineStatusApproved)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowed := make([]models.PriceUpdate, 0, len(updates))
+	var quarantined []models.QuarantinedPrice
+	for _, update := range updates {
+		if price, ok := approved[update.GlobalID]; ok && price == update.DiscountedPrice {
+			allowed = append(allowed, update)
+			continue
+		}
+
+		item := models.QuarantinedPrice{
+			Marketplace:     marketplace,
+			GlobalID:        update.GlobalID,
+			ExternalID:      update.ExternalID,
+			Price:           update.Price,
+			DiscountedPrice: update.DiscountedPrice,
+		}
+		if last, ok := lastPushed[update.GlobalID]; ok {
+			item.LastPrice = &last
+		}
+		if item.Reason = g.check(marketplace, purchases, &item); item.Reason != "" {
+			quarantined = append(quarantined, item)
+			continue
+		}
+		allowed = append(allowed, update)
+	}
+	return allowed, quarantined, nil
+}
+
+// check заполняет прибыль и маржу и возвращает причину задержки цены или пустую строку.
+func (g *MarginGuard) check(marketplace string, purchases map[int]storage.Purchase, item *models.QuarantinedPrice) string {
+	purchase, ok := purchases[item.GlobalID]
+	if !ok || purchase.Price <= 0 {
+		return "нет закупочной цены"
+	}
+	if item.DiscountedPrice <= 0 {
+		return "нулевая цена продажи"
+	}
+
+	profile := g.profiles.Resolve(marketplace, purchase.Category)
+	item.PurchasePrice = purchase.Price
+	item.Profit = business.NetProfit(item.DiscountedPrice, purchase.Price, profile)
+	item.MarginPercent = math.Round(item.Profit/float64(item.DiscountedPrice)*10000) / 100
+
+	if item.Profit < g.cfg.MinProfit {
+		return fmt.Sprintf("прибыль %.2f руб. ниже минимальной %.2f руб.", item.Profit, g.cfg.MinProfit)
+	}
+	if item.MarginPercent < g.cfg.MinMarginPercent {
+		return fmt.Sprintf("маржа %.2f%% ниже минимальной %.2f%%", item.MarginPercent, g.cfg.MinMarginPercent)
+	}
+	if g.cfg.MaxChangePercent > 0 && item.LastPrice != nil && *item.LastPrice > 0 {
+		change := math.Abs(float64(item.DiscountedPrice-*item.LastPrice)) / float64(*item.LastPrice) * 100
+		if change > g.cfg.MaxChangePercent {
+			return fmt.Sprintf("цена изменилась на %.2f%% (%d -> %d), допустимо %.2f%%",
+				change, *item.LastPrice, item.DiscountedPrice, g.cfg.MaxChangePercent)
+		}
+	}
+	return ""
+}
+
+// Check возвращает обновления, которые можно выгрузить, а остальные отправляет в карантин.
+func (g *MarginGuard) Check(ctx context.Context, marketplace string, updates []models.PriceUpdate) ([]models.PriceUpdate, error) {
+	allowed, quarantined, err := g.Evaluate(ctx, marketplace, updates)
+	if err != nil || len(quarantined) == 0 {
+		return allowed, err
+	}
+
+	rejected, err := g.repo.GetResolved(ctx, marketplace, globalIDs(quarantined), models.QuarantineStatusRejected)
+	if err != nil {
+		return nil, err
+	}
+	// отклонённая вручную цена не возвращается в карантин, пока не изменится
+	pending := make([]models.QuarantinedPrice, 0, len(quarantined))
+	for _, item := range quarantined {
+		if price, ok := rejected[item.GlobalID]; ok && price == item.DiscountedPrice {
+			continue
+		}
+		pending = append(pending, item)
+	}
+	if err := g.repo.SaveQuarantined(ctx, pending); err != nil {
+		return nil, err
+	}
+
+	g.log.Log("%s: allowed %d, quarantined %d (new or changed %d)", marketplace, len(allowed), len(quarantined), len(pending))
+	return allowed, nil
+}
+
+// Confirm запоминает выгруженные цены продажи - от них считается изменение цены при следующей проверке.
+func (g *MarginGuard) Confirm(ctx context.Context, marketplace string, pushed []models.PriceUpdate) error {
+	if g == nil || len(pushed) == 0 {
+		return nil
+	}
+	prices := make(map[int]int, len(pushed))
+	for _, update := range pushed {
+		prices[update.GlobalID] = update.DiscountedPrice
+	}
+	return g.repo.SavePushed(ctx, marketplace, prices)
+}
+
+func globalIDs(items []models.QuarantinedPrice) []int {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.GlobalID)
+	}
+	return ids
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"gomarketplace_api/config"
+	"gomarketplace_api/config/values"
+	"gomarketplace_api/internal/core/models"
+	"gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/internal/suppliers/wholesaler/business"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMarginGuardCheck(t *testing.T) {
+	guard := NewMarginGuard(nil, nil, config.MarginGuardConfig{
+		Enabled: true, MinProfit: 50, MinMarginPercent: 5, MaxChangePercent: 20,
+	}, io.Discard)
+	purchases := map[int]storage.Purchase{1: {Price: 1000}, 2: {Price: 0}}
+	// цена по профилю по умолчанию проходит все проверки
+	price := business.CalculatePrice(1, 1000, values.DefaultPricingProfile()).Z
+	last := func(v int) *int { return &v }
+
+	tests := []struct {
+		name      string
+		item      models.QuarantinedPrice
+		wantCause string
+	}{
+		{name: "нет товара в wholesaler", item: models.QuarantinedPrice{GlobalID: 3, DiscountedPrice: price}, wantCause: "нет закупочной цены"},
+		{name: "нулевая закупочная цена", item: models.QuarantinedPrice{GlobalID: 2, DiscountedPrice: price}, wantCause: "нет закупочной цены"},
+		// закупочная цена проверяется раньше цены продажи
+		{name: "нулевые обе цены", item: models.QuarantinedPrice{GlobalID: 2}, wantCause: "нет закупочной цены"},
+		{name: "нулевая цена продажи", item: models.QuarantinedPrice{GlobalID: 1}, wantCause: "нулевая цена продажи"},
+		{name: "убыток", item: models.QuarantinedPrice{GlobalID: 1, DiscountedPrice: 1100}, wantCause: "прибыль"},
+		// прибыль проверяется раньше изменения цены
+		{name: "убыток и скачок цены", item: models.QuarantinedPrice{GlobalID: 1, DiscountedPrice: 1100, LastPrice: last(price)}, wantCause: "прибыль"},
+		{name: "скачок цены", item: models.QuarantinedPrice{GlobalID: 1, DiscountedPrice: price, LastPrice: last(price / 2)}, wantCause: "цена изменилась"},
+		{name: "изменение в пределах", item: models.QuarantinedPrice{GlobalID: 1, DiscountedPrice: price, LastPrice: last(price - price/10)}},
+		{name: "первая выгрузка", item: models.QuarantinedPrice{GlobalID: 1, DiscountedPrice: price}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := tt.item
+			cause := guard.check("wildberries", purchases, &item)
+			if tt.wantCause == "" && cause != "" || !strings.HasPrefix(cause, tt.wantCause) {
+				t.Errorf("check = %q, want %q", cause, tt.wantCause)
+			}
+		})
+	}
+}
+
+func TestMarginGuardThresholds(t *testing.T) {
+	purchases := map[int]storage.Purchase{1: {Price: 1000}}
+	price := business.CalculatePrice(1, 1000, values.DefaultPricingProfile()).Z
+	item := models.QuarantinedPrice{GlobalID: 1, DiscountedPrice: price}
+	open := NewMarginGuard(nil, nil, config.MarginGuardConfig{}, io.Discard)
+	if cause := open.check("wildberries", purchases, &item); cause != "" {
+		t.Fatalf("check without thresholds = %q", cause)
+	}
+	if item.PurchasePrice != 1000 || item.Profit <= 0 || item.MarginPercent <= 0 {
+		t.Fatalf("profit not filled: %+v", item)
+	}
+
+	tests := []struct {
+		name      string
+		cfg       config.MarginGuardConfig
+		wantCause string
+	}{
+		{name: "прибыль на границе", cfg: config.MarginGuardConfig{MinProfit: item.Profit}},
+		{name: "прибыль выше цены", cfg: config.MarginGuardConfig{MinProfit: item.Profit + 0.01}, wantCause: "прибыль"},
+		{name: "маржа на границе", cfg: config.MarginGuardConfig{MinMarginPercent: item.MarginPercent}},
+		{name: "маржа выше цены", cfg: config.MarginGuardConfig{MinMarginPercent: item.MarginPercent + 0.01}, wantCause: "маржа"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := NewMarginGuard(nil, nil, tt.cfg, io.Discard)
+			checked := models.QuarantinedPrice{GlobalID: 1, DiscountedPrice: price}
+			cause := guard.check("wildberries", purchases, &checked)
+			if tt.wantCause == "" && cause != "" || !strings.HasPrefix(cause, tt.wantCause) {
+				t.Errorf("check = %q, want %q", cause, tt.wantCause)
+			}
+		})
+	}
+}
+
+func TestMarginGuardNilAllowsAll(t *testing.T) {
+	var guard *MarginGuard
+	updates := []models.PriceUpdate{{GlobalID: 1, DiscountedPrice: 1}}
+	allowed, quarantined, err := guard.Evaluate(context.Background(), "wildberries", updates)
+	if err != nil || len(allowed) != 1 || len(quarantined) != 0 {
+		t.Errorf("Evaluate on nil guard = %v, %v, %v", allowed, quarantined, err)
+	}
+}
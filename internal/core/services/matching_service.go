@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"gomarketplace_api/internal/core/models"
+	"gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// MatchingService находит одинаковые товары разных поставщиков и объединяет их в группы.
+// Основной признак - общий баркод, запасной (для товаров без баркодов) - нормализованные бренд и модель.
+type MatchingService struct {
+	repo   *storage.ProductGroupRepository
+	logger logger.Logger
+}
+
+func NewMatchingService(repo *storage.ProductGroupRepository, logWriter io.Writer) *MatchingService {
+	return &MatchingService{
+		repo:   repo,
+		logger: logger.NewLogger(logWriter, "[MatchingService]"),
+	}
+}
+
+// Rebuild пересобирает группы по текущему содержимому core.products.
+func (s *MatchingService) Rebuild(ctx context.Context) ([]models.ProductGroup, error) {
+	products, err := s.repo.GetProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := make([]offer, 0, len(products))
+	for _, product := range products {
+		id, err := strconv.Atoi(product.ID)
+		if err != nil {
+			s.logger.Log("Skipping product with invalid id %q", product.ID)
+			continue
+		}
+		data, err := product.Decode()
+		if err != nil {
+			s.logger.Log("Skipping product %d: invalid base_data: %v", id, err)
+			continue
+		}
+		offers = append(offers, offer{globalID: id, supplierID: product.SupplierID, data: data})
+	}
+
+	groups := matchOffers(offers)
+	if err := s.repo.ReplaceGroups(ctx, groups); err != nil {
+		return nil, err
+	}
+
+	s.logger.Log("Matched %d products into %d groups", len(offers), len(groups))
+	return groups, nil
+}
+
+type offer struct {
+	globalID   int
+	supplierID int
+	data       *models.ProductBaseData
+}
+
+// matchOffers группирует предложения. В результат попадают только группы из двух и более товаров.
+func matchOffers(offers []offer) []models.ProductGroup {
+	uf := newUnionFind(len(offers))
+
+	byBarcode := make(map[string]int)
+	byBrandModel := make(map[string]int)
+	for i, o := range offers {
+		for _, barcode := range o.data.Barcodes {
+			barcode = strings.TrimSpace(barcode)
+			if barcode == "" {
+				continue
+			}
+			if j, ok := byBarcode[barcode]; ok {
+				uf.union(i, j)
+			} else {
+				byBarcode[barcode] = i
+			}
+		}
+	}
+	for i, o := range offers {
+		key := brandModelKey(o.data)
+		if key == "" {
+			continue
+		}
+		j, ok := byBrandModel[key]
+		if !ok {
+			byBrandModel[key] = i
+			continue
+		}
+		// бренд+модель используем только если хотя бы у одного из товаров нет баркодов,
+		// иначе разные баркоды означают разные товары (например, разные цвета одной модели)
+		if len(o.data.Barcodes) == 0 || len(offers[j].data.Barcodes) == 0 {
+			uf.union(i, j)
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range offers {
+		root := uf.find(i)
+		members[root] = append(members[root], i)
+	}
+
+	groups := make([]models.ProductGroup, 0)
+	for _, idx := range members {
+		if len(idx) < 2 {
+			continue
+		}
+
+		group := models.ProductGroup{Members: make([]int, 0, len(idx))}
+		var barcodes []string
+		for _, i := range idx {
+			group.Members = append(group.Members, offers[i].globalID)
+			barcodes = append(barcodes, offers[i].data.Barcodes...)
+		}
+		sort.Ints(group.Members)
+
+		if len(barcodes) > 0 {
+			sort.Strings(barcodes)
+			group.MatchType = models.MatchTypeBarcode
+			group.MatchKey = models.MatchTypeBarcode + ":" + barcodes[0]
+		} else {
+			group.MatchType = models.MatchTypeBrandModel
+			group.MatchKey = models.MatchTypeBrandModel + ":" + brandModelKey(offers[idx[0]].data)
+		}
+		group.PreferredGlobalID = preferredOffer(offers, idx)
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].MatchKey < groups[j].MatchKey })
+	return groups
+}
+
+// preferredOffer выбирает предложение группы: сначала товары в наличии, затем минимальная цена, затем меньший global_id.
+func preferredOffer(offers []offer, idx []int) int {
+	best := idx[0]
+	for _, i := range idx[1:] {
+		if betterOffer(offers[i], offers[best]) {
+			best = i
+		}
+	}
+	return offers[best].globalID
+}
+
+func betterOffer(a, b offer) bool {
+	aInStock, bInStock := a.data.Stocks > 0, b.data.Stocks > 0
+	if aInStock != bInStock {
+		return aInStock
+	}
+	aPriced, bPriced := a.data.Price > 0, b.data.Price > 0
+	if aPriced != bPriced {
+		return aPriced
+	}
+	if a.data.Price != b.data.Price {
+		return a.data.Price < b.data.Price
+	}
+	return a.globalID < b.globalID
+}
+
+// brandModelKey нормализует бренд и модель: нижний регистр, только буквы и цифры.
+func brandModelKey(data *models.ProductBaseData) string {
+	brand := nonAlphanumeric.ReplaceAllString(strings.ToLower(data.Brand), "")
+	model := nonAlphanumeric.ReplaceAllString(strings.ToLower(data.Model), "")
+	if brand == "" || model == "" {
+		return ""
+	}
+	return brand + "|" + model
+}
+
+type unionFind struct {
+	parent []int
+}
+
+func newUnionFind(n int) *unionFind {
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	return &unionFind{parent: parent}
+}
+
+func (u *unionFind) find(x int) int {
+	for u.parent[x] != x {
+		u.parent[x] = u.parent[u.parent[x]]
+		x = u.parent[x]
+	}
+	return x
+}
+
+func (u *unionFind) union(a, b int) {
+	ra, rb := u.find(a), u.find(b)
+	if ra != rb {
+		u.parent[rb] = ra
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"gomarketplace_api/config"
+	"sort"
+	"sync"
+)
+
+// AdapterFactory создаёт адаптер для поставщика из конфига.
+type AdapterFactory func(db *sql.DB, supplier config.SupplierConfig) (SupplierAdapter, error)
+
+// RegisteredSupplier - поставщик из конфига, связанный с реализацией адаптера.
+type RegisteredSupplier struct {
+	Config  config.SupplierConfig
+	Adapter SupplierAdapter
+}
+
+// SupplierRegistry хранит реализации SupplierAdapter по имени.
+// Новому поставщику достаточно зарегистрировать фабрику и описать его в config.yaml.
+type SupplierRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]AdapterFactory
+}
+
+func NewSupplierRegistry() *SupplierRegistry {
+	return &SupplierRegistry{factories: make(map[string]AdapterFactory)}
+}
+
+// Register регистрирует фабрику адаптера. Повторная регистрация имени - ошибка.
+func (r *SupplierRegistry) Register(adapterName string, factory AdapterFactory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.factories[adapterName]; exists {
+		return fmt.Errorf("adapter '%s' already registered", adapterName)
+	}
+	r.factories[adapterName] = factory
+	return nil
+}
+
+// Adapters возвращает имена зарегистрированных адаптеров.
+func (r *SupplierRegistry) Adapters() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build создаёт адаптеры для включённых поставщиков из конфига.
+func (r *SupplierRegistry) Build(db *sql.DB, suppliers []config.SupplierConfig) ([]RegisteredSupplier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]struct{}, len(suppliers))
+	result := make([]RegisteredSupplier, 0, len(suppliers))
+	for _, supplier := range suppliers {
+		if !supplier.Enabled {
+			continue
+		}
+		if supplier.Name == "" {
+			return nil, fmt.Errorf("supplier name is empty")
+		}
+		if _, ok := seen[supplier.Name]; ok {
+			return nil, fmt.Errorf("supplier '%s' declared twice", supplier.Name)
+		}
+		seen[supplier.Name] = struct{}{}
+
+		adapterName := supplier.Adapter
+		if adapterName == "" {
+			adapterName = supplier.Name
+		}
+		factory, ok := r.factories[adapterName]
+		if !ok {
+			return nil, fmt.Errorf("adapter '%s' for supplier '%s' is not registered", adapterName, supplier.Name)
+		}
+
+		adapter, err := factory(db, supplier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create adapter for supplier '%s': %w", supplier.Name, err)
+		}
+		result = append(result, RegisteredSupplier{Config: supplier, Adapter: adapter})
+	}
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultSyncInterval = time.Hour
+
+// SupplierSyncService по очереди синхронизирует всех зарегистрированных поставщиков
+// и записывает результат в core.supplier_sync_status.
+type SupplierSyncService struct {
+	suppliers []RegisteredSupplier
+	repo      *storage.SupplierRepository
+	matcher   *MatchingService
+	logger    logger.Logger
+}
+
+// NewSupplierSyncService создаёт сервис синхронизации. matcher может быть nil - тогда группы товаров не пересобираются.
+func NewSupplierSyncService(suppliers []RegisteredSupplier, repo *storage.SupplierRepository, matcher *MatchingService, logWriter io.Writer) *SupplierSyncService {
+	return &SupplierSyncService{
+		suppliers: suppliers,
+		repo:      repo,
+		matcher:   matcher,
+		logger:    logger.NewLogger(logWriter, "[SupplierSyncService]"),
+	}
+}
+
+// SyncAll синхронизирует всех поставщиков. Ошибка одного поставщика не останавливает остальных.
+func (s *SupplierSyncService) SyncAll(ctx context.Context) error {
+	var failed []string
+	for _, supplier := range s.suppliers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.SyncSupplier(supplier); err != nil {
+			s.logger.Log("Supplier '%s' sync failed: %v", supplier.Config.Name, err)
+			failed = append(failed, supplier.Config.Name)
+		}
+	}
+	s.rebuildGroups(ctx)
+	if len(failed) > 0 {
+		return fmt.Errorf("sync failed for suppliers: %v", failed)
+	}
+	return nil
+}
+
+// SyncSupplier синхронизирует одного поставщика и записывает статус.
+func (s *SupplierSyncService) SyncSupplier(supplier RegisteredSupplier) error {
+	record, err := s.repo.EnsureSupplier(supplier.Config.Name)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.MarkSyncStarted(record.ID); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	products, syncErr := supplier.Adapter.SyncProducts()
+	if err := s.repo.MarkSyncFinished(record.ID, len(products), syncErr); err != nil {
+		return err
+	}
+	if syncErr != nil {
+		return syncErr
+	}
+
+	s.logger.Log("Supplier '%s' synced: %d products in %s", supplier.Config.Name, len(products), time.Since(start))
+	return nil
+}
+
+// StatusHandler возвращает состояние синхронизации всех поставщиков из core.supplier_sync_status. Только GET.
+func (s *SupplierSyncService) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		statuses, err := s.repo.GetSyncStatuses()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(statuses); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	})
+}
+
+// Run запускает синхронизацию каждого поставщика с его интервалом до отмены контекста.
+func (s *SupplierSyncService) Run(ctx context.Context) {
+	for _, supplier := range s.suppliers {
+		go s.loop(ctx, supplier)
+	}
+	<-ctx.Done()
+}
+
+func (s *SupplierSyncService) loop(ctx context.Context, supplier RegisteredSupplier) {
+	interval := supplier.Config.SyncInterval
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SyncSupplier(supplier); err != nil {
+				s.logger.Log("Supplier '%s' sync failed: %v", supplier.Config.Name, err)
+				continue
+			}
+			s.rebuildGroups(ctx)
+		}
+	}
+}
+
+// rebuildGroups пересобирает группы одинаковых товаров после изменения core.products.
+func (s *SupplierSyncService) rebuildGroups(ctx context.Context) {
+	if s.matcher == nil {
+		return
+	}
+	if _, err := s.matcher.Rebuild(ctx); err != nil {
+		s.logger.Log("Product matching failed: %v", err)
+	}
+}
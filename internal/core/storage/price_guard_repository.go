@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/core/models"
+)
+
+type PriceGuardRepository struct {
+	db *sql.DB
+}
+
+func NewPriceGuardRepository(db *sql.DB) *PriceGuardRepository {
+	return &PriceGuardRepository{db: db}
+}
+
+// Purchase - закупочная цена и категория товара поставщика.
+type Purchase struct {
+	Price    int
+	Category string
+}
+
+// GetPurchases возвращает закупочные цены и категории товаров из wholesaler.
+func (r *PriceGuardRepository) GetPurchases(ctx context.Context, ids []int) (map[int]Purchase, error) {
+	query := `
+		SELECT p.global_id, COALESCE(p.price, 0), COALESCE(pr.category, '')
+		FROM wholesaler.price p
+		LEFT JOIN wholesaler.products pr ON pr.global_id = p.global_id
+		WHERE p.global_id = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса закупочных цен: %w", err)
+	}
+	defer rows.Close()
+
+	purchases := make(map[int]Purchase, len(ids))
+	for rows.Next() {
+		var globalID int
+		var purchase Purchase
+		if err := rows.Scan(&globalID, &purchase.Price, &purchase.Category); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования закупочной цены: %w", err)
+		}
+		purchases[globalID] = purchase
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return purchases, nil
+}
+
+// GetLastPushed возвращает последние выгруженные на площадку цены продажи.
+func (r *PriceGuardRepository) GetLastPushed(ctx context.Context, marketplace string, ids []int) (map[int]int, error) {
+	query := `SELECT global_id, price FROM core.prices_pushed WHERE marketplace = $1 AND global_id = ANY($2)`
+	return r.queryPrices(ctx, query, marketplace, pq.Array(ids))
+}
+
+// GetResolved возвращает последние цены продажи из карантина с указанным статусом
+// (одобренные ещё не выгруженные или отклонённые).
+func (r *PriceGuardRepository) GetResolved(ctx context.Context, marketplace string, ids []int, status string) (map[int]int, error) {
+	query := `
+		SELECT DISTINCT ON (global_id) global_id, discounted_price FROM core.price_quarantine
+		WHERE marketplace = $1 AND global_id = ANY($2) AND status = $3
+		ORDER BY global_id, resolved_at DESC NULLS LAST, id DESC`
+	return r.queryPrices(ctx, query, marketplace, pq.Array(ids), status)
+}
+
+func (r *PriceGuardRepository) queryPrices(ctx context.Context, query string, args ...interface{}) (map[int]int, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса цен: %w", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[int]int)
+	for rows.Next() {
+		var globalID, price int
+		if err := rows.Scan(&globalID, &price); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования цены: %w", err)
+		}
+		prices[globalID] = price
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return prices, nil
+}
+
+// SaveQuarantined добавляет цены в карантин. Ожидающая решения запись товара заменяется новой ценой.
+func (r *PriceGuardRepository) SaveQuarantined(ctx context.Context, items []models.QuarantinedPrice) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO core.price_quarantine
+		    (marketplace, global_id, external_id, price, discounted_price, purchase_price,
+		     profit, margin_percent, last_price, reason, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		ON CONFLICT (marketplace, global_id) WHERE status = 'pending' DO UPDATE
+		SET external_id = EXCLUDED.external_id,
+		    price = EXCLUDED.price,
+		    discounted_price = EXCLUDED.discounted_price,
+		    purchase_price = EXCLUDED.purchase_price,
+		    profit = EXCLUDED.profit,
+		    margin_percent = EXCLUDED.margin_percent,
+		    last_price = EXCLUDED.last_price,
+		    reason = EXCLUDED.reason,
+		    created_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare quarantine insert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		var lastPrice sql.NullInt64
+		if item.LastPrice != nil {
+			lastPrice = sql.NullInt64{Int64: int64(*item.LastPrice), Valid: true}
+		}
+		if _, err := stmt.ExecContext(ctx, item.Marketplace, item.GlobalID, item.ExternalID, item.Price,
+			item.DiscountedPrice, item.PurchasePrice, item.Profit, item.MarginPercent, lastPrice, item.Reason,
+			models.QuarantineStatusPending); err != nil {
+			return fmt.Errorf("save quarantined price %d error: %w", item.GlobalID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// SavePushed запоминает выгруженные цены продажи и закрывает одобренные записи карантина этих товаров.
+func (r *PriceGuardRepository) SavePushed(ctx context.Context, marketplace string, prices map[int]int) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO core.prices_pushed (marketplace, global_id, price, pushed_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (marketplace, global_id) DO UPDATE
+		SET price = EXCLUDED.price, pushed_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare pushed prices insert error: %w", err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, len(prices))
+	for globalID, price := range prices {
+		if _, err := stmt.ExecContext(ctx, marketplace, globalID, price); err != nil {
+			return fmt.Errorf("save pushed price %d error: %w", globalID, err)
+		}
+		ids = append(ids, globalID)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE core.price_quarantine SET status = $1, resolved_at = COALESCE(resolved_at, NOW())
+		WHERE marketplace = $2 AND global_id = ANY($3) AND status = $4`,
+		models.QuarantineStatusApplied, marketplace, pq.Array(ids), models.QuarantineStatusApproved)
+	if err != nil {
+		return fmt.Errorf("close approved quarantine error: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// GetQuarantined возвращает записи карантина с указанным статусом. Пустой marketplace - все площадки.
+func (r *PriceGuardRepository) GetQuarantined(ctx context.Context, status, marketplace string) ([]models.QuarantinedPrice, error) {
+	query := `
+		SELECT id, marketplace, global_id, external_id, price, discounted_price, purchase_price,
+		       profit, margin_percent, last_price, reason, status, created_at, resolved_at
+		FROM core.price_quarantine
+		WHERE status = $1 AND ($2 = '' OR marketplace = $2)
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, status, marketplace)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса карантина цен: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.QuarantinedPrice
+	for rows.Next() {
+		var item models.QuarantinedPrice
+		var lastPrice sql.NullInt64
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&item.ID, &item.Marketplace, &item.GlobalID, &item.ExternalID, &item.Price,
+			&item.DiscountedPrice, &item.PurchasePrice, &item.Profit, &item.MarginPercent, &lastPrice,
+			&item.Reason, &item.Status, &item.CreatedAt, &resolvedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования карантина цен: %w", err)
+		}
+		if lastPrice.Valid {
+			price := int(lastPrice.Int64)
+			item.LastPrice = &price
+		}
+		if resolvedAt.Valid {
+			item.ResolvedAt = &resolvedAt.Time
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return items, nil
+}
+
+// Resolve одобряет или отклоняет ожидающие решения записи карантина. Возвращает количество изменённых записей.
+func (r *PriceGuardRepository) Resolve(ctx context.Context, ids []int64, status string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE core.price_quarantine SET status = $1, resolved_at = NOW()
+		WHERE id = ANY($2) AND status = $3`,
+		status, pq.Array(ids), models.QuarantineStatusPending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve quarantined prices: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/core/models"
+	"strconv"
+)
+
+type ProductGroupRepository struct {
+	db *sql.DB
+}
+
+func NewProductGroupRepository(db *sql.DB) *ProductGroupRepository {
+	return &ProductGroupRepository{db: db}
+}
+
+// GetProducts возвращает все товары из core.products.
+func (r *ProductGroupRepository) GetProducts(ctx context.Context) ([]*models.Product, error) {
+	query := `SELECT global_id, supplier_id, COALESCE(base_data, '{}'::jsonb), created_at, updated_at FROM core.products`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get core products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		var (
+			product  models.Product
+			globalID int
+			baseData []byte
+		)
+		if err := rows.Scan(&globalID, &product.SupplierID, &baseData, &product.CreatedAt, &product.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan core product: %w", err)
+		}
+		product.ID = strconv.Itoa(globalID)
+		product.BaseData = baseData
+		products = append(products, &product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return products, nil
+}
+
+// ReplaceGroups полностью пересобирает группы одной транзакцией.
+// Группы с прежним match_key сохраняют свой group_id.
+func (r *ProductGroupRepository) ReplaceGroups(ctx context.Context, groups []models.ProductGroup) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM core.product_group_members`); err != nil {
+		return fmt.Errorf("failed to clear group members: %w", err)
+	}
+
+	groupStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO core.product_groups (match_key, match_type, preferred_global_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (match_key) DO UPDATE
+		SET match_type = EXCLUDED.match_type,
+		    preferred_global_id = EXCLUDED.preferred_global_id,
+		    updated_at = NOW()
+		RETURNING group_id`)
+	if err != nil {
+		return fmt.Errorf("prepare group insert error: %w", err)
+	}
+	defer groupStmt.Close()
+
+	memberStmt, err := tx.PrepareContext(ctx, `INSERT INTO core.product_group_members (global_id, group_id) VALUES ($1, $2)`)
+	if err != nil {
+		return fmt.Errorf("prepare member insert error: %w", err)
+	}
+	defer memberStmt.Close()
+
+	keys := make([]string, 0, len(groups))
+	for _, group := range groups {
+		var groupID int
+		if err := groupStmt.QueryRowContext(ctx, group.MatchKey, group.MatchType, group.PreferredGlobalID).Scan(&groupID); err != nil {
+			return fmt.Errorf("insert group %s error: %w", group.MatchKey, err)
+		}
+		for _, member := range group.Members {
+			if _, err := memberStmt.ExecContext(ctx, member, groupID); err != nil {
+				return fmt.Errorf("insert group member %d error: %w", member, err)
+			}
+		}
+		keys = append(keys, group.MatchKey)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM core.product_groups WHERE NOT (match_key = ANY($1))`, pq.Array(keys)); err != nil {
+		return fmt.Errorf("failed to delete stale groups: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// GetGroups возвращает все группы вместе с участниками.
+func (r *ProductGroupRepository) GetGroups(ctx context.Context) ([]models.ProductGroup, error) {
+	query := `
+		SELECT g.group_id, g.match_key, g.match_type, COALESCE(g.preferred_global_id, 0), m.global_id
+		FROM core.product_groups g
+		JOIN core.product_group_members m ON m.group_id = g.group_id
+		ORDER BY g.group_id, m.global_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []models.ProductGroup
+	for rows.Next() {
+		var (
+			group  models.ProductGroup
+			member int
+		)
+		if err := rows.Scan(&group.ID, &group.MatchKey, &group.MatchType, &group.PreferredGlobalID, &member); err != nil {
+			return nil, fmt.Errorf("failed to scan product group: %w", err)
+		}
+		if n := len(groups); n > 0 && groups[n-1].ID == group.ID {
+			groups[n-1].Members = append(groups[n-1].Members, member)
+			continue
+		}
+		group.Members = []int{member}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return groups, nil
+}
+
+// GetNonPreferredIDs возвращает global_id товаров, которые входят в группу, но не являются её предпочтительным предложением.
+// Такие товары не нужно выгружать на маркетплейсы, чтобы не плодить дубли карточек.
+func (r *ProductGroupRepository) GetNonPreferredIDs(ctx context.Context) (map[int]struct{}, error) {
+	query := `
+		SELECT m.global_id
+		FROM core.product_group_members m
+		JOIN core.product_groups g ON g.group_id = m.group_id
+		WHERE g.preferred_global_id IS DISTINCT FROM m.global_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get non preferred products: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]struct{})
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan global_id: %w", err)
+		}
+		ids[id] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return ids, nil
+}
+
+// ExcludeNonPreferred убирает из ids товары-дубли, которые не являются предпочтительным предложением своей группы.
+// Возвращает оставшиеся ids в исходном порядке.
+func (r *ProductGroupRepository) ExcludeNonPreferred(ctx context.Context, ids []int) ([]int, error) {
+	nonPreferred, err := r.GetNonPreferredIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := nonPreferred[id]; !ok {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gomarketplace_api/internal/core/models"
+	"strconv"
+)
+
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// SupplierByName возвращает поставщика из core.suppliers по имени.
+func (r *ProductRepository) SupplierByName(name string) (*models.Supplier, error) {
+	query := `SELECT supplier_id, name, created_at, updated_at FROM core.suppliers WHERE name = $1`
+
+	var supplier models.Supplier
+	err := r.db.QueryRow(query, name).Scan(&supplier.ID, &supplier.Name, &supplier.CreatedAt, &supplier.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("supplier '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+	return &supplier, nil
+}
+
+// UpsertProducts записывает товары в core.products одной транзакцией. Товар определяется парой
+// (supplier_id, supplier_articul из base_data); base_data перезаписывается только если документ изменился,
+// поставщик товара не меняется. Товары, global_id которых уже занят другим поставщиком или артикулом,
+// пропускаются и возвращаются в skipped. Возвращает число изменённых строк.
+func (r *ProductRepository) UpsertProducts(ctx context.Context, products []*models.Product) (affected int, skipped []models.ProductCollision, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	collisionStmt, err := tx.PrepareContext(ctx, `
+		SELECT supplier_id, supplier_articul FROM core.products
+		WHERE global_id = $1 AND (supplier_id <> $2 OR supplier_articul <> $3)`)
+	if err != nil {
+		return 0, nil, fmt.Errorf("prepare collision check error: %w", err)
+	}
+	defer collisionStmt.Close()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO core.products (global_id, supplier_id, supplier_articul, base_data, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (supplier_id, supplier_articul) DO UPDATE
+		SET base_data = EXCLUDED.base_data,
+		    updated_at = NOW()
+		WHERE core.products.base_data IS DISTINCT FROM EXCLUDED.base_data`)
+	if err != nil {
+		return 0, nil, fmt.Errorf("prepare upsert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, product := range products {
+		globalID, err := strconv.Atoi(product.ID)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid product id %q: %w", product.ID, err)
+		}
+		data, err := product.Decode()
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid base_data of product %d: %w", globalID, err)
+		}
+		if data.SupplierArticul == "" {
+			return 0, nil, fmt.Errorf("product %d: supplier_articul is required", globalID)
+		}
+
+		collision := models.ProductCollision{GlobalID: globalID, SupplierID: product.SupplierID, SupplierArticul: data.SupplierArticul}
+		err = collisionStmt.QueryRowContext(ctx, globalID, product.SupplierID, data.SupplierArticul).Scan(&collision.OwnerID, &collision.OwnerArticul)
+		if err == nil {
+			skipped = append(skipped, collision)
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, fmt.Errorf("check product %d error: %w", globalID, err)
+		}
+
+		res, err := stmt.ExecContext(ctx, globalID, product.SupplierID, data.SupplierArticul, []byte(product.BaseData))
+		if err != nil {
+			return 0, nil, fmt.Errorf("upsert product %d error: %w", globalID, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			affected += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("commit error: %w", err)
+	}
+	return affected, skipped, nil
+}
+
+// GetProductByID возвращает товар из core.products. Если товара нет - nil, nil.
+func (r *ProductRepository) GetProductByID(id int) (*models.Product, error) {
+	query := `SELECT global_id, supplier_id, COALESCE(base_data, '{}'::jsonb), created_at, updated_at
+			  FROM core.products WHERE global_id = $1`
+
+	var (
+		product  models.Product
+		globalID int
+		baseData []byte
+	)
+	err := r.db.QueryRow(query, id).Scan(&globalID, &product.SupplierID, &baseData, &product.CreatedAt, &product.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get core product: %w", err)
+	}
+	product.ID = strconv.Itoa(globalID)
+	product.BaseData = baseData
+	return &product, nil
+}
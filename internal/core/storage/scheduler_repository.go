@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gomarketplace_api/pkg/scheduler"
+)
+
+// SchedulerRepository хранит состояние задач планировщика в core.scheduler_jobs.
+type SchedulerRepository struct {
+	db *sql.DB
+}
+
+func NewSchedulerRepository(db *sql.DB) *SchedulerRepository {
+	return &SchedulerRepository{db: db}
+}
+
+// LoadStates возвращает последнее сохранённое состояние задач по имени.
+func (r *SchedulerRepository) LoadStates(ctx context.Context) (map[string]scheduler.JobState, error) {
+	query := `
+		SELECT job_name, status, trigger, COALESCE(error, ''), started_at, finished_at, last_success_at
+		FROM core.scheduler_jobs`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler jobs: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]scheduler.JobState)
+	for rows.Next() {
+		var state scheduler.JobState
+		if err := rows.Scan(&state.Name, &state.Status, &state.Trigger, &state.Error,
+			&state.StartedAt, &state.FinishedAt, &state.LastSuccessAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduler job: %w", err)
+		}
+		states[state.Name] = state
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return states, nil
+}
+
+// SaveState записывает состояние задачи.
+func (r *SchedulerRepository) SaveState(ctx context.Context, state scheduler.JobState) error {
+	query := `
+		INSERT INTO core.scheduler_jobs (job_name, status, trigger, error, started_at, finished_at, last_success_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+		ON CONFLICT (job_name) DO UPDATE
+		SET status = EXCLUDED.status,
+		    trigger = EXCLUDED.trigger,
+		    error = EXCLUDED.error,
+		    started_at = EXCLUDED.started_at,
+		    finished_at = EXCLUDED.finished_at,
+		    last_success_at = EXCLUDED.last_success_at`
+
+	if _, err := r.db.ExecContext(ctx, query, state.Name, state.Status, state.Trigger, state.Error,
+		state.StartedAt, state.FinishedAt, state.LastSuccessAt); err != nil {
+		return fmt.Errorf("failed to save scheduler job '%s': %w", state.Name, err)
+	}
+	return nil
+}
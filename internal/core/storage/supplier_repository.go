@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"gomarketplace_api/internal/core/models"
+)
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+// EnsureSupplier создаёт поставщика в core.suppliers, если его ещё нет, и возвращает запись.
+func (r *SupplierRepository) EnsureSupplier(name string) (*models.Supplier, error) {
+	query := `
+		INSERT INTO core.suppliers (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING supplier_id, name, created_at, updated_at`
+
+	var supplier models.Supplier
+	err := r.db.QueryRow(query, name).Scan(&supplier.ID, &supplier.Name, &supplier.CreatedAt, &supplier.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure supplier '%s': %w", name, err)
+	}
+	return &supplier, nil
+}
+
+// MarkSyncStarted отмечает начало синхронизации поставщика.
+func (r *SupplierRepository) MarkSyncStarted(supplierID int) error {
+	query := `
+		INSERT INTO core.supplier_sync_status (supplier_id, status, started_at, finished_at, error)
+		VALUES ($1, $2, NOW(), NULL, NULL)
+		ON CONFLICT (supplier_id) DO UPDATE
+		SET status = EXCLUDED.status, started_at = EXCLUDED.started_at, finished_at = NULL, error = NULL`
+
+	if _, err := r.db.Exec(query, supplierID, models.SyncStatusRunning); err != nil {
+		return fmt.Errorf("failed to mark sync start: %w", err)
+	}
+	return nil
+}
+
+// MarkSyncFinished записывает результат синхронизации. Если syncErr != nil - синхронизация считается упавшей.
+func (r *SupplierRepository) MarkSyncFinished(supplierID int, productsCount int, syncErr error) error {
+	status := models.SyncStatusSuccess
+	var errText sql.NullString
+	if syncErr != nil {
+		status = models.SyncStatusFailed
+		errText = sql.NullString{String: syncErr.Error(), Valid: true}
+	}
+
+	query := `
+		UPDATE core.supplier_sync_status
+		SET status = $2,
+		    products_count = $3,
+		    error = $4,
+		    finished_at = NOW(),
+		    last_success_at = CASE WHEN $2 = 'success' THEN NOW() ELSE last_success_at END
+		WHERE supplier_id = $1`
+
+	if _, err := r.db.Exec(query, supplierID, status, productsCount, errText); err != nil {
+		return fmt.Errorf("failed to mark sync finish: %w", err)
+	}
+	return nil
+}
+
+// GetSyncStatuses возвращает состояние синхронизации всех поставщиков.
+func (r *SupplierRepository) GetSyncStatuses() ([]models.SupplierSyncStatus, error) {
+	query := `
+		SELECT s.supplier_id, s.name, COALESCE(st.status, ''), COALESCE(st.products_count, 0), COALESCE(st.error, ''),
+		       st.started_at, st.finished_at, st.last_success_at
+		FROM core.suppliers s
+		LEFT JOIN core.supplier_sync_status st ON st.supplier_id = s.supplier_id
+		ORDER BY s.supplier_id`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync statuses: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []models.SupplierSyncStatus
+	for rows.Next() {
+		var status models.SupplierSyncStatus
+		if err := rows.Scan(&status.SupplierID, &status.SupplierName, &status.Status, &status.ProductsCount,
+			&status.Error, &status.StartedAt, &status.FinishedAt, &status.LastSuccessAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sync status: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return statuses, nil
+}
@@ -9,6 +9,21 @@ import (
 type CoreSuppliers struct{}
 
 func (m *CoreSuppliers) UpMigration(db *sql.DB) error {
+	// таблица должна существовать до проверки наличия поставщика
+	query := `
+    CREATE SCHEMA IF NOT EXISTS core;
+    
+    CREATE TABLE IF NOT EXISTS core.suppliers (
+        supplier_id SERIAL PRIMARY KEY,
+        name VARCHAR(255) UNIQUE NOT NULL,
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );`
+
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create core.suppliers table: %w", err)
+	}
+
 	var exists bool
 	err := db.QueryRow(
 		"SELECT EXISTS(SELECT 1 FROM core.suppliers WHERE name = 'wholesaler')").Scan(&exists)
@@ -22,21 +37,7 @@ func (m *CoreSuppliers) UpMigration(db *sql.DB) error {
 		return nil
 	}
 
-	query := `
-    CREATE SCHEMA IF NOT EXISTS core;
-    
-    CREATE TABLE IF NOT EXISTS core.suppliers (
-        supplier_id SERIAL PRIMARY KEY,
-        name VARCHAR(255) UNIQUE NOT NULL,
-        created_at TIMESTAMP DEFAULT NOW(),
-        updated_at TIMESTAMP DEFAULT NOW()
-    );
-    
-    INSERT INTO core.suppliers (name) 
-    VALUES ('wholesaler') 
-    ON CONFLICT DO NOTHING;`
-
-	_, err = db.Exec(query)
+	_, err = db.Exec(`INSERT INTO core.suppliers (name) VALUES ('wholesaler') ON CONFLICT DO NOTHING;`)
 	return err
 }
 
@@ -64,6 +65,26 @@ func (m *CoreProducts) UpMigration(db *sql.DB) error {
 	return err
 }
 
+// CoreProductsSupplierArticul добавляет в core.products артикул поставщика: товар определяется парой
+// (supplier_id, supplier_articul), а не global_id, который у разных поставщиков может совпасть.
+type CoreProductsSupplierArticul struct{}
+
+func (m *CoreProductsSupplierArticul) UpMigration(db *sql.DB) error {
+	query := `
+    ALTER TABLE core.products ADD COLUMN IF NOT EXISTS supplier_articul TEXT;
+
+    UPDATE core.products
+    SET supplier_articul = COALESCE(NULLIF(base_data->>'supplier_articul', ''), global_id::text)
+    WHERE supplier_articul IS NULL;
+
+    ALTER TABLE core.products ALTER COLUMN supplier_articul SET NOT NULL;
+
+    CREATE UNIQUE INDEX IF NOT EXISTS ux_products_supplier_articul ON core.products (supplier_id, supplier_articul);`
+
+	_, err := db.Exec(query)
+	return err
+}
+
 type CoreRelations struct{}
 
 func (m *CoreRelations) UpMigration(db *sql.DB) error {
@@ -82,3 +103,45 @@ func (m *CoreRelations) UpMigration(db *sql.DB) error {
 	}
 	return nil
 }
+
+type CoreSupplierSyncStatus struct{}
+
+func (m *CoreSupplierSyncStatus) UpMigration(db *sql.DB) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS core.supplier_sync_status (
+        supplier_id INT PRIMARY KEY REFERENCES core.suppliers(supplier_id),
+        status VARCHAR(32) NOT NULL,
+        products_count INT DEFAULT 0,
+        error TEXT,
+        started_at TIMESTAMP,
+        finished_at TIMESTAMP,
+        last_success_at TIMESTAMP
+    );`
+
+	_, err := db.Exec(query)
+	return err
+}
+
+type CoreProductGroups struct{}
+
+func (m *CoreProductGroups) UpMigration(db *sql.DB) error {
+	query := `
+    CREATE TABLE IF NOT EXISTS core.product_groups (
+        group_id SERIAL PRIMARY KEY,
+        match_key TEXT UNIQUE NOT NULL,
+        match_type VARCHAR(32) NOT NULL,
+        preferred_global_id INT REFERENCES core.products(global_id) ON DELETE SET NULL,
+        created_at TIMESTAMP DEFAULT NOW(),
+        updated_at TIMESTAMP DEFAULT NOW()
+    );
+
+    CREATE TABLE IF NOT EXISTS core.product_group_members (
+        global_id INT PRIMARY KEY REFERENCES core.products(global_id) ON DELETE CASCADE,
+        group_id INT NOT NULL REFERENCES core.product_groups(group_id) ON DELETE CASCADE
+    );
+
+    CREATE INDEX IF NOT EXISTS idx_product_group_members_group_id ON core.product_group_members (group_id);`
+
+	_, err := db.Exec(query)
+	return err
+}
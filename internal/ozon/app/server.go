@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"gomarketplace_api/config"
+	"gomarketplace_api/internal/core"
+	coreservices "gomarketplace_api/internal/core/services"
+	corestorage "gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/internal/ozon/business/services"
+	"gomarketplace_api/internal/ozon/business/services/get"
+	"gomarketplace_api/internal/ozon/business/services/update"
+	"gomarketplace_api/internal/ozon/pkg/clients"
+	"gomarketplace_api/internal/ozon/storage"
+	registry "gomarketplace_api/internal/registry/business"
+	registrystorage "gomarketplace_api/internal/registry/storage"
+	"gomarketplace_api/migrations/infrastructure"
+	"gomarketplace_api/migrations/marketplaces/ozon"
+	"gomarketplace_api/pkg/dbconnect"
+	"gomarketplace_api/pkg/dbconnect/migration"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"time"
+)
+
+type OzonServer struct {
+	dbconnect.Database
+	config.OzonConfig
+	wsClientUrl string
+	marginGuard *config.MarginGuardConfig
+	pricing     *config.PricingConfig
+	log         logger.Logger
+	writer      io.Writer
+}
+
+func NewOzonServer(connector dbconnect.Database, ozonConfig config.OzonConfig, wsClientUrl string, writer io.Writer) *OzonServer {
+	_log := logger.NewLogger(writer, "[OzonServer]")
+	return &OzonServer{Database: connector, OzonConfig: ozonConfig, wsClientUrl: wsClientUrl, log: _log, writer: writer}
+}
+
+// WithMarginGuard включает проверку маржи цен перед выгрузкой на Ozon.
+func (s *OzonServer) WithMarginGuard(guard *config.MarginGuardConfig, pricing *config.PricingConfig) *OzonServer {
+	s.marginGuard, s.pricing = guard, pricing
+	return s
+}
+
+func (s *OzonServer) Run() {
+	if err := s.Sync(context.Background()); err != nil {
+		s.log.Log("Ozon sync failed: %v", err)
+	}
+}
+
+// Sync применяет миграции, синхронизирует товары площадки, создаёт карточки для новых товаров wholesaler
+// и выгружает цены и остатки.
+func (s *OzonServer) Sync(ctx context.Context) error {
+	auth := services.NewClientAuth(s.ClientID, s.ApiKey)
+	if auth == nil {
+		s.log.Log("Ozon client_id/api_key not configured. Skipping.")
+		return nil
+	}
+
+	db, err := s.Connect()
+	if err != nil {
+		return fmt.Errorf("error connecting to PostgreSQL: %w", err)
+	}
+	defer db.Close()
+
+	migrationApply := []migration.MigrationInterface{
+		&ozon.CreateOzonSchema{},
+		&ozon.OzonProducts{},
+		&ozon.OzonImportTasks{},
+		&infrastructure.RegistrySchema{},
+		&infrastructure.RegistrySupplierTable{},
+		&infrastructure.RegistryArticularAccountingTable{},
+		&infrastructure.RegistryArticularSequence{},
+		&core.CoreSuppliers{},
+		&core.CoreProducts{},
+		&core.CoreProductsSupplierArticul{},
+		&core.CoreProductGroups{},
+	}
+	for _, _migration := range migrationApply {
+		if err := _migration.UpMigration(db); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	}
+	s.log.Log("Ozon migrations applied successfully!")
+
+	client := clients.NewOzonClient(s.BaseURL, auth, s.writer)
+	wsClient, err := clients.NewWServiceClient(s.wsClientUrl, s.writer)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler client: %w", err)
+	}
+	repo := storage.NewProductRepository(db)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	articular := registry.NewArticularService(registrystorage.NewArticularRepository(db), s.OzonIdentity.Code, s.OzonIdentity.Supplier)
+	syncService := get.NewProductSyncService(client, repo, articular, s.writer)
+	if _, err := syncService.Sync(ctx); err != nil {
+		return fmt.Errorf("ozon products sync failed: %w", err)
+	}
+
+	cardService := update.NewCardService(client, wsClient, repo, articular, s.OzonConfig, s.writer)
+	cardService.SetProductGroups(corestorage.NewProductGroupRepository(db))
+	if err := cardService.CheckImportTasks(ctx); err != nil {
+		s.log.Log("Ozon import tasks check failed: %v", err)
+	}
+	if s.OzonValues.DescriptionCategoryID == 0 || s.OzonValues.TypeID == 0 {
+		s.log.Log("Ozon description-category-id/type-id not configured, cards creation skipped.")
+	} else if tasks, err := cardService.PrepareAndUpload(ctx, nil); err != nil {
+		s.log.Log("Ozon cards creation failed: %v", err)
+	} else {
+		s.log.Log("Ozon cards sent in %d import tasks", len(tasks))
+	}
+
+	guard, guardErr := coreservices.LoadMarginGuard(db, s.marginGuard, s.pricing, s.writer)
+	priceStockService := update.NewPriceStockService(client, wsClient, repo, guard, s.WarehouseID, s.writer)
+	if guardErr != nil {
+		s.log.Log("Ozon prices push skipped, margin guard failed: %v", guardErr)
+	} else if _, err := priceStockService.PushPrices(ctx); err != nil {
+		s.log.Log("Ozon prices push failed: %v", err)
+	}
+	if _, err := priceStockService.PushStocks(ctx); err != nil {
+		s.log.Log("Ozon stocks push failed: %v", err)
+	}
+	return nil
+}
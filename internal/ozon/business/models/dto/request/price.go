@@ -0,0 +1,29 @@
+package request
+
+// PricesRequest - тело запроса /v1/product/import/prices.
+type PricesRequest struct {
+	Prices []PriceItem `json:"prices"`
+}
+
+type PriceItem struct {
+	OfferID           string `json:"offer_id"`
+	ProductID         int64  `json:"product_id,omitempty"`
+	Price             string `json:"price"`
+	OldPrice          string `json:"old_price"`
+	PremiumPrice      string `json:"premium_price,omitempty"`
+	MinPrice          string `json:"min_price,omitempty"`
+	CurrencyCode      string `json:"currency_code"`
+	AutoActionEnabled string `json:"auto_action_enabled"`
+}
+
+// StocksRequest - тело запроса /v2/products/stocks.
+type StocksRequest struct {
+	Stocks []StockItem `json:"stocks"`
+}
+
+type StockItem struct {
+	OfferID     string `json:"offer_id"`
+	ProductID   int64  `json:"product_id,omitempty"`
+	Stock       int    `json:"stock"`
+	WarehouseID int64  `json:"warehouse_id"`
+}
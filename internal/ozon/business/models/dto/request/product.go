@@ -0,0 +1,64 @@
+package request
+
+// ProductListRequest - тело запроса /v3/product/list.
+type ProductListRequest struct {
+	Filter ProductListFilter `json:"filter"`
+	LastID string            `json:"last_id"`
+	Limit  int               `json:"limit"`
+}
+
+type ProductListFilter struct {
+	OfferID    []string `json:"offer_id,omitempty"`
+	ProductID  []int64  `json:"product_id,omitempty"`
+	Visibility string   `json:"visibility"`
+}
+
+// ProductInfoRequest - тело запроса /v3/product/info/list.
+type ProductInfoRequest struct {
+	OfferID   []string `json:"offer_id,omitempty"`
+	ProductID []int64  `json:"product_id,omitempty"`
+	SKU       []int64  `json:"sku,omitempty"`
+}
+
+// ProductImportRequest - тело запроса /v3/product/import.
+type ProductImportRequest struct {
+	Items []ProductImportItem `json:"items"`
+}
+
+type ProductImportItem struct {
+	OfferID               string      `json:"offer_id"`
+	Name                  string      `json:"name"`
+	DescriptionCategoryID int64       `json:"description_category_id"`
+	TypeID                int64       `json:"type_id"`
+	Barcode               string      `json:"barcode,omitempty"`
+	Price                 string      `json:"price"`
+	OldPrice              string      `json:"old_price,omitempty"`
+	PremiumPrice          string      `json:"premium_price,omitempty"`
+	CurrencyCode          string      `json:"currency_code"`
+	Vat                   string      `json:"vat"`
+	Height                int         `json:"height"`
+	Width                 int         `json:"width"`
+	Depth                 int         `json:"depth"`
+	DimensionUnit         string      `json:"dimension_unit"`
+	Weight                int         `json:"weight"`
+	WeightUnit            string      `json:"weight_unit"`
+	PrimaryImage          string      `json:"primary_image,omitempty"`
+	Images                []string    `json:"images"`
+	Attributes            []Attribute `json:"attributes"`
+}
+
+type Attribute struct {
+	ID        int64            `json:"id"`
+	ComplexID int64            `json:"complex_id"`
+	Values    []AttributeValue `json:"values"`
+}
+
+type AttributeValue struct {
+	DictionaryValueID int64  `json:"dictionary_value_id,omitempty"`
+	Value             string `json:"value"`
+}
+
+// ImportInfoRequest - тело запроса /v1/product/import/info.
+type ImportInfoRequest struct {
+	TaskID int64 `json:"task_id"`
+}
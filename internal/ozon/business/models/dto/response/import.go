@@ -0,0 +1,40 @@
+package response
+
+type ProductImportResponse struct {
+	Result struct {
+		TaskID int64 `json:"task_id"`
+	} `json:"result"`
+}
+
+type ImportInfoResponse struct {
+	Result ImportInfoResult `json:"result"`
+}
+
+type ImportInfoResult struct {
+	Items []ImportInfoItem `json:"items"`
+	Total int              `json:"total"`
+}
+
+type ImportInfoItem struct {
+	OfferID   string      `json:"offer_id"`
+	ProductID int64       `json:"product_id"`
+	Status    string      `json:"status"`
+	Errors    []ItemError `json:"errors"`
+}
+
+type ItemError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// UpdateResponse - ответ /v1/product/import/prices и /v2/products/stocks.
+type UpdateResponse struct {
+	Result []UpdateResult `json:"result"`
+}
+
+type UpdateResult struct {
+	OfferID   string      `json:"offer_id"`
+	ProductID int64       `json:"product_id"`
+	Updated   bool        `json:"updated"`
+	Errors    []ItemError `json:"errors"`
+}
@@ -0,0 +1,66 @@
+package response
+
+type ProductListResponse struct {
+	Result ProductListResult `json:"result"`
+}
+
+type ProductListResult struct {
+	Items  []ProductListItem `json:"items"`
+	Total  int               `json:"total"`
+	LastID string            `json:"last_id"`
+}
+
+type ProductListItem struct {
+	ProductID int64  `json:"product_id"`
+	OfferID   string `json:"offer_id"`
+	Archived  bool   `json:"archived"`
+}
+
+type ProductInfoResponse struct {
+	Items []ProductInfo `json:"items"`
+}
+
+type ProductInfo struct {
+	ID         int64           `json:"id"`
+	Name       string          `json:"name"`
+	OfferID    string          `json:"offer_id"`
+	Barcodes   []string        `json:"barcodes"`
+	Price      string          `json:"price"`
+	OldPrice   string          `json:"old_price"`
+	IsArchived bool            `json:"is_archived"`
+	Sources    []ProductSource `json:"sources"`
+	Stocks     ProductStocks   `json:"stocks"`
+}
+
+type ProductSource struct {
+	SKU    int64  `json:"sku"`
+	Source string `json:"source"`
+}
+
+type ProductStocks struct {
+	Stocks []ProductStock `json:"stocks"`
+}
+
+type ProductStock struct {
+	Present  int    `json:"present"`
+	Reserved int    `json:"reserved"`
+	SKU      int64  `json:"sku"`
+	Source   string `json:"source"`
+}
+
+// SKU возвращает первый SKU товара на Ozon.
+func (p *ProductInfo) SKU() int64 {
+	if len(p.Sources) == 0 {
+		return 0
+	}
+	return p.Sources[0].SKU
+}
+
+// Present возвращает суммарный доступный остаток.
+func (p *ProductInfo) Present() int {
+	total := 0
+	for _, stock := range p.Stocks.Stocks {
+		total += stock.Present - stock.Reserved
+	}
+	return total
+}
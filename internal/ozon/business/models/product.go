@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Product - товар продавца на Ozon (таблица ozon.products).
+type Product struct {
+	ProductID int64     `json:"product_id"`
+	OfferID   string    `json:"offer_id"`
+	GlobalID  int       `json:"global_id"` // внутренний артикул из offer_id, 0 если offer_id не наш
+	SKU       int64     `json:"sku"`
+	Name      string    `json:"name"`
+	Barcodes  []string  `json:"barcodes"`
+	Price     float64   `json:"price"`
+	OldPrice  float64   `json:"old_price"`
+	Stocks    int       `json:"stocks"`
+	Archived  bool      `json:"archived"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
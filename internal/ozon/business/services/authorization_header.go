@@ -0,0 +1,32 @@
+package services
+
+import (
+	"net/http"
+)
+
+type AuthEngine interface {
+	GetApiKey() string
+	SetApiKey(request *http.Request)
+}
+
+// ClientAuth авторизует запросы к Ozon Seller API парой Client-Id / Api-Key.
+type ClientAuth struct {
+	clientID string
+	apiKey   string
+}
+
+func (a *ClientAuth) GetApiKey() string {
+	return a.apiKey
+}
+
+func (a *ClientAuth) SetApiKey(request *http.Request) {
+	request.Header.Set("Client-Id", a.clientID)
+	request.Header.Set("Api-Key", a.apiKey)
+}
+
+func NewClientAuth(clientID, apiKey string) *ClientAuth {
+	if clientID == "" || apiKey == "" {
+		return nil
+	}
+	return &ClientAuth{clientID: clientID, apiKey: apiKey}
+}
@@ -0,0 +1,111 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	"gomarketplace_api/internal/ozon/business/models"
+	"gomarketplace_api/internal/ozon/pkg/clients"
+	"gomarketplace_api/internal/ozon/storage"
+	registry "gomarketplace_api/internal/registry/business"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"strconv"
+)
+
+const (
+	productListLimit = 1000
+	productInfoLimit = 1000
+)
+
+// ProductSyncService выгружает список товаров продавца с Ozon в ozon.products.
+type ProductSyncService struct {
+	client    *clients.OzonClient
+	repo      *storage.ProductRepository
+	articular *registry.ArticularService
+	log       logger.Logger
+}
+
+func NewProductSyncService(client *clients.OzonClient, repo *storage.ProductRepository, articular *registry.ArticularService, writer io.Writer) *ProductSyncService {
+	return &ProductSyncService{
+		client:    client,
+		repo:      repo,
+		articular: articular,
+		log:       logger.NewLogger(writer, "[Ozon ProductSync]"),
+	}
+}
+
+// Sync загружает все товары продавца постранично и сохраняет их. Возвращает количество товаров.
+func (s *ProductSyncService) Sync(ctx context.Context) (int, error) {
+	var (
+		productIDs []int64
+		archived   = make(map[int64]bool)
+		lastID     string
+	)
+	for {
+		page, err := s.client.ProductList(ctx, lastID, productListLimit)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get product list: %w", err)
+		}
+		for _, item := range page.Result.Items {
+			productIDs = append(productIDs, item.ProductID)
+			archived[item.ProductID] = item.Archived
+		}
+		if len(page.Result.Items) < productListLimit || page.Result.LastID == "" {
+			break
+		}
+		lastID = page.Result.LastID
+	}
+
+	synced := 0
+	for start := 0; start < len(productIDs); start += productInfoLimit {
+		end := min(start+productInfoLimit, len(productIDs))
+
+		info, err := s.client.ProductInfo(ctx, productIDs[start:end])
+		if err != nil {
+			return synced, fmt.Errorf("failed to get product info: %w", err)
+		}
+
+		offerIDs := make([]string, len(info.Items))
+		for i, item := range info.Items {
+			offerIDs[i] = item.OfferID
+		}
+		// global_id - артикул поставщика карточек; у товаров других поставщиков он остаётся 0
+		globalIDs, err := s.articular.SupplierArticuls(offerIDs)
+		if err != nil {
+			return synced, fmt.Errorf("failed to resolve offer ids: %w", err)
+		}
+
+		products := make([]models.Product, 0, len(info.Items))
+		for _, item := range info.Items {
+			product := models.Product{
+				ProductID: item.ID,
+				OfferID:   item.OfferID,
+				SKU:       item.SKU(),
+				Name:      item.Name,
+				Barcodes:  item.Barcodes,
+				Price:     parsePrice(item.Price),
+				OldPrice:  parsePrice(item.OldPrice),
+				Stocks:    item.Present(),
+				Archived:  item.IsArchived || archived[item.ID],
+				GlobalID:  globalIDs[item.OfferID],
+			}
+			products = append(products, product)
+		}
+
+		if err := s.repo.UpsertProducts(ctx, products); err != nil {
+			return synced, err
+		}
+		synced += len(products)
+	}
+
+	s.log.Log("Synced %d ozon products", synced)
+	return synced, nil
+}
+
+func parsePrice(value string) float64 {
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return price
+}
@@ -0,0 +1,255 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"gomarketplace_api/config"
+	corestorage "gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/internal/ozon/business/models/dto/request"
+	"gomarketplace_api/internal/ozon/pkg/clients"
+	"gomarketplace_api/internal/ozon/storage"
+	registry "gomarketplace_api/internal/registry/business"
+	pkg2 "gomarketplace_api/internal/suppliers/wholesaler/pkg"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"strconv"
+)
+
+const (
+	importBatchSize = 100
+
+	brandAttributeID      = 85
+	annotationAttributeID = 4191
+	noBrandValue          = "Нет бренда"
+)
+
+// vendorCoder выдаёт vendor code товарам поставщика карточек; реализуется registry.ArticularService.
+type vendorCoder interface {
+	VendorCodes(supplierArticuls []int) (map[int]string, error)
+}
+
+// CardService собирает карточки Ozon из данных wholesaler и отправляет их на импорт.
+type CardService struct {
+	client    *clients.OzonClient
+	wsclient  *clients.WServiceClient
+	repo      *storage.ProductRepository
+	articular vendorCoder
+	// groups - группы дублей товаров: карточки создаются только для предпочтительных предложений
+	groups *corestorage.ProductGroupRepository
+	config config.OzonConfig
+	log    logger.Logger
+}
+
+func NewCardService(
+	client *clients.OzonClient,
+	wsclient *clients.WServiceClient,
+	repo *storage.ProductRepository,
+	articular *registry.ArticularService,
+	ozonConfig config.OzonConfig,
+	writer io.Writer) *CardService {
+	return &CardService{
+		client:    client,
+		wsclient:  wsclient,
+		repo:      repo,
+		articular: articular,
+		config:    ozonConfig,
+		log:       logger.NewLogger(writer, "[Ozon CardService]"),
+	}
+}
+
+// Prepare собирает карточки для товаров wholesaler. Товары без названия, цены или изображений пропускаются.
+func (s *CardService) Prepare(ctx context.Context, ids []int) ([]request.ProductImportItem, error) {
+	filter := requests.FilterRequest{ProductIDs: ids}
+
+	appellations, err := pkg2.FetchStrings(ctx, s.wsclient.FetcherChain, "appellations", requests.AppellationsRequest{FilterRequest: filter})
+	if err != nil {
+		return nil, err
+	}
+	descriptions, err := pkg2.FetchStrings(ctx, s.wsclient.FetcherChain, "descriptions", requests.DescriptionRequest{FilterRequest: filter})
+	if err != nil {
+		return nil, err
+	}
+	brands, err := pkg2.FetchStrings(ctx, s.wsclient.FetcherChain, "brands", requests.BrandRequest{FilterRequest: filter})
+	if err != nil {
+		return nil, err
+	}
+	prices, err := pkg2.FetchPrices(ctx, s.wsclient.FetcherChain, marketplaceName, ids)
+	if err != nil {
+		return nil, err
+	}
+	barcodes, err := pkg2.FetchBarcodes(ctx, s.wsclient.FetcherChain, ids)
+	if err != nil {
+		return nil, err
+	}
+	media, err := pkg2.FetchMedia(ctx, s.wsclient.FetcherChain, ids)
+	if err != nil {
+		return nil, err
+	}
+	vendorCodes, err := s.articular.VendorCodes(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	values := s.config.OzonValues
+	items := make([]request.ProductImportItem, 0, len(ids))
+	for _, id := range ids {
+		title, ok := appellations[id]
+		if !ok {
+			s.log.Log("Excluded ID: %d, Reason: no appellation", id)
+			continue
+		}
+		price, ok := prices[id]
+		if !ok || price.Z <= 0 {
+			s.log.Log("Excluded ID: %d, Reason: no price", id)
+			continue
+		}
+		images := media[id]
+		if len(images) == 0 {
+			s.log.Log("Excluded ID: %d, Reason: no media", id)
+			continue
+		}
+
+		brand := brands[id]
+		if brand == "" {
+			brand = noBrandValue
+		}
+
+		item := request.ProductImportItem{
+			OfferID:               vendorCodes[id],
+			Name:                  title,
+			DescriptionCategoryID: values.DescriptionCategoryID,
+			TypeID:                values.TypeID,
+			Price:                 strconv.Itoa(price.Z),
+			OldPrice:              strconv.Itoa(price.Y),
+			CurrencyCode:          "RUB",
+			Vat:                   values.Vat,
+			Height:                values.PackageHeight,
+			Width:                 values.PackageWidth,
+			Depth:                 values.PackageDepth,
+			DimensionUnit:         "mm",
+			Weight:                values.PackageWeight,
+			WeightUnit:            "g",
+			PrimaryImage:          images[0],
+			Images:                images[1:],
+			Attributes: []request.Attribute{
+				{ID: brandAttributeID, Values: []request.AttributeValue{{Value: brand}}},
+			},
+		}
+		if price.X > 0 {
+			item.PremiumPrice = strconv.Itoa(price.X)
+		}
+		if codes := barcodes[id]; len(codes) > 0 {
+			item.Barcode = codes[0]
+		}
+		if description := descriptions[id]; description != "" {
+			item.Attributes = append(item.Attributes, request.Attribute{
+				ID: annotationAttributeID, Values: []request.AttributeValue{{Value: description}},
+			})
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Upload отправляет карточки пачками и сохраняет id задач импорта.
+func (s *CardService) Upload(ctx context.Context, items []request.ProductImportItem) ([]int64, error) {
+	var tasks []int64
+	for start := 0; start < len(items); start += importBatchSize {
+		end := min(start+importBatchSize, len(items))
+
+		taskID, err := s.client.ImportProducts(ctx, items[start:end])
+		if err != nil {
+			return tasks, fmt.Errorf("failed to import products: %w", err)
+		}
+		if err := s.repo.SaveImportTask(ctx, taskID, end-start); err != nil {
+			return tasks, err
+		}
+		tasks = append(tasks, taskID)
+	}
+
+	s.log.Log("Sent %d cards in %d import tasks", len(items), len(tasks))
+	return tasks, nil
+}
+
+// SetProductGroups включает отсев дублей: товары, которые не являются предпочтительным предложением
+// своей группы в core.product_groups, не попадают в карточки.
+func (s *CardService) SetProductGroups(groups *corestorage.ProductGroupRepository) {
+	s.groups = groups
+}
+
+// PrepareAndUpload создаёт карточки для товаров wholesaler, которых ещё нет на Ozon.
+// Дубли из групп core.product_groups пропускаются, если задан SetProductGroups.
+// Если ids пустой - берутся все товары wholesaler.
+func (s *CardService) PrepareAndUpload(ctx context.Context, ids []int) ([]int64, error) {
+	if len(ids) == 0 {
+		all, err := pkg2.FetchGlobalIDs(ctx, s.wsclient.FetcherChain)
+		if err != nil {
+			return nil, err
+		}
+		ids = all
+	}
+
+	existing, err := s.repo.GetGlobalIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uncreated := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := existing[id]; !ok {
+			uncreated = append(uncreated, id)
+		}
+	}
+	if s.groups != nil {
+		preferred, err := s.groups.ExcludeNonPreferred(ctx, uncreated)
+		if err != nil {
+			return nil, err
+		}
+		s.log.Log("Excluded %d duplicate products", len(uncreated)-len(preferred))
+		uncreated = preferred
+	}
+	if len(uncreated) == 0 {
+		return nil, nil
+	}
+
+	items, err := s.Prepare(ctx, uncreated)
+	if err != nil {
+		return nil, err
+	}
+	return s.Upload(ctx, items)
+}
+
+// CheckImportTasks проверяет статусы отправленных задач импорта.
+func (s *CardService) CheckImportTasks(ctx context.Context) error {
+	tasks, err := s.repo.GetPendingImportTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, taskID := range tasks {
+		info, err := s.client.ImportInfo(ctx, taskID)
+		if err != nil {
+			return err
+		}
+
+		status := "imported"
+		for _, item := range info.Result.Items {
+			switch item.Status {
+			case "pending":
+				status = "pending"
+			case "failed":
+				s.log.Log("Offer %s import failed: %v", item.OfferID, item.Errors)
+				if status != "pending" {
+					status = "failed"
+				}
+			}
+		}
+		if status == "pending" {
+			continue
+		}
+		if err := s.repo.SetImportTaskStatus(ctx, taskID, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gomarketplace_api/config"
+	"gomarketplace_api/config/values"
+	"gomarketplace_api/internal/ozon/pkg/clients"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeVendorCoder struct{}
+
+func (fakeVendorCoder) VendorCodes(ids []int) (map[int]string, error) {
+	result := make(map[int]string, len(ids))
+	for _, id := range ids {
+		result[id] = fmt.Sprintf("id-%d-1366", id)
+	}
+	return result, nil
+}
+
+// fakeWholesaler - локальный сервис wholesaler с данными трёх товаров: 1 - полный, 2 - без цены, 3 - без фото.
+func fakeWholesaler(t *testing.T) *httptest.Server {
+	t.Helper()
+	responses := map[string]interface{}{
+		"/api/appellations": map[int]string{1: "Товар 1", 2: "Товар 2", 3: "Товар 3"},
+		"/api/descriptions": map[int]string{1: "Описание 1"},
+		"/api/brands":       map[int]string{1: "", 2: "Brand"},
+		"/api/price": map[int]map[string]int{
+			1: {"X": 950, "Y": 1200, "Z": 1000},
+			3: {"Y": 600, "Z": 500},
+		},
+		"/api/barcodes": map[int][]string{1: {"4600000000011", "4600000000028"}},
+		"/api/media":    map[int][]string{1: {"https://img/1.jpg", "https://img/2.jpg"}, 2: {"https://img/3.jpg"}},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPrepare(t *testing.T) {
+	server := fakeWholesaler(t)
+	wsclient, err := clients.NewWServiceClient(server.URL, io.Discard)
+	if err != nil {
+		t.Fatalf("NewWServiceClient: %v", err)
+	}
+	ozonConfig := config.OzonConfig{OzonValues: values.OzonValues{
+		DescriptionCategoryID: 17028922, TypeID: 91565, Vat: "0",
+		PackageHeight: 300, PackageWidth: 200, PackageDepth: 200, PackageWeight: 500,
+	}}
+	service := NewCardService(nil, wsclient, nil, nil, ozonConfig, io.Discard)
+	service.articular = fakeVendorCoder{}
+
+	items, err := service.Prepare(context.Background(), []int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1 (products without price or media are skipped): %+v", len(items), items)
+	}
+
+	item := items[0]
+	if item.OfferID != "id-1-1366" || item.Name != "Товар 1" {
+		t.Errorf("unexpected offer: %s %s", item.OfferID, item.Name)
+	}
+	if item.Price != "1000" || item.OldPrice != "1200" || item.PremiumPrice != "950" {
+		t.Errorf("prices = %s/%s/%s, want 1000/1200/950", item.Price, item.OldPrice, item.PremiumPrice)
+	}
+	if item.DescriptionCategoryID != 17028922 || item.TypeID != 91565 {
+		t.Errorf("category = %d/%d", item.DescriptionCategoryID, item.TypeID)
+	}
+	if item.PrimaryImage != "https://img/1.jpg" || len(item.Images) != 1 || item.Images[0] != "https://img/2.jpg" {
+		t.Errorf("images = %s %v", item.PrimaryImage, item.Images)
+	}
+	if item.Barcode != "4600000000011" {
+		t.Errorf("barcode = %s", item.Barcode)
+	}
+	if len(item.Attributes) != 2 || item.Attributes[0].Values[0].Value != noBrandValue ||
+		item.Attributes[1].Values[0].Value != "Описание 1" {
+		t.Errorf("attributes = %+v", item.Attributes)
+	}
+}
@@ -0,0 +1,199 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	coremodels "gomarketplace_api/internal/core/models"
+	coreservices "gomarketplace_api/internal/core/services"
+	"gomarketplace_api/internal/ozon/business/models"
+	"gomarketplace_api/internal/ozon/business/models/dto/request"
+	"gomarketplace_api/internal/ozon/business/models/dto/response"
+	"gomarketplace_api/internal/ozon/pkg/clients"
+	"gomarketplace_api/internal/ozon/storage"
+	pkg2 "gomarketplace_api/internal/suppliers/wholesaler/pkg"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"strconv"
+)
+
+const (
+	// marketplaceName - площадка для выбора профиля цен PriceEngine.
+	marketplaceName = "ozon"
+
+	pricesBatchSize = 1000
+	stocksBatchSize = 100
+)
+
+// PriceStockService выгружает на Ozon цены PriceEngine и остатки wholesaler.
+// Цены перед выгрузкой проверяет MarginGuard; nil guard пропускает все цены.
+type PriceStockService struct {
+	client      *clients.OzonClient
+	wsclient    *clients.WServiceClient
+	repo        *storage.ProductRepository
+	guard       *coreservices.MarginGuard
+	warehouseID int64
+	log         logger.Logger
+}
+
+func NewPriceStockService(
+	client *clients.OzonClient,
+	wsclient *clients.WServiceClient,
+	repo *storage.ProductRepository,
+	guard *coreservices.MarginGuard,
+	warehouseID int64,
+	writer io.Writer) *PriceStockService {
+	return &PriceStockService{
+		client:      client,
+		wsclient:    wsclient,
+		repo:        repo,
+		guard:       guard,
+		warehouseID: warehouseID,
+		log:         logger.NewLogger(writer, "[Ozon PriceStockService]"),
+	}
+}
+
+// PushPrices обновляет цены всех наших товаров на Ozon. Возвращает количество обновлённых товаров.
+func (s *PriceStockService) PushPrices(ctx context.Context) (int, error) {
+	products, ids, err := s.products(ctx)
+	if err != nil || len(products) == 0 {
+		return 0, err
+	}
+
+	prices, err := pkg2.FetchPrices(ctx, s.wsclient.FetcherChain, marketplaceName, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	updates := make([]coremodels.PriceUpdate, 0, len(products))
+	for _, product := range products {
+		price, ok := prices[product.GlobalID]
+		if !ok || price.Z <= 0 {
+			continue
+		}
+		updates = append(updates, coremodels.PriceUpdate{
+			GlobalID:        product.GlobalID,
+			ExternalID:      product.OfferID,
+			Price:           price.Y,
+			DiscountedPrice: price.Z,
+		})
+	}
+	allowed, err := s.guard.Check(ctx, marketplaceName, updates)
+	if err != nil {
+		return 0, fmt.Errorf("margin guard failed: %w", err)
+	}
+	allowedIDs := make(map[string]coremodels.PriceUpdate, len(allowed))
+	for _, update := range allowed {
+		allowedIDs[update.ExternalID] = update
+	}
+
+	items := make([]request.PriceItem, 0, len(allowed))
+	for _, product := range products {
+		if _, ok := allowedIDs[product.OfferID]; !ok {
+			continue
+		}
+		price := prices[product.GlobalID]
+		item := request.PriceItem{
+			OfferID:           product.OfferID,
+			ProductID:         product.ProductID,
+			Price:             strconv.Itoa(price.Z),
+			OldPrice:          strconv.Itoa(price.Y),
+			CurrencyCode:      "RUB",
+			AutoActionEnabled: "UNKNOWN",
+		}
+		if price.X > 0 {
+			item.PremiumPrice = strconv.Itoa(price.X)
+		}
+		items = append(items, item)
+	}
+
+	updated := 0
+	for start := 0; start < len(items); start += pricesBatchSize {
+		end := min(start+pricesBatchSize, len(items))
+		resp, err := s.client.UpdatePrices(ctx, items[start:end])
+		if err != nil {
+			return updated, fmt.Errorf("failed to update prices: %w", err)
+		}
+		updated += s.countUpdated(resp, "price")
+
+		confirmed := make([]coremodels.PriceUpdate, 0, len(resp.Result))
+		for _, result := range resp.Result {
+			if update, ok := allowedIDs[result.OfferID]; ok && result.Updated {
+				confirmed = append(confirmed, update)
+			}
+		}
+		if err := s.guard.Confirm(ctx, marketplaceName, confirmed); err != nil {
+			return updated, err
+		}
+	}
+
+	s.log.Log("Prices updated: %d of %d", updated, len(items))
+	return updated, nil
+}
+
+// PushStocks обновляет остатки всех наших товаров на складе продавца. Возвращает количество обновлённых товаров.
+func (s *PriceStockService) PushStocks(ctx context.Context) (int, error) {
+	if s.warehouseID == 0 {
+		return 0, fmt.Errorf("ozon warehouse_id is not configured")
+	}
+
+	products, ids, err := s.products(ctx)
+	if err != nil || len(products) == 0 {
+		return 0, err
+	}
+
+	stocks, err := pkg2.FetchStocks(ctx, s.wsclient.FetcherChain, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	items := make([]request.StockItem, 0, len(products))
+	for _, product := range products {
+		stock := stocks[product.GlobalID]
+		if stock < 0 {
+			stock = 0
+		}
+		items = append(items, request.StockItem{
+			OfferID:     product.OfferID,
+			ProductID:   product.ProductID,
+			Stock:       stock,
+			WarehouseID: s.warehouseID,
+		})
+	}
+
+	updated := 0
+	for start := 0; start < len(items); start += stocksBatchSize {
+		end := min(start+stocksBatchSize, len(items))
+		resp, err := s.client.UpdateStocks(ctx, items[start:end])
+		if err != nil {
+			return updated, fmt.Errorf("failed to update stocks: %w", err)
+		}
+		updated += s.countUpdated(resp, "stock")
+	}
+
+	s.log.Log("Stocks updated: %d of %d", updated, len(items))
+	return updated, nil
+}
+
+func (s *PriceStockService) products(ctx context.Context) ([]models.Product, []int, error) {
+	products, err := s.repo.GetProducts(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.GlobalID)
+	}
+	return products, ids, nil
+}
+
+func (s *PriceStockService) countUpdated(resp *response.UpdateResponse, kind string) int {
+	updated := 0
+	for _, result := range resp.Result {
+		if result.Updated {
+			updated++
+			continue
+		}
+		s.log.Log("Offer %s %s not updated: %v", result.OfferID, kind, result.Errors)
+	}
+	return updated
+}
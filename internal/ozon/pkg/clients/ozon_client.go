@@ -0,0 +1,143 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gomarketplace_api/internal/ozon/business/models/dto/request"
+	"gomarketplace_api/internal/ozon/business/models/dto/response"
+	"gomarketplace_api/internal/ozon/business/services"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api-seller.ozon.ru"
+
+const (
+	productListPath   = "/v3/product/list"
+	productInfoPath   = "/v3/product/info/list"
+	productImportPath = "/v3/product/import"
+	importInfoPath    = "/v1/product/import/info"
+	importPricesPath  = "/v1/product/import/prices"
+	updateStocksPath  = "/v2/products/stocks"
+)
+
+// OzonClient - клиент Ozon Seller API. baseURL можно подменить на адрес локального тестового сервера.
+type OzonClient struct {
+	baseURL string
+	auth    services.AuthEngine
+	client  *http.Client
+	log     logger.Logger
+}
+
+func NewOzonClient(baseURL string, auth services.AuthEngine, writer io.Writer) *OzonClient {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &OzonClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		auth:    auth,
+		client:  &http.Client{Timeout: 60 * time.Second},
+		log:     logger.NewLogger(writer, "[OzonClient]"),
+	}
+}
+
+// ProductList возвращает страницу списка товаров продавца.
+func (c *OzonClient) ProductList(ctx context.Context, lastID string, limit int) (*response.ProductListResponse, error) {
+	body := request.ProductListRequest{
+		Filter: request.ProductListFilter{Visibility: "ALL"},
+		LastID: lastID,
+		Limit:  limit,
+	}
+	var resp response.ProductListResponse
+	if err := c.doRequest(ctx, productListPath, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ProductInfo возвращает подробную информацию по товарам.
+func (c *OzonClient) ProductInfo(ctx context.Context, productIDs []int64) (*response.ProductInfoResponse, error) {
+	var resp response.ProductInfoResponse
+	if err := c.doRequest(ctx, productInfoPath, request.ProductInfoRequest{ProductID: productIDs}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ImportProducts создаёт или обновляет карточки. Возвращает id задачи импорта.
+func (c *OzonClient) ImportProducts(ctx context.Context, items []request.ProductImportItem) (int64, error) {
+	var resp response.ProductImportResponse
+	if err := c.doRequest(ctx, productImportPath, request.ProductImportRequest{Items: items}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Result.TaskID, nil
+}
+
+// ImportInfo возвращает статус задачи импорта.
+func (c *OzonClient) ImportInfo(ctx context.Context, taskID int64) (*response.ImportInfoResponse, error) {
+	var resp response.ImportInfoResponse
+	if err := c.doRequest(ctx, importInfoPath, request.ImportInfoRequest{TaskID: taskID}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdatePrices обновляет цены (не больше 1000 товаров за запрос).
+func (c *OzonClient) UpdatePrices(ctx context.Context, prices []request.PriceItem) (*response.UpdateResponse, error) {
+	var resp response.UpdateResponse
+	if err := c.doRequest(ctx, importPricesPath, request.PricesRequest{Prices: prices}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateStocks обновляет остатки (не больше 100 товаров за запрос).
+func (c *OzonClient) UpdateStocks(ctx context.Context, stocks []request.StockItem) (*response.UpdateResponse, error) {
+	var resp response.UpdateResponse
+	if err := c.doRequest(ctx, updateStocksPath, request.StocksRequest{Stocks: stocks}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *OzonClient) doRequest(ctx context.Context, path string, body interface{}, out interface{}) error {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.auth != nil {
+		c.auth.SetApiKey(req)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		c.log.Log("Request %s failed with status %d: %s", path, resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("ozon %s: unexpected status code %d", path, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(bodyBytes, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"gomarketplace_api/internal/ozon/business/models/dto/request"
+	"gomarketplace_api/internal/ozon/business/services"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeOzon - локальный сервер Ozon Seller API: проверяет авторизацию и отвечает на запросы по путям.
+func fakeOzon(t *testing.T, handlers map[string]func(body []byte) (int, interface{})) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("%s: method %s, want POST", r.URL.Path, r.Method)
+		}
+		if r.Header.Get("Client-Id") != "client" || r.Header.Get("Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler, ok := handlers[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		status, resp := handler(body)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(baseURL string) *OzonClient {
+	return NewOzonClient(baseURL, services.NewClientAuth("client", "key"), io.Discard)
+}
+
+func TestImportProducts(t *testing.T) {
+	server := fakeOzon(t, map[string]func([]byte) (int, interface{}){
+		productImportPath: func(body []byte) (int, interface{}) {
+			var req request.ProductImportRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Fatalf("invalid import request: %v", err)
+			}
+			if len(req.Items) != 2 || req.Items[0].OfferID != "id-1-1366" {
+				t.Errorf("unexpected items: %+v", req.Items)
+			}
+			return http.StatusOK, map[string]interface{}{"result": map[string]interface{}{"task_id": 42}}
+		},
+	})
+
+	taskID, err := newTestClient(server.URL).ImportProducts(context.Background(), []request.ProductImportItem{
+		{OfferID: "id-1-1366"}, {OfferID: "id-2-1366"},
+	})
+	if err != nil {
+		t.Fatalf("ImportProducts: %v", err)
+	}
+	if taskID != 42 {
+		t.Errorf("task id = %d, want 42", taskID)
+	}
+}
+
+func TestImportInfo(t *testing.T) {
+	server := fakeOzon(t, map[string]func([]byte) (int, interface{}){
+		importInfoPath: func(body []byte) (int, interface{}) {
+			var req request.ImportInfoRequest
+			json.Unmarshal(body, &req)
+			if req.TaskID != 42 {
+				t.Errorf("task id = %d, want 42", req.TaskID)
+			}
+			return http.StatusOK, map[string]interface{}{"result": map[string]interface{}{
+				"items": []map[string]interface{}{
+					{"offer_id": "id-1-1366", "status": "imported"},
+					{"offer_id": "id-2-1366", "status": "failed", "errors": []map[string]string{{"code": "bad", "message": "bad"}}},
+				},
+				"total": 2,
+			}}
+		},
+	})
+
+	info, err := newTestClient(server.URL).ImportInfo(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("ImportInfo: %v", err)
+	}
+	if len(info.Result.Items) != 2 || info.Result.Items[1].Status != "failed" || len(info.Result.Items[1].Errors) != 1 {
+		t.Errorf("unexpected import info: %+v", info.Result)
+	}
+}
+
+func TestUpdatePricesAndStocks(t *testing.T) {
+	server := fakeOzon(t, map[string]func([]byte) (int, interface{}){
+		importPricesPath: func(body []byte) (int, interface{}) {
+			var req request.PricesRequest
+			json.Unmarshal(body, &req)
+			if len(req.Prices) != 1 || req.Prices[0].Price != "990" {
+				t.Errorf("unexpected prices: %+v", req.Prices)
+			}
+			return http.StatusOK, map[string]interface{}{"result": []map[string]interface{}{{"offer_id": "id-1-1366", "updated": true}}}
+		},
+		updateStocksPath: func(body []byte) (int, interface{}) {
+			var req request.StocksRequest
+			json.Unmarshal(body, &req)
+			if len(req.Stocks) != 1 || req.Stocks[0].Stock != 5 || req.Stocks[0].WarehouseID != 7 {
+				t.Errorf("unexpected stocks: %+v", req.Stocks)
+			}
+			return http.StatusOK, map[string]interface{}{"result": []map[string]interface{}{{"offer_id": "id-1-1366", "updated": false}}}
+		},
+	})
+	client := newTestClient(server.URL)
+
+	prices, err := client.UpdatePrices(context.Background(), []request.PriceItem{{OfferID: "id-1-1366", Price: "990"}})
+	if err != nil {
+		t.Fatalf("UpdatePrices: %v", err)
+	}
+	if len(prices.Result) != 1 || !prices.Result[0].Updated {
+		t.Errorf("unexpected prices result: %+v", prices.Result)
+	}
+
+	stocks, err := client.UpdateStocks(context.Background(), []request.StockItem{{OfferID: "id-1-1366", Stock: 5, WarehouseID: 7}})
+	if err != nil {
+		t.Fatalf("UpdateStocks: %v", err)
+	}
+	if len(stocks.Result) != 1 || stocks.Result[0].Updated {
+		t.Errorf("unexpected stocks result: %+v", stocks.Result)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	server := fakeOzon(t, map[string]func([]byte) (int, interface{}){
+		productListPath: func([]byte) (int, interface{}) {
+			return http.StatusBadRequest, map[string]string{"message": "invalid filter"}
+		},
+	})
+
+	if _, err := newTestClient(server.URL).ProductList(context.Background(), "", 10); err == nil {
+		t.Fatal("ProductList: expected error on 400")
+	}
+
+	unauthorized := NewOzonClient(server.URL, services.NewClientAuth("client", "wrong"), io.Discard)
+	if _, err := unauthorized.ProductList(context.Background(), "", 10); err == nil {
+		t.Fatal("ProductList: expected error with wrong api key")
+	}
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/ozon/business/models"
+)
+
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// UpsertProducts записывает товары Ozon в ozon.products одной транзакцией.
+func (r *ProductRepository) UpsertProducts(ctx context.Context, products []models.Product) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO ozon.products (product_id, offer_id, global_id, sku, name, barcodes, price, old_price, stocks, archived, updated_at)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9, $10, NOW())
+		ON CONFLICT (product_id) DO UPDATE
+		SET offer_id = EXCLUDED.offer_id,
+		    global_id = EXCLUDED.global_id,
+		    sku = EXCLUDED.sku,
+		    name = EXCLUDED.name,
+		    barcodes = EXCLUDED.barcodes,
+		    price = EXCLUDED.price,
+		    old_price = EXCLUDED.old_price,
+		    stocks = EXCLUDED.stocks,
+		    archived = EXCLUDED.archived,
+		    updated_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare upsert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range products {
+		if _, err := stmt.ExecContext(ctx, p.ProductID, p.OfferID, p.GlobalID, p.SKU, p.Name,
+			pq.Array(p.Barcodes), p.Price, p.OldPrice, p.Stocks, p.Archived); err != nil {
+			return fmt.Errorf("upsert ozon product %d error: %w", p.ProductID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// GetProducts возвращает неархивные товары Ozon, связанные с нашими артикулами.
+func (r *ProductRepository) GetProducts(ctx context.Context) ([]models.Product, error) {
+	query := `
+		SELECT product_id, offer_id, global_id, COALESCE(sku, 0), COALESCE(name, ''), COALESCE(barcodes, '{}'),
+		       COALESCE(price, 0), COALESCE(old_price, 0), COALESCE(stocks, 0), archived, updated_at
+		FROM ozon.products
+		WHERE global_id IS NOT NULL AND NOT archived`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения товаров ozon: %w", err)
+	}
+	defer rows.Close()
+
+	var products []models.Product
+	for rows.Next() {
+		var p models.Product
+		if err := rows.Scan(&p.ProductID, &p.OfferID, &p.GlobalID, &p.SKU, &p.Name, pq.Array(&p.Barcodes),
+			&p.Price, &p.OldPrice, &p.Stocks, &p.Archived, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования товара ozon: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return products, nil
+}
+
+// GetGlobalIDs возвращает множество global_id, для которых уже есть карточки на Ozon.
+func (r *ProductRepository) GetGlobalIDs(ctx context.Context) (map[int]struct{}, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT global_id FROM ozon.products WHERE global_id IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения globalIDs ozon: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]struct{})
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования globalID: %w", err)
+		}
+		ids[id] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return ids, nil
+}
+
+// SaveImportTask сохраняет задачу импорта карточек.
+func (r *ProductRepository) SaveImportTask(ctx context.Context, taskID int64, itemsCount int) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO ozon.import_tasks (task_id, items_count) VALUES ($1, $2)
+		ON CONFLICT (task_id) DO NOTHING`, taskID, itemsCount)
+	if err != nil {
+		return fmt.Errorf("failed to save import task %d: %w", taskID, err)
+	}
+	return nil
+}
+
+// GetPendingImportTasks возвращает задачи импорта, статус которых ещё не проверен.
+func (r *ProductRepository) GetPendingImportTasks(ctx context.Context) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT task_id FROM ozon.import_tasks WHERE status = 'pending' ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending import tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan task id: %w", err)
+		}
+		tasks = append(tasks, id)
+	}
+	return tasks, rows.Err()
+}
+
+// SetImportTaskStatus обновляет статус задачи импорта.
+func (r *ProductRepository) SetImportTaskStatus(ctx context.Context, taskID int64, status string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE ozon.import_tasks SET status = $2, updated_at = NOW() WHERE task_id = $1`, taskID, status)
+	if err != nil {
+		return fmt.Errorf("failed to update import task %d: %w", taskID, err)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package business
+
+import (
+	"fmt"
+	"gomarketplace_api/internal/registry/models"
+	"gomarketplace_api/internal/registry/storage"
+	"strconv"
+	"sync"
+)
+
+// LegacySupplierCode - поставщик, чьи артикулы исторически используются во vendor code как есть.
+// Для него внутренний артикул совпадает с артикулом поставщика, чтобы существующие карточки не поменяли vendor code.
+const LegacySupplierCode = "wholesaler"
+
+// ArticularService выдаёт и ищет внутренние артикулы поставщиков.
+// supplierCode - поставщик, из товаров которого строятся карточки маркетплейса: его артикулы
+// возвращают VendorCodes и SupplierArticuls.
+type ArticularService struct {
+	repo         *storage.ArticularRepository
+	identityCode int
+	supplierCode string
+
+	mu        sync.Mutex
+	suppliers map[string]int
+	// resolved - найденные артикулы по внутреннему: выданный артикул не меняется
+	resolved map[int]models.Articular
+}
+
+// NewArticularService создаёт сервис артикулов маркетплейса с кодом продавца identityCode.
+// Пустой supplierCode - LegacySupplierCode.
+func NewArticularService(repo *storage.ArticularRepository, identityCode int, supplierCode string) *ArticularService {
+	if supplierCode == "" {
+		supplierCode = LegacySupplierCode
+	}
+	return &ArticularService{
+		repo:         repo,
+		identityCode: identityCode,
+		supplierCode: supplierCode,
+		suppliers:    make(map[string]int),
+		resolved:     make(map[int]models.Articular),
+	}
+}
+
+// InternalArticuls возвращает внутренние артикулы для артикулов поставщика, выдавая новые при необходимости.
+func (s *ArticularService) InternalArticuls(supplierCode string, supplierArticuls []string) (map[string]int, error) {
+	supplierID, err := s.supplierID(supplierCode)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.GetInternalArticuls(supplierID, supplierArticuls)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, supplierArticul := range supplierArticuls {
+		if _, ok := result[supplierArticul]; ok {
+			continue
+		}
+
+		internal := 0
+		if supplierCode == LegacySupplierCode {
+			internal, err = strconv.Atoi(supplierArticul)
+			if err != nil {
+				return nil, fmt.Errorf("legacy articul %q is not numeric: %w", supplierArticul, err)
+			}
+			if !isLegacyArticul(internal) {
+				return nil, fmt.Errorf("legacy articul %q must be between 1 and %d: larger articuls are allocated for other suppliers",
+					supplierArticul, FirstAllocatedArticul-1)
+			}
+		}
+
+		result[supplierArticul], err = s.repo.Allocate(supplierID, supplierArticul, internal)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// VendorCodes строит vendor code для товаров поставщика карточек с числовыми артикулами: артикул -> vendor code.
+func (s *ArticularService) VendorCodes(supplierArticuls []int) (map[int]string, error) {
+	keys := make([]string, len(supplierArticuls))
+	for i, articul := range supplierArticuls {
+		keys[i] = strconv.Itoa(articul)
+	}
+
+	internals, err := s.InternalArticuls(s.supplierCode, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]string, len(supplierArticuls))
+	for _, articul := range supplierArticuls {
+		result[articul] = FormatVendorCode(internals[strconv.Itoa(articul)], s.identityCode)
+	}
+	return result, nil
+}
+
+// Resolve находит артикул поставщика по vendor code карточки. Если артикул не выдавался - nil, nil.
+func (s *ArticularService) Resolve(vendorCode string) (*models.Articular, error) {
+	if _, err := ParseVendorCode(vendorCode); err != nil {
+		return nil, err
+	}
+	resolved, err := s.ResolveVendorCodes([]string{vendorCode})
+	if err != nil {
+		return nil, err
+	}
+	articular, ok := resolved[vendorCode]
+	if !ok {
+		return nil, nil
+	}
+	return &articular, nil
+}
+
+// ResolveVendorCodes находит артикулы поставщиков по vendor code карточек: vendor code -> артикул.
+// Vendor code, которые не разбираются или артикул которых не выдавался, в результат не попадают.
+// Карточки легаси-поставщика, созданные до registry, считаются его артикулами без записи в registry.articular.
+func (s *ArticularService) ResolveVendorCodes(vendorCodes []string) (map[string]models.Articular, error) {
+	internals := make(map[string]int, len(vendorCodes))
+	for _, vendorCode := range vendorCodes {
+		internal, err := ParseVendorCode(vendorCode)
+		if err != nil {
+			continue
+		}
+		internals[vendorCode] = internal
+	}
+
+	s.mu.Lock()
+	var keys []int
+	for _, internal := range internals {
+		if _, ok := s.resolved[internal]; !ok {
+			keys = append(keys, internal)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(keys) > 0 {
+		articuls, err := s.repo.GetByInternalArticuls(keys)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		for _, internal := range keys {
+			if articular, ok := articuls[internal]; ok {
+				s.resolved[internal] = articular
+				continue
+			}
+			if isLegacyArticul(internal) {
+				s.resolved[internal] = models.Articular{
+					SupplierCode:    LegacySupplierCode,
+					SupplierArticul: strconv.Itoa(internal),
+					InternalArticul: internal,
+				}
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]models.Articular, len(internals))
+	for vendorCode, internal := range internals {
+		if articular, ok := s.resolved[internal]; ok {
+			result[vendorCode] = articular
+		}
+	}
+	return result, nil
+}
+
+// SupplierArticuls возвращает для vendor code карточек числовые артикулы поставщика карточек.
+// Карточки других поставщиков и нераспознанные vendor code в результат не попадают.
+func (s *ArticularService) SupplierArticuls(vendorCodes []string) (map[string]int, error) {
+	resolved, err := s.ResolveVendorCodes(vendorCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int, len(resolved))
+	for vendorCode, articular := range resolved {
+		if articular.SupplierCode != s.supplierCode {
+			continue
+		}
+		articul, err := strconv.Atoi(articular.SupplierArticul)
+		if err != nil {
+			continue
+		}
+		result[vendorCode] = articul
+	}
+	return result, nil
+}
+
+// SupplierArticul возвращает числовой артикул поставщика карточек по vendor code карточки.
+// Для карточек других поставщиков и невыданных артикулов - ErrOtherSupplier.
+func (s *ArticularService) SupplierArticul(vendorCode string) (int, error) {
+	if _, err := ParseVendorCode(vendorCode); err != nil {
+		return 0, err
+	}
+	articuls, err := s.SupplierArticuls([]string{vendorCode})
+	if err != nil {
+		return 0, err
+	}
+	articul, ok := articuls[vendorCode]
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", vendorCode, ErrOtherSupplier)
+	}
+	return articul, nil
+}
+
+func (s *ArticularService) supplierID(code string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.suppliers[code]; ok {
+		return id, nil
+	}
+	id, err := s.repo.EnsureSupplier(code, code)
+	if err != nil {
+		return 0, err
+	}
+	s.suppliers[code] = id
+	return id, nil
+}
@@ -0,0 +1,43 @@
+package business
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var vendorCodePattern = regexp.MustCompile(`\w*-(\d*)-\w*`)
+
+// FirstAllocatedArticul - первое значение registry.internal_articul_seq. Артикулы легаси-поставщика
+// становятся внутренними как есть и должны быть меньше, иначе совпадут с выданными другим поставщикам.
+const FirstAllocatedArticul = 10000000
+
+// ErrOtherSupplier - внутренний артикул выдан не поставщику карточек маркетплейса или не выдавался.
+var ErrOtherSupplier = errors.New("internal articul is not an articul of the cards supplier")
+
+// FormatVendorCode строит vendor code карточки: id-<внутренний артикул>-<код продавца>.
+func FormatVendorCode(internalArticul int, identityCode int) string {
+	return fmt.Sprintf("id-%d-%d", internalArticul, identityCode)
+}
+
+// ParseVendorCode достаёт внутренний артикул из vendor code, построенного FormatVendorCode.
+func ParseVendorCode(vendorCode string) (int, error) {
+	match := vendorCodePattern.FindAllStringSubmatch(vendorCode, -1)
+
+	// Проверяем, что найдено хотя бы одно совпадение и нужная группа
+	if len(match) == 0 || len(match[0]) < 2 {
+		return 0, fmt.Errorf("no match found in VendorCode: %s", vendorCode)
+	}
+
+	internalArticul, err := strconv.Atoi(match[0][1])
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert global ID: %w", err)
+	}
+
+	return internalArticul, nil
+}
+
+func isLegacyArticul(articul int) bool {
+	return articul > 0 && articul < FirstAllocatedArticul
+}
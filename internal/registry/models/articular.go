@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Articular - связь артикула поставщика с внутренним артикулом (таблица registry.articular).
+// Внутренний артикул входит в vendor code карточек на маркетплейсах.
+type Articular struct {
+	ID              int       `json:"id"`
+	SupplierID      int       `json:"supplier_id"`
+	SupplierCode    string    `json:"supplier_code"`
+	SupplierArticul string    `json:"supplier_articul"`
+	InternalArticul int       `json:"internal_articul"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
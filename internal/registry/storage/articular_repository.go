@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/registry/models"
+	"strconv"
+)
+
+type ArticularRepository struct {
+	db *sql.DB
+}
+
+func NewArticularRepository(db *sql.DB) *ArticularRepository {
+	return &ArticularRepository{db: db}
+}
+
+// EnsureSupplier возвращает id поставщика в registry.supplier, создавая запись при необходимости.
+func (r *ArticularRepository) EnsureSupplier(code, name string) (int, error) {
+	query := `
+		INSERT INTO registry.supplier (code, name) VALUES ($1, $2)
+		ON CONFLICT (code) DO UPDATE SET code = EXCLUDED.code
+		RETURNING id`
+
+	var id int
+	if err := r.db.QueryRow(query, code, name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to ensure registry supplier '%s': %w", code, err)
+	}
+	return id, nil
+}
+
+// GetInternalArticuls возвращает уже выданные внутренние артикулы: supplier_articul -> internal_articul.
+func (r *ArticularRepository) GetInternalArticuls(supplierID int, supplierArticuls []string) (map[string]int, error) {
+	query := `
+		SELECT supplier_articul, internal_articul FROM registry.articular
+		WHERE supplier_id = $1 AND supplier_articul = ANY($2)`
+
+	rows, err := r.db.Query(query, supplierID, pq.Array(supplierArticuls))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения артикулов: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]int, len(supplierArticuls))
+	for rows.Next() {
+		var supplierArticul, internalArticul string
+		if err := rows.Scan(&supplierArticul, &internalArticul); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования артикула: %w", err)
+		}
+		internal, err := strconv.Atoi(internalArticul)
+		if err != nil {
+			return nil, fmt.Errorf("invalid internal articul %q: %w", internalArticul, err)
+		}
+		result[supplierArticul] = internal
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return result, nil
+}
+
+// Allocate выдаёт внутренний артикул для артикула поставщика. Если internalArticul == 0,
+// значение берётся из registry.internal_articul_seq. Повторный вызов возвращает уже выданный артикул.
+func (r *ArticularRepository) Allocate(supplierID int, supplierArticul string, internalArticul int) (int, error) {
+	query := `
+		INSERT INTO registry.articular (supplier_id, supplier_articul, internal_articul)
+		VALUES ($1, $2, CASE WHEN $3 = 0 THEN nextval('registry.internal_articul_seq') ELSE $3 END::VARCHAR)
+		ON CONFLICT (supplier_id, supplier_articul) DO UPDATE SET updated_at = registry.articular.updated_at
+		RETURNING internal_articul`
+
+	var internal string
+	if err := r.db.QueryRow(query, supplierID, supplierArticul, internalArticul).Scan(&internal); err != nil {
+		return 0, fmt.Errorf("failed to allocate internal articul for %s: %w", supplierArticul, err)
+	}
+	return strconv.Atoi(internal)
+}
+
+// GetByInternalArticul ищет артикул поставщика по внутреннему. Если не найден - nil, nil.
+func (r *ArticularRepository) GetByInternalArticul(internalArticul int) (*models.Articular, error) {
+	query := `
+		SELECT a.id, a.supplier_id, s.code, a.supplier_articul, a.internal_articul, a.created_at, a.updated_at
+		FROM registry.articular a
+		JOIN registry.supplier s ON s.id = a.supplier_id
+		WHERE a.internal_articul = $1`
+
+	var (
+		articular models.Articular
+		internal  string
+	)
+	err := r.db.QueryRow(query, strconv.Itoa(internalArticul)).Scan(
+		&articular.ID, &articular.SupplierID, &articular.SupplierCode, &articular.SupplierArticul,
+		&internal, &articular.CreatedAt, &articular.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get articular: %w", err)
+	}
+
+	articular.InternalArticul, err = strconv.Atoi(internal)
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal articul %q: %w", internal, err)
+	}
+	return &articular, nil
+}
+
+// GetByInternalArticuls ищет артикулы поставщиков по внутренним: internal_articul -> артикул.
+// Невыданные артикулы в результат не попадают.
+func (r *ArticularRepository) GetByInternalArticuls(internalArticuls []int) (map[int]models.Articular, error) {
+	keys := make([]string, len(internalArticuls))
+	for i, internal := range internalArticuls {
+		keys[i] = strconv.Itoa(internal)
+	}
+
+	query := `
+		SELECT a.id, a.supplier_id, s.code, a.supplier_articul, a.internal_articul, a.created_at, a.updated_at
+		FROM registry.articular a
+		JOIN registry.supplier s ON s.id = a.supplier_id
+		WHERE a.internal_articul = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения артикулов: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int]models.Articular, len(internalArticuls))
+	for rows.Next() {
+		var (
+			articular models.Articular
+			internal  string
+		)
+		if err := rows.Scan(&articular.ID, &articular.SupplierID, &articular.SupplierCode, &articular.SupplierArticul,
+			&internal, &articular.CreatedAt, &articular.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования артикула: %w", err)
+		}
+		articular.InternalArticul, err = strconv.Atoi(internal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid internal articul %q: %w", internal, err)
+		}
+		result[articular.InternalArticul] = articular
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return result, nil
+}
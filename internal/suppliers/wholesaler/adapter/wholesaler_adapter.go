@@ -1 +1,237 @@
 package adapter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gomarketplace_api/config"
+	coremodels "gomarketplace_api/internal/core/models"
+	"gomarketplace_api/internal/core/services"
+	corestorage "gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AdapterName - имя, под которым адаптер регистрируется в реестре поставщиков.
+const AdapterName = "wholesaler"
+
+var _ services.SupplierAdapter = (*WholesalerAdapter)(nil)
+
+// WholesalerAdapter собирает товары из таблиц схемы wholesaler в core.products.
+type WholesalerAdapter struct {
+	supplierName string
+	productRepo  *repositories.ProductRepository
+	priceRepo    *repositories.PriceRepository
+	stocksRepo   *repositories.StocksRepository
+	mediaRepo    *repositories.MediaRepository
+	sizeRepo     *repositories.SizeRepository
+	coreRepo     *corestorage.ProductRepository
+	logger       logger.Logger
+}
+
+func NewWholesalerAdapter(db *sql.DB, supplierName string, logWriter io.Writer) *WholesalerAdapter {
+	return &WholesalerAdapter{
+		supplierName: supplierName,
+		productRepo:  repositories.NewProductRepository(db),
+		priceRepo:    repositories.NewPriceRepository(db),
+		stocksRepo:   repositories.NewStocksRepository(db, nil),
+		mediaRepo:    repositories.NewMediaRepository(db),
+		sizeRepo:     repositories.NewSizeRepository(db, logWriter),
+		coreRepo:     corestorage.NewProductRepository(db),
+		logger:       logger.NewLogger(logWriter, "[WholesalerAdapter]"),
+	}
+}
+
+// NewFactory возвращает фабрику адаптера для реестра поставщиков.
+func NewFactory(logWriter io.Writer) services.AdapterFactory {
+	return func(db *sql.DB, supplier config.SupplierConfig) (services.SupplierAdapter, error) {
+		return NewWholesalerAdapter(db, supplier.Name, logWriter), nil
+	}
+}
+
+// SyncProducts читает все товары поставщика, приводит их к core-формату и записывает в core.products.
+func (a *WholesalerAdapter) SyncProducts() ([]*coremodels.Product, error) {
+	supplier, err := a.coreRepo.SupplierByName(a.supplierName)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := a.productRepo.GetProducts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wholesaler products: %w", err)
+	}
+	prices, err := a.priceRepo.GetPrices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wholesaler prices: %w", err)
+	}
+	stocks, err := a.stocksRepo.GetStocks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wholesaler stocks: %w", err)
+	}
+	descriptions, err := a.productRepo.GetDescriptions(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wholesaler descriptions: %w", err)
+	}
+	media, err := a.mediaRepo.GetMediaSources(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wholesaler media: %w", err)
+	}
+	sizes, err := a.sizeRepo.GetSizes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wholesaler sizes: %w", err)
+	}
+	sizesMap := make(map[int][]models.SizeWrapper, len(sizes))
+	for _, size := range sizes {
+		sizesMap[size.GlobalID] = size.Sizes
+	}
+
+	result := make([]*coremodels.Product, 0, len(products))
+	for _, product := range products {
+		description, _ := descriptions[product.ID].(string)
+		coreProduct, err := a.toCoreProduct(supplier.ID, product, prices[product.ID], stocks[product.ID],
+			description, media[product.ID], sizesMap[product.ID])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, coreProduct)
+	}
+
+	updated, skipped, err := a.coreRepo.UpsertProducts(context.Background(), result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert core products: %w", err)
+	}
+	skippedIDs := make(map[string]struct{}, len(skipped))
+	for _, collision := range skipped {
+		a.logger.Log("Product %d (%s) skipped: global_id is taken by supplier %d (%s)",
+			collision.GlobalID, collision.SupplierArticul, collision.OwnerID, collision.OwnerArticul)
+		skippedIDs[strconv.Itoa(collision.GlobalID)] = struct{}{}
+	}
+	synced := result[:0]
+	for _, product := range result {
+		if _, ok := skippedIDs[product.ID]; !ok {
+			synced = append(synced, product)
+		}
+	}
+	a.logger.Log("Synced %d products, %d changed, %d skipped", len(synced), updated, len(skipped))
+
+	return synced, nil
+}
+
+// FetchProductDetails собирает core-представление одного товара поставщика. Если товара нет - nil, nil.
+func (a *WholesalerAdapter) FetchProductDetails(prodCoreID int) (*coremodels.Product, error) {
+	supplier, err := a.coreRepo.SupplierByName(a.supplierName)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := a.productRepo.GetProductByID(prodCoreID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, nil
+	}
+
+	var price, stock int
+	if p, err := a.priceRepo.GetPriceByProductID(prodCoreID); err != nil {
+		return nil, err
+	} else if p != nil {
+		price = p.Price
+	}
+	if s, err := a.stocksRepo.GetStocksByProductID(prodCoreID); err != nil {
+		return nil, err
+	} else if s != nil {
+		stock = s.Stocks
+	}
+
+	descriptions, err := a.productRepo.GetDescriptionsByIDs([]int{prodCoreID}, false)
+	if err != nil {
+		return nil, err
+	}
+	description, _ := descriptions[prodCoreID].(string)
+
+	media, err := a.mediaRepo.GetMediaSourceByProductID(prodCoreID, false, repositories.BigSize)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := a.sizeRepo.GetSizesByIDs([]int{prodCoreID})
+	if err != nil {
+		return nil, err
+	}
+	var productSizes []models.SizeWrapper
+	if len(sizes) > 0 {
+		productSizes = sizes[0].Sizes
+	}
+
+	return a.toCoreProduct(supplier.ID, product, price, stock, description, media, productSizes)
+}
+
+func (a *WholesalerAdapter) toCoreProduct(
+	supplierID int,
+	product *models.Product,
+	price, stock int,
+	description string,
+	media []string,
+	sizes []models.SizeWrapper,
+) (*coremodels.Product, error) {
+	// если media ещё не заполнена Populate - строим ссылки из колонки products.media
+	if len(media) == 0 {
+		media = repositories.BuildMediaUrls(product.ID, product.Media, false, repositories.BigSize)
+	}
+
+	data := coremodels.ProductBaseData{
+		SupplierArticul: strconv.Itoa(product.ID),
+		Title:           strings.TrimSpace(product.Appellation),
+		Description:     strings.TrimSpace(description),
+		Model:           strings.TrimSpace(product.Model),
+		Category:        strings.TrimSpace(product.Category),
+		Brand:           strings.TrimSpace(product.Brand),
+		Country:         strings.TrimSpace(product.Country),
+		ProductType:     strings.TrimSpace(product.ProductType),
+		Features:        strings.TrimSpace(product.Features),
+		Sex:             strings.TrimSpace(product.Sex),
+		Color:           strings.TrimSpace(product.Color),
+		Material:        strings.TrimSpace(product.Material),
+		Barcodes:        splitBarcodes(product.Barcodes),
+		Media:           media,
+		Sizes:           make([]coremodels.ProductSize, 0, len(sizes)),
+		Price:           price,
+		Stocks:          stock,
+	}
+	for _, size := range sizes {
+		data.Sizes = append(data.Sizes, coremodels.ProductSize{
+			Descriptor: string(size.Descriptor),
+			Type:       string(size.Type),
+			Value:      size.Value,
+			Unit:       size.Unit,
+		})
+	}
+
+	baseData, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal base data for product %d: %w", product.ID, err)
+	}
+
+	return &coremodels.Product{
+		ID:         strconv.Itoa(product.ID),
+		SupplierID: supplierID,
+		BaseData:   baseData,
+	}, nil
+}
+
+// splitBarcodes разбирает строку баркодов вида "123#456".
+func splitBarcodes(barcodes string) []string {
+	result := make([]string, 0)
+	for _, barcode := range strings.Split(barcodes, "#") {
+		if barcode = strings.TrimSpace(barcode); barcode != "" {
+			result = append(result, barcode)
+		}
+	}
+	return result
+}
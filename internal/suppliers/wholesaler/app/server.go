@@ -2,6 +2,12 @@ package app
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"gomarketplace_api/config"
+	"gomarketplace_api/internal/core"
+	"gomarketplace_api/internal/suppliers/wholesaler/business"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
 	"gomarketplace_api/migrations/infrastructure"
 	"gomarketplace_api/pkg/business/service/csv_to_postgres"
 	"gomarketplace_api/pkg/dbconnect"
@@ -10,18 +16,56 @@ import (
 	"time"
 )
 
+// importTimeout - время на импорт одного файла: файл читается потоково во время загрузки в БД.
+const importTimeout = 10 * time.Minute
+
 type WholesalerServer struct {
 	dbconnect.Database
+	imports  []config.ImportJobConfig
+	fetchers *config.FetchersConfig
+	quality  *config.QualityConfig
+}
+
+// NewWServer создаёт сервер поставщика. imports - импорты фидов из секции imports конфига,
+// fetchers - настройки их источников (nil - по умолчанию).
+func NewWServer(dbCon dbconnect.Database, imports []config.ImportJobConfig, fetchers *config.FetchersConfig) *WholesalerServer {
+	return &WholesalerServer{Database: dbCon, imports: imports, fetchers: fetchers}
 }
 
-func NewWServer(dbCon dbconnect.Database) *WholesalerServer {
-	return &WholesalerServer{dbCon}
+// WithQuality включает проверки качества товаров после импорта.
+func (s *WholesalerServer) WithQuality(quality *config.QualityConfig) *WholesalerServer {
+	s.quality = quality
+	return s
 }
 
 func (s *WholesalerServer) Run() {
-	var db, err = s.Connect()
+	if err := s.Migrate(); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if err := s.Import(context.Background()); err != nil {
+		log.Printf("Обновление завершено с ошибками: %v", err)
+	}
+
+	// ------------------ обновления с инициализацией репо ------------------
+	//mediaRepo := repositories.NewMediaRepository(db)
+	//err = mediaRepo.Populate()
+	//if err != nil {
+	//	log.Fatalf("Error populating media table: %s\n", err)
+	//}
+	//// ПОМЕНЯТЬ WRITER !
+	//sizeRepo := repositories.NewSizeRepository(db, os.Stderr)
+	//err = sizeRepo.Populate()
+	//if err != nil {
+	//	log.Fatalf("Error populating media table: %s\n", err)
+	//}
+
+}
+
+// Migrate применяет миграции схемы wholesaler.
+func (s *WholesalerServer) Migrate() error {
+	db, err := s.Connect()
 	if err != nil {
-		log.Printf("Error connecting to PostgreSQL: %s\n", err)
+		return fmt.Errorf("error connecting to PostgreSQL: %w", err)
 	}
 	defer db.Close()
 
@@ -29,113 +73,86 @@ func (s *WholesalerServer) Run() {
 		&infrastructure.WholesalerSchema{},
 		&infrastructure.MigrationsSchema{},
 		&infrastructure.Metadata{},
+		&infrastructure.MetadataValidators{},
 		&infrastructure.WholesalerProducts{},
 		&infrastructure.WholesalerDescriptions{},
 		&infrastructure.WholesalerPrice{},
 		&infrastructure.WholesalerStock{},
 		&infrastructure.WholesalerMedia{},
 		&infrastructure.ProductSize{},
+		&infrastructure.WholesalerPricingProfiles{},
+		&infrastructure.WholesalerPriceHistory{},
+		&infrastructure.WholesalerChangeEvents{},
+		&infrastructure.WholesalerImportRuns{},
+		&infrastructure.WholesalerProductQuality{},
+		&core.CoreSuppliers{},
+		&core.CoreProducts{},
+		&core.CoreProductsSupplierArticul{},
+		&core.CorePriceGuard{},
 	}
 
 	for _, _migration := range migrationApply {
 		if err := _migration.UpMigration(db); err != nil {
-			log.Fatalf("Migration failed: %v", err)
+			return err
 		}
 	}
 	log.Println("Wholesaler migrations applied successfully!")
+	return nil
+}
 
-	// ------------------ products update ------------------
-	fetcher := csv_to_postgres.NewHTTPFetcher()
-	csvProc := csv_to_postgres.NewProcessor([]string{"global_id", "model", "appellation", "category",
-		"brand", "country", "product_type", "features",
-		"sex", "color", "dimension", "package", "empty",
-		"media", "barcodes", "material", "package_battery"})
-
-	postgresUpd := csv_to_postgres.NewPostgresUpdater(
-		db,
-		"wholesaler",
-		"products",
-		[]string{"global_id", "model", "appellation", "category",
-			"brand", "country", "product_type", "features",
-			"sex", "color", "dimension", "package", "empty",
-			"media", "barcodes", "material", "package_battery"})
-
-	csvUpdater := csv_to_postgres.NewUpdater(
-		"http://sexoptovik.ru/files/all_prod_info.inf",
-		"http://sexoptovik.ru/files/all_prod_info.csv",
-		"last_update_products",
-		fetcher,
-		csvProc,
-		postgresUpd)
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
-	defer cancel()
-
-	if err := csvUpdater.Execute(ctx, nil, db); err != nil {
-		log.Fatalf("Ошибка обновления: %v", err)
+// Import выполняет импорты фидов из конфига и, если включено, проверку качества товаров.
+// Каждый запуск импорта и его итог записаны в wholesaler.import_runs.
+func (s *WholesalerServer) Import(ctx context.Context) error {
+	db, err := s.Connect()
+	if err != nil {
+		return fmt.Errorf("error connecting to PostgreSQL: %w", err)
 	}
-	// ---------------------------------------------------
-
-	// ------------------ prices update ------------------
-	csvProc.SetNewColumnNaming([]string{"id товара", "цена"})
-	postgresUpd.SetNewTableName("price").SetNewColumnNaming([]string{"global_id", "price"})
-	csvUpdater.
-		SetNewInfUrl("http://sexoptovik.ru/files/all_prod_prices.inf").
-		SetNewCSVUrl("http://sexoptovik.ru/files/all_prod_prices.csv").
-		SetNewLastModCol("last_update_prices")
-
-	ctx, cancel = context.WithTimeout(context.Background(), time.Second*20)
-	defer cancel()
+	defer db.Close()
 
-	if err := csvUpdater.Execute(ctx, []string{"global_id", "price"}, db); err != nil {
-		log.Fatalf("Ошибка обновления: %v", err)
+	runner, err := s.ImportRunner(ctx, db)
+	if err != nil {
+		return err
 	}
-	// ---------------------------------------------------
-
-	// ------------------ stocks update ------------------
-	csvProc.SetNewColumnNaming([]string{"id товара", "наличие"})
-	postgresUpd.SetNewTableName("stocks").SetNewColumnNaming([]string{"global_id", "stocks"})
-
-	csvUpdater.
-		SetNewInfUrl("http://sexoptovik.ru/files/all_prod_prices__.inf").
-		SetNewCSVUrl("http://sexoptovik.ru/files/all_prod_prices__.csv").
-		SetNewLastModCol("last_update_stocks")
-
-	ctx, cancel = context.WithTimeout(context.Background(), time.Second*20)
-	defer cancel()
+	return s.ImportWith(db, runner)(ctx)
+}
 
-	if err := csvUpdater.Execute(ctx, []string{"global_id", "stocks"}, db); err != nil {
-		log.Fatalf("Ошибка обновления: %v", err)
+// ImportRunner проверяет импорты из конфига - описания и целевые таблицы в БД - и создаёт runner.
+// Вызывается при старте после Migrate, чтобы ошибки конфига останавливали приложение.
+func (s *WholesalerServer) ImportRunner(ctx context.Context, db *sql.DB) (*csv_to_postgres.JobRunner, error) {
+	runner, err := csv_to_postgres.NewJobRunner(db, csv_to_postgres.NewFetcher(s.fetchers), s.imports)
+	if err != nil {
+		return nil, fmt.Errorf("invalid import jobs: %w", err)
+	}
+	if err := runner.CheckTables(ctx); err != nil {
+		return nil, fmt.Errorf("invalid import jobs: %w", err)
 	}
-	// ---------------------------------------------------
+	return runner, nil
+}
 
-	// ------------------ descriptions update ------------------
-	csvProc.SetNewColumnNaming([]string{"global_id", "product_description"})
-	postgresUpd.SetNewTableName("stocks").SetNewColumnNaming([]string{"global_id", "product_description"})
+// ImportWith возвращает задачу импорта на проверенном runner: импорты и, если включено, проверка качества.
+// db - соединение, на котором создан runner.
+func (s *WholesalerServer) ImportWith(db *sql.DB, runner *csv_to_postgres.JobRunner) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		importErr := runner.RunAll(ctx, importTimeout)
 
-	csvUpdater.
-		SetNewInfUrl("http://sexoptovik.ru/files/all_prod_info.inf").
-		SetNewCSVUrl("http://www.sexoptovik.ru/files/all_prod_d33_.csv").
-		SetNewLastModCol("last_update_description")
+		if s.quality != nil && s.quality.Enabled {
+			s.checkQuality(ctx, db)
+		}
+		return importErr
+	}
+}
 
-	ctx, cancel = context.WithTimeout(context.Background(), time.Second*20)
+// checkQuality проверяет качество товаров после импорта. Ошибка проверки не останавливает сервер.
+func (s *WholesalerServer) checkQuality(ctx context.Context, db *sql.DB) {
+	ctx, cancel := context.WithTimeout(ctx, importTimeout)
 	defer cancel()
 
-	if err := csvUpdater.Execute(ctx, []string{"global_id", "product_description"}, db); err != nil {
-		log.Fatalf("Ошибка обновления: %v", err)
+	summary, err := business.NewQualityService(repositories.NewProductQualityRepository(db), s.quality).Run(ctx)
+	if err != nil {
+		log.Printf("Проверка качества товаров не выполнена: %v", err)
+		return
+	}
+	for _, item := range summary {
+		log.Printf("Качество товаров: правило %s (%s) нарушено у %d товаров", item.Rule, item.Severity, item.Products)
 	}
-	// ---------------------------------------------------
-
-	// ------------------ обновления с инициализацией репо ------------------
-	//mediaRepo := repositories.NewMediaRepository(db)
-	//err = mediaRepo.Populate()
-	//if err != nil {
-	//	log.Fatalf("Error populating media table: %s\n", err)
-	//}
-	//// ПОМЕНЯТЬ WRITER !
-	//sizeRepo := repositories.NewSizeRepository(db, os.Stderr)
-	//err = sizeRepo.Populate()
-	//if err != nil {
-	//	log.Fatalf("Error populating media table: %s\n", err)
-	//}
 }
@@ -37,6 +37,18 @@ func SetupRoutes(handlers ...handlers3.Handler) {
 			handlerMap["DescriptionsHandler"] = h
 		case *h2.WholesalerIdsHandler:
 			handlerMap["IdsHandler"] = h
+		case *h2.StocksHandler:
+			handlerMap["StocksHandler"] = h
+		case *h2.PriceHistoryHandler:
+			handlerMap["PriceHistoryHandler"] = h
+		case *h2.PriceQuarantineHandler:
+			handlerMap["PriceQuarantineHandler"] = h
+		case *h2.ChangesHandler:
+			handlerMap["ChangesHandler"] = h
+		case *h2.ImportRunsHandler:
+			handlerMap["ImportRunsHandler"] = h
+		case *h2.QualityHandler:
+			handlerMap["QualityHandler"] = h
 		default:
 			log.Printf("Unknown handler type: %T", h)
 		}
@@ -131,6 +143,94 @@ func SetupRoutes(handlers ...handlers3.Handler) {
 				}
 			},
 		},
+		{
+			handlerKey: "StocksHandler",
+			routePath:  "/api/stocks",
+			errMsg:     "StocksHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.StocksHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeHTTP(w, r)
+				}
+			},
+		},
+		{
+			handlerKey: "PriceHistoryHandler",
+			routePath:  "/api/price/history",
+			errMsg:     "PriceHistoryHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.PriceHistoryHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeHTTP(w, r)
+				}
+			},
+		},
+		{
+			handlerKey: "PriceHistoryHandler",
+			routePath:  "/api/price/changes",
+			errMsg:     "PriceHistoryHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.PriceHistoryHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeChanges(w, r)
+				}
+			},
+		},
+		{
+			handlerKey: "PriceQuarantineHandler",
+			routePath:  "/api/price/quarantine",
+			errMsg:     "PriceQuarantineHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.PriceQuarantineHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeHTTP(w, r)
+				}
+			},
+		},
+		{
+			handlerKey: "ChangesHandler",
+			routePath:  "/api/changes",
+			errMsg:     "ChangesHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.ChangesHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeHTTP(w, r)
+				}
+			},
+		},
+		{
+			handlerKey: "ImportRunsHandler",
+			routePath:  "/api/imports/runs",
+			errMsg:     "ImportRunsHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.ImportRunsHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeHTTP(w, r)
+				}
+			},
+		},
+		{
+			handlerKey: "QualityHandler",
+			routePath:  "/api/quality",
+			errMsg:     "QualityHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.QualityHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeHTTP(w, r)
+				}
+			},
+		},
+		{
+			handlerKey: "QualityHandler",
+			routePath:  "/api/quality/summary",
+			errMsg:     "QualityHandler not provided",
+			castFunc: func(h handlers3.Handler) http.HandlerFunc {
+				handler := h.(*h2.QualityHandler)
+				return func(w http.ResponseWriter, r *http.Request) {
+					handler.ServeSummary(w, r)
+				}
+			},
+		},
 	}
 
 	mux := http.NewServeMux()
@@ -0,0 +1,50 @@
+package h
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"net/http"
+)
+
+type ChangesHandler struct {
+	repo *repositories.ChangeEventRepository
+}
+
+func NewChangesHandler(db *sql.DB) *ChangesHandler {
+	return &ChangesHandler{
+		repo: repositories.NewChangeEventRepository(db),
+	}
+}
+
+func (h *ChangesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var changesReq requests.ChangesRequest
+	if err := json.NewDecoder(r.Body).Decode(&changesReq); err != nil || changesReq.Consumer == "" {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	if changesReq.Ack > 0 {
+		if err := h.repo.Ack(r.Context(), changesReq.Consumer, changesReq.Ack); err != nil {
+			http.Error(w, "Failed to ack change events", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	events, err := h.repo.GetPending(r.Context(), changesReq.Consumer, repositories.ChangeFilter{
+		Table:   changesReq.Table,
+		Kinds:   changesReq.Kinds,
+		Columns: changesReq.Columns,
+		Limit:   changesReq.Limit,
+	})
+	if err != nil {
+		http.Error(w, "Failed to fetch change events", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
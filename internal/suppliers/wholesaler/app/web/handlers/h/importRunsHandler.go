@@ -0,0 +1,45 @@
+package h
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"net/http"
+)
+
+type ImportRunsHandler struct {
+	repo *repositories.ImportRunRepository
+}
+
+func NewImportRunsHandler(db *sql.DB) *ImportRunsHandler {
+	return &ImportRunsHandler{
+		repo: repositories.NewImportRunRepository(db),
+	}
+}
+
+func (h *ImportRunsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var runsReq requests.ImportRunsRequest
+	if err := json.NewDecoder(r.Body).Decode(&runsReq); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	var runs []models.ImportRun
+	var err error
+	if runsReq.LatestOnly {
+		runs, err = h.repo.GetLatest(r.Context())
+	} else {
+		runs, err = h.repo.GetRuns(r.Context(), runsReq.JobName, runsReq.Status, runsReq.Limit)
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch import runs", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(runs); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
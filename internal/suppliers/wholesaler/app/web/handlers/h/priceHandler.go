@@ -13,9 +13,9 @@ type PriceHandler struct {
 	business.PriceService
 }
 
-func NewPriceHandler(db *sql.DB) *PriceHandler {
+func NewPriceHandler(db *sql.DB, profiles *business.PricingProfiles) *PriceHandler {
 	priceRepo := repositories.NewPriceRepository(db)
-	priceService := business.NewPriceEngine(priceRepo)
+	priceService := business.NewPriceEngine(priceRepo, profiles)
 
 	return &PriceHandler{
 		PriceService: priceService,
@@ -31,20 +31,15 @@ func (h *PriceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var response interface{}
-
-	if len(priceRequest.ProductIDs) == 0 {
-		response, err = h.GetPrices(priceRequest.All)
-		if err != nil {
-			http.Error(w, "Failed to fetch all media sources", http.StatusInternalServerError)
-			return
-		}
-	} else {
-		response, err = h.GetPricesById(priceRequest.ProductIDs)
-		if err != nil {
-			http.Error(w, "Failed to fetch media sources", http.StatusInternalServerError)
-			return
-		}
+	response, err := h.Calculate(business.PriceQuery{
+		Marketplace: priceRequest.Marketplace,
+		ProductIDs:  priceRequest.ProductIDs,
+		All:         priceRequest.All,
+		Explain:     priceRequest.Explain,
+	})
+	if err != nil {
+		http.Error(w, "Failed to calculate prices", http.StatusInternalServerError)
+		return
 	}
 
 	err = json.NewEncoder(w).Encode(response)
@@ -0,0 +1,88 @@
+package h
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gomarketplace_api/internal/suppliers/wholesaler/business"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"net/http"
+	"time"
+)
+
+// PriceHistoryHandler обслуживает /api/price/history (ServeHTTP) и /api/price/changes (ServeChanges).
+type PriceHistoryHandler struct {
+	service *business.PriceHistoryService
+}
+
+func NewPriceHistoryHandler(db *sql.DB) *PriceHistoryHandler {
+	return &PriceHistoryHandler{
+		service: business.NewPriceHistoryService(repositories.NewPriceHistoryRepository(db)),
+	}
+}
+
+func (h *PriceHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var historyReq requests.PriceHistoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&historyReq); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	since, err := parseSince(historyReq.Since)
+	if err != nil {
+		http.Error(w, "Invalid since date", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.service.History(historyReq.GlobalID, since)
+	if errors.Is(err, business.ErrInvalidProductID) {
+		http.Error(w, "Invalid global_id", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch price history", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(history); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *PriceHistoryHandler) ServeChanges(w http.ResponseWriter, r *http.Request) {
+	var changesReq requests.PriceChangesRequest
+	if err := json.NewDecoder(r.Body).Decode(&changesReq); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	since, err := parseSince(changesReq.Since)
+	if err != nil || since.IsZero() {
+		http.Error(w, "Invalid since date", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.service.BiggestChanges(since, changesReq.Limit, changesReq.OnlyIncreases)
+	if err != nil {
+		http.Error(w, "Failed to fetch price changes", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(changes); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseSince разбирает дату в формате RFC3339 или 2006-01-02. Пустая строка - нулевое время.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
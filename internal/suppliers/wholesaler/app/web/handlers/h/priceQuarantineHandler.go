@@ -0,0 +1,73 @@
+package h
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gomarketplace_api/internal/core/models"
+	"gomarketplace_api/internal/core/storage"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"net/http"
+)
+
+// PriceQuarantineHandler обслуживает /api/price/quarantine (ServeHTTP) - список цен, задержанных
+// проверкой маржи, и /api/price/quarantine/resolve (ServeResolve) - их одобрение или отклонение.
+// ServeResolve снимает цены с проверки маржи и подключается только к локальному API планировщика.
+type PriceQuarantineHandler struct {
+	repo *storage.PriceGuardRepository
+}
+
+func NewPriceQuarantineHandler(db *sql.DB) *PriceQuarantineHandler {
+	return &PriceQuarantineHandler{
+		repo: storage.NewPriceGuardRepository(db),
+	}
+}
+
+func (h *PriceQuarantineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var quarantineReq requests.PriceQuarantineRequest
+	if err := json.NewDecoder(r.Body).Decode(&quarantineReq); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if quarantineReq.Status == "" {
+		quarantineReq.Status = models.QuarantineStatusPending
+	}
+
+	items, err := h.repo.GetQuarantined(r.Context(), quarantineReq.Status, quarantineReq.Marketplace)
+	if err != nil {
+		http.Error(w, "Failed to fetch quarantined prices", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(items); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *PriceQuarantineHandler) ServeResolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var resolveReq requests.PriceQuarantineResolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&resolveReq); err != nil || len(resolveReq.IDs) == 0 {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	status := models.QuarantineStatusRejected
+	if resolveReq.Approve {
+		status = models.QuarantineStatusApproved
+	}
+	resolved, err := h.repo.Resolve(r.Context(), resolveReq.IDs, status)
+	if err != nil {
+		http.Error(w, "Failed to resolve quarantined prices", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(map[string]int64{"resolved": resolved}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
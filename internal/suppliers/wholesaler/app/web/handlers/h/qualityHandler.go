@@ -0,0 +1,69 @@
+package h
+
+import (
+	"encoding/json"
+	"gomarketplace_api/internal/suppliers/wholesaler/business"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"net/http"
+)
+
+// QualityHandler обслуживает /api/quality (ServeHTTP), /api/quality/summary (ServeSummary)
+// и /api/quality/run (ServeRun). ServeRun подключается только к локальному API планировщика.
+type QualityHandler struct {
+	service *business.QualityService
+}
+
+func NewQualityHandler(service *business.QualityService) *QualityHandler {
+	return &QualityHandler{service: service}
+}
+
+func (h *QualityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var qualityReq requests.QualityRequest
+	if err := json.NewDecoder(r.Body).Decode(&qualityReq); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.Results(r.Context(), qualityReq.ProductIDs, qualityReq.Status, qualityReq.Rule, qualityReq.Limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch quality results", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(results); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *QualityHandler) ServeSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.Summary(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch quality summary", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(summary); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ServeRun перепроверяет все товары, например после ручного исправления данных. Только POST.
+func (h *QualityHandler) ServeRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	summary, err := h.service.Run(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to run quality checks", http.StatusInternalServerError)
+		return
+	}
+
+	if err = json.NewEncoder(w).Encode(summary); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
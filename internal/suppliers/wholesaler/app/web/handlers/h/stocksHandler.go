@@ -0,0 +1,51 @@
+package h
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"net/http"
+)
+
+type StocksHandler struct {
+	repo *repositories.StocksRepository
+}
+
+func NewStocksHandler(db *sql.DB) *StocksHandler {
+	return &StocksHandler{
+		repo: repositories.NewStocksRepository(db, nil),
+	}
+}
+
+func (h *StocksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	var stocksReq requests.StocksRequest
+	if err = json.NewDecoder(r.Body).Decode(&stocksReq); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		return
+	}
+
+	var stocksResponse map[int]int
+
+	if len(stocksReq.ProductIDs) == 0 {
+		stocksResponse, err = h.repo.GetStocks()
+		if err != nil {
+			http.Error(w, "Failed to fetch all stocks", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		stocksResponse, err = h.repo.GetStocksByIDs(stocksReq.ProductIDs)
+		if err != nil {
+			http.Error(w, "Failed to fetch stocks", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = json.NewEncoder(w).Encode(stocksResponse)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
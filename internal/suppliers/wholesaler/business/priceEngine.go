@@ -1,57 +1,105 @@
 package business
 
 import (
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"gomarketplace_api/config/values"
 	"gomarketplace_api/internal/suppliers/wholesaler/models"
 	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"hash/fnv"
 	"log"
 	"math"
-	"math/rand"
-	"time"
 )
 
 type PriceEngine struct {
-	repo *repositories.PriceRepository
+	repo     *repositories.PriceRepository
+	profiles *PricingProfiles
 }
 
-func NewPriceEngine(repo *repositories.PriceRepository) *PriceEngine {
-	return &PriceEngine{repo}
+// NewPriceEngine создаёт движок цен. Если profiles nil - используется профиль по умолчанию.
+func NewPriceEngine(repo *repositories.PriceRepository, profiles *PricingProfiles) *PriceEngine {
+	if profiles == nil {
+		profiles = NewPricingProfiles()
+	}
+	return &PriceEngine{repo: repo, profiles: profiles}
 }
 
-func (e *PriceEngine) GetPrices(all bool) (interface{}, error) {
-	priceData, err := e.repo.GetPrices()
-	prices := make(map[int]PriceResult)
+// PriceQuery - запрос расчёта цен. Пустой ProductIDs - все товары.
+type PriceQuery struct {
+	Marketplace string
+	ProductIDs  []int
+	All         bool // включать товары с нулевой закупочной ценой
+	Explain     bool // добавить к результату разбор расчёта
+}
 
-	if err != nil {
-		return nil, err
+// Calculate рассчитывает цены товаров по профилю площадки и категории товара.
+func (e *PriceEngine) Calculate(query PriceQuery) (map[int]PriceResult, error) {
+	purchasePrices := make(map[int]int)
+	if len(query.ProductIDs) == 0 {
+		priceData, err := e.repo.GetPrices()
+		if err != nil {
+			return nil, err
+		}
+		for id, price := range priceData {
+			if !query.All && price <= 0 {
+				continue
+			}
+			purchasePrices[id] = price
+		}
+	} else {
+		priceData, err := e.repo.GetPricesById(query.ProductIDs)
+		if err != nil {
+			return nil, err
+		}
+		for id, price := range priceData {
+			purchasePrices[id] = price.(int)
+		}
 	}
 
-	for k, v := range priceData {
-		if !all && v <= 0 {
-			continue
+	var categories map[int]string
+	if e.profiles.HasCategoryProfiles() {
+		var err error
+		if categories, err = e.repo.GetCategories(query.ProductIDs); err != nil {
+			return nil, err
 		}
-		prices[k] = e.CalculatePrices(v)
 	}
 
+	prices := make(map[int]PriceResult, len(purchasePrices))
+	for id, price := range purchasePrices {
+		result := CalculatePrice(id, price, e.profiles.Resolve(query.Marketplace, categories[id]))
+		if !query.Explain {
+			result.Explanation = nil
+		}
+		prices[id] = result
+	}
 	return prices, nil
 }
 
+func (e *PriceEngine) GetPrices(all bool) (interface{}, error) {
+	return e.Calculate(PriceQuery{All: all})
+}
+
 func (e *PriceEngine) GetPriceById(id int) (int, error) {
-	repoPrice, err := e.repo.GetPriceById(id)
+	prices, err := e.Calculate(PriceQuery{ProductIDs: []int{id}})
 	if err != nil {
 		return 0, err
 	}
-	return e.CalculatePrices(int(repoPrice)).Z, nil
+	price, ok := prices[id]
+	if !ok {
+		return 0, fmt.Errorf("price not found for global_id: %d", id)
+	}
+	return price.Z, nil
 }
 
 func (e *PriceEngine) GetPricesById(ids []int) (map[int]interface{}, error) {
-	repoPrices, err := e.repo.GetPricesById(ids)
+	calculated, err := e.Calculate(PriceQuery{ProductIDs: ids})
 	if err != nil {
 		return nil, err
 	}
-	prices := make(map[int]interface{}, len(ids))
-	for id, price := range repoPrices {
-		prices[id] = e.CalculatePrices(price.(int))
+	prices := make(map[int]interface{}, len(calculated))
+	for id, price := range calculated {
+		prices[id] = price
 	}
 	return prices, nil
 }
@@ -64,137 +112,165 @@ type PriceResult struct {
 	Q int `json:"Q"` // желаемая чистая прибыль в руб
 	S int `json:"S"` // расчёт стоимости доставки
 	R int `json:"R"` // цена товара без учёта доставки
+
+	Explanation *PriceExplanation `json:"explanation,omitempty"`
 }
 
-var (
-	D = []int{38, 37, 34, 29, 26, 25, 25,
-		25, 24, 22, 22, 21, 21, 21,
-		21, 20, 20, 19, 18, 17, 16,
-		15, 14, 13}
-	MIN_Q                    = 30.0
-	MIN_S                    = 55.0
-	MAX_S                    = 205.0
-	MAX_U_COEFFICIENT        = 0.5
-	Q_COEFFICIENT            = 1.1
-	T_MIN                    = 20.0
-	T_MAX                    = 500.0
-	DIVISION_ROUNDING        = 100.0
-	ADDING_PRICE_COEFFICIENT = 1.05
-)
+// PriceExplanation - разбор расчёта цены для проверки финансами.
+type PriceExplanation struct {
+	Profile           string   `json:"profile"`
+	PurchasePrice     int      `json:"purchase_price"`
+	MarkupPercent     float64  `json:"markup_percent"`
+	Jitter            int      `json:"jitter"`
+	MinProfitApplied  bool     `json:"min_profit_applied"`
+	CommissionPercent float64  `json:"commission_percent"`
+	AcquiringFee      float64  `json:"acquiring_fee"`
+	HandlingFee       float64  `json:"handling_fee"`
+	PriceCoefficient  float64  `json:"price_coefficient"`
+	Steps             []string `json:"steps"`
+}
 
-func getDValue(P float64) (int, error) {
-	switch {
-	case P >= 1 && P <= 100:
-		return D[0], nil
-	case P >= 101 && P <= 200:
-		return D[1], nil
-	case P >= 201 && P <= 400:
-		return D[2], nil
-	case P >= 401 && P <= 700:
-		return D[3], nil
-	case P >= 701 && P <= 1000:
-		return D[4], nil
-	case P >= 1001 && P <= 1300:
-		return D[5], nil
-	case P >= 1301 && P <= 1600:
-		return D[6], nil
-	case P >= 1601 && P <= 1900:
-		return D[7], nil
-	case P >= 1901 && P <= 2300:
-		return D[8], nil
-	case P >= 2301 && P <= 2700:
-		return D[9], nil
-	case P >= 2701 && P <= 3000:
-		return D[10], nil
-	case P >= 3001 && P <= 4000:
-		return D[11], nil
-	case P >= 4001 && P <= 5000:
-		return D[12], nil
-	case P >= 5001 && P <= 6000:
-		return D[13], nil
-	case P >= 6001 && P <= 7000:
-		return D[14], nil
-	case P >= 7001 && P <= 8000:
-		return D[15], nil
-	case P >= 8001 && P <= 9000:
-		return D[16], nil
-	case P >= 9001 && P <= 10000:
-		return D[17], nil
-	case P >= 10001 && P <= 12000:
-		return D[18], nil
-	case P >= 12001 && P <= 14000:
-		return D[19], nil
-	case P >= 14001 && P <= 16000:
-		return D[20], nil
-	case P >= 16001 && P <= 18000:
-		return D[21], nil
-	case P >= 18001 && P <= 20000:
-		return D[22], nil
-	case P >= 20001:
-		return D[23], nil
-	default:
-		return 0, errors.New("purchase price out of range")
+const divisionRounding = 100.0
+
+// CalculatePrice рассчитывает цены для закупочной цены P по профилю:
+//
+//	Q = max(ceil(P*D/100 + jitter), MinProfit)               - желаемая прибыль, D - наценка по диапазону P
+//	R = (P + Q + Logistics.Min + Acquiring) / (1 - Commission) - цена без учёта доставки
+//	S = clamp(R * Logistics.Percent, Logistics.Min, Logistics.Max)
+//	Z = (P + Q + S + Handling + Acquiring) / (1 - Commission) * PriceCoefficient
+//	Y = Z * (1 + PreDiscountMarkup), T = clamp(Z * Premium.Percent, Premium.Min, Premium.Max)
+//	X = Z - T - 1, если T < Premium.MaxProfitShare * Q, иначе 0
+//
+// globalID участвует только в детерминированной добавке jitter.
+func CalculatePrice(globalID, P int, profile values.PricingProfile) PriceResult {
+	p := float64(P)
+	commission := (100 - profile.CommissionPercent) / 100
+	explanation := &PriceExplanation{
+		Profile:           profile.Name,
+		PurchasePrice:     P,
+		CommissionPercent: profile.CommissionPercent,
+		AcquiringFee:      profile.AcquiringFee,
+		HandlingFee:       profile.HandlingFee,
+		PriceCoefficient:  profile.PriceCoefficient,
 	}
-}
 
-func calculateQ(P float64) float64 {
-	D, _ := getDValue(P)
-	randomFactor := float64(rand.Intn(10) + 1)
+	markup, err := markupPercent(profile.MarkupTiers, p)
+	if err != nil {
+		explanation.Steps = append(explanation.Steps, err.Error())
+	}
+	explanation.MarkupPercent = markup
+	explanation.Jitter = jitter(profile.Jitter, globalID, P)
 
-	Q := math.Ceil(P*float64(D)/100 + randomFactor)
-	if Q < MIN_Q {
-		return MIN_Q
+	Q := math.Ceil(p*markup/100 + float64(explanation.Jitter))
+	if Q < profile.MinProfit {
+		Q = profile.MinProfit
+		explanation.MinProfitApplied = true
+	}
+	explanation.Steps = append(explanation.Steps, fmt.Sprintf(
+		"Q = ceil(%d * %.2f%% + %d) = %.0f (min %.0f)", P, markup, explanation.Jitter, Q, profile.MinProfit))
+
+	R := roundPrice((p + Q + profile.Logistics.Min + profile.AcquiringFee) / commission)
+	explanation.Steps = append(explanation.Steps, fmt.Sprintf(
+		"R = (%d + %.0f + %.2f + %.2f) / %.2f = %.2f", P, Q, profile.Logistics.Min, profile.AcquiringFee, commission, R))
+
+	S := clamp(R*profile.Logistics.Percent/100, profile.Logistics.Min, profile.Logistics.Max)
+	explanation.Steps = append(explanation.Steps, fmt.Sprintf(
+		"S = clamp(%.2f * %.2f%%, %.2f, %.2f) = %.2f", R, profile.Logistics.Percent, profile.Logistics.Min, profile.Logistics.Max, S))
+
+	Z := roundPrice((p + Q + S + profile.HandlingFee + profile.AcquiringFee) / commission * profile.PriceCoefficient)
+	explanation.Steps = append(explanation.Steps, fmt.Sprintf(
+		"Z = (%d + %.0f + %.2f + %.2f + %.2f) / %.2f * %.2f = %.2f",
+		P, Q, S, profile.HandlingFee, profile.AcquiringFee, commission, profile.PriceCoefficient, Z))
+
+	Y := math.Round(Z * (1 + profile.PreDiscountMarkupPercent/100))
+	explanation.Steps = append(explanation.Steps, fmt.Sprintf(
+		"Y = %.2f * (1 + %.2f%%) = %.0f", Z, profile.PreDiscountMarkupPercent, Y))
+
+	premium := profile.PremiumDiscount
+	T := clamp(Z*premium.Percent/100, premium.Min, premium.Max)
+	X := Z - T - 1
+	if T >= premium.MaxProfitShare*Q {
+		X = 0
+		explanation.Steps = append(explanation.Steps, fmt.Sprintf(
+			"X = 0: premium discount %.2f >= %.2f * Q", T, premium.MaxProfitShare))
+	} else {
+		explanation.Steps = append(explanation.Steps, fmt.Sprintf("X = %.2f - %.2f - 1 = %.2f", Z, T, X))
+	}
+
+	return PriceResult{
+		X: int(X), Y: int(Y), Z: int(Z), T: int(T), Q: int(Q), S: int(S), R: int(R),
+		Explanation: explanation,
 	}
-	return Q
 }
 
-func calculateR(P, Q float64) float64 {
-	return math.Round(((P+Q+55+20)*100/(100-23))*DIVISION_ROUNDING) / DIVISION_ROUNDING
+// NetProfit - чистая прибыль с продажи по цене price при закупочной цене P по тем же параметрам профиля,
+// что и CalculatePrice: цена за вычетом комиссии, доставки, эквайринга, обработки и закупки.
+// Доставка - та, что CalculatePrice заложил бы в цену price, поэтому для Z из CalculatePrice
+// с PriceCoefficient 1 прибыль равна Q.
+func NetProfit(price, P int, profile values.PricingProfile) float64 {
+	z := float64(price)
+	commission := (100 - profile.CommissionPercent) / 100
+	S := deliveryCost(z, profile)
+	return roundPrice(z*commission - S - profile.AcquiringFee - profile.HandlingFee - float64(P))
 }
 
-func calculateS(R float64) float64 {
-	S := R / 100 * 5
-	if S < MIN_S {
-		return MIN_S
-	} else if S > MAX_S {
-		return MAX_S
+// deliveryCost возвращает доставку S, из которой CalculatePrice получил бы цену price. В CalculatePrice
+// S = clamp(R * k), k = Logistics.Percent/100, c = 1 - Commission; из формул R и Z
+// R = Z/PriceCoefficient - (S + Handling - Logistics.Min)/c, откуда
+// S = k * (Z/PriceCoefficient - (Handling - Logistics.Min)/c) / (1 + k/c).
+func deliveryCost(price float64, profile values.PricingProfile) float64 {
+	commission := (100 - profile.CommissionPercent) / 100
+	coefficient := profile.PriceCoefficient
+	if coefficient <= 0 {
+		coefficient = 1
 	}
-	return math.Round(S*DIVISION_ROUNDING) / DIVISION_ROUNDING
+	k := profile.Logistics.Percent / 100
+	S := k * (price/coefficient - (profile.HandlingFee-profile.Logistics.Min)/commission) / (1 + k/commission)
+	return clamp(S, profile.Logistics.Min, profile.Logistics.Max)
 }
 
-func calculateZ(P, Q, S float64) float64 {
-	return math.Round((P+Q+S+25+20)*100/(100-23)*DIVISION_ROUNDING*ADDING_PRICE_COEFFICIENT) / DIVISION_ROUNDING
+// CalculatePrices рассчитывает цены по профилю по умолчанию.
+func (e *PriceEngine) CalculatePrices(P int) PriceResult {
+	return CalculatePrice(0, P, e.profiles.Resolve("", ""))
 }
 
-func calculateX(Z, T, Q float64) float64 {
-	if T >= MAX_U_COEFFICIENT*Q {
-		return 0
+func markupPercent(tiers []values.MarkupTier, P float64) (float64, error) {
+	if P < 1 {
+		return 0, errors.New("purchase price out of range")
 	}
-	return Z - T - 1
+	for _, tier := range tiers {
+		if tier.UpTo == 0 || P <= tier.UpTo {
+			return tier.Percent, nil
+		}
+	}
+	return 0, errors.New("purchase price out of range")
 }
 
-func calculateT(Z float64) float64 {
-	T := Z * 5 / 100
-	if T >= T_MAX {
-		return T_MAX
-	} else if T <= T_MIN {
-		return T_MIN
+// jitter возвращает добавку от 1 до Max, зависящую только от seed, товара и закупочной цены.
+func jitter(cfg *values.Jitter, globalID, P int) int {
+	if cfg == nil || cfg.Max <= 0 {
+		return 0
 	}
-	return math.Round(T*DIVISION_ROUNDING) / DIVISION_ROUNDING
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range []int64{cfg.Seed, int64(globalID), int64(P)} {
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		h.Write(buf[:])
+	}
+	return int(h.Sum64()%uint64(cfg.Max)) + 1
 }
 
-func (e *PriceEngine) CalculatePrices(P int) PriceResult {
-	p := float64(P)
-	Q := calculateQ(p)
-	R := calculateR(p, Q)
-	S := calculateS(R)
-	Z := calculateZ(p, Q, S)
-	T := calculateT(Z)
-	X := calculateX(Z, T, Q)
-	Y := math.Round(Z * DIVISION_ROUNDING / DIVISION_ROUNDING)
-	return PriceResult{
-		X: int(X), Y: int(Y), Z: int(Z), T: int(T), Q: int(Q), S: int(S), R: int(R),
+func clamp(value, minValue, maxValue float64) float64 {
+	if value >= maxValue {
+		return maxValue
+	} else if value <= minValue {
+		return minValue
 	}
+	return roundPrice(value)
+}
+
+func roundPrice(value float64) float64 {
+	return math.Round(value*divisionRounding) / divisionRounding
 }
 
 func (e *PriceEngine) GetProductPriceByID(id int) (*models.Price, error) {
@@ -214,7 +290,3 @@ func (e *PriceEngine) GetProductPriceByID(id int) (*models.Price, error) {
 	log.Printf("Retrieved price with product with ID: %d", id)
 	return price, nil
 }
-
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
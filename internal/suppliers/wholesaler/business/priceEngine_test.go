@@ -0,0 +1,49 @@
+package business
+
+import (
+	"gomarketplace_api/config/values"
+	"math"
+	"testing"
+)
+
+func TestNetProfitMatchesCalculatePrice(t *testing.T) {
+	flat := values.DefaultPricingProfile()
+	flat.Name = "flat"
+	flat.PriceCoefficient = 1
+
+	for _, profile := range []values.PricingProfile{values.DefaultPricingProfile(), flat} {
+		commission := (100 - profile.CommissionPercent) / 100
+		for _, P := range []int{10, 150, 500, 1000, 3000, 10000, 50000} {
+			result := CalculatePrice(P, P, profile)
+			profit := NetProfit(result.Z, P, profile)
+
+			// ожидаемая прибыль - цена Z за вычетом комиссии и тех же расходов, что заложены в расчёт
+			want := float64(result.Z)*commission - float64(result.S) - profile.AcquiringFee - profile.HandlingFee - float64(P)
+			if math.Abs(profit-want) > 1 {
+				t.Errorf("%s P=%d: NetProfit(%d) = %.2f, want %.2f (S=%d)", profile.Name, P, result.Z, profit, want, result.S)
+			}
+			if profile.PriceCoefficient == 1 && math.Abs(profit-float64(result.Q)) > 1 {
+				t.Errorf("%s P=%d: NetProfit(%d) = %.2f, want Q=%d", profile.Name, P, result.Z, profit, result.Q)
+			}
+		}
+	}
+}
+
+func TestDeliveryCostClamp(t *testing.T) {
+	profile := values.DefaultPricingProfile()
+	tests := []struct {
+		price float64
+		want  float64
+	}{
+		{price: 100, want: profile.Logistics.Min},
+		{price: 100000, want: profile.Logistics.Max},
+	}
+	for _, tt := range tests {
+		if got := deliveryCost(tt.price, profile); got != tt.want {
+			t.Errorf("deliveryCost(%.0f) = %.2f, want %.2f", tt.price, got, tt.want)
+		}
+	}
+	if got := deliveryCost(2000, profile); got <= profile.Logistics.Min || got >= profile.Logistics.Max {
+		t.Errorf("deliveryCost(2000) = %.2f, want between min and max", got)
+	}
+}
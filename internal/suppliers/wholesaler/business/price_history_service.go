@@ -0,0 +1,65 @@
+package business
+
+import (
+	"errors"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"math"
+	"time"
+)
+
+const defaultPriceChangesLimit = 100
+
+// ErrInvalidProductID - ID товара в запросе истории цен не положительный.
+var ErrInvalidProductID = errors.New("invalid product ID")
+
+type PriceHistoryService struct {
+	repo *repositories.PriceHistoryRepository
+}
+
+func NewPriceHistoryService(repo *repositories.PriceHistoryRepository) *PriceHistoryService {
+	return &PriceHistoryService{repo: repo}
+}
+
+// History возвращает историю закупочной цены товара. Нулевой since - вся история.
+func (s *PriceHistoryService) History(globalID int, since time.Time) ([]models.PriceChange, error) {
+	if globalID <= 0 {
+		return nil, ErrInvalidProductID
+	}
+
+	history, err := s.repo.GetHistory(globalID, since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		fillDelta(&history[i])
+	}
+	return history, nil
+}
+
+// BiggestChanges возвращает товары с наибольшим изменением цены с since.
+// onlyIncreases оставляет только подорожания - они съедают маржу.
+func (s *PriceHistoryService) BiggestChanges(since time.Time, limit int, onlyIncreases bool) ([]models.PriceChange, error) {
+	if since.IsZero() {
+		return nil, errors.New("since is required")
+	}
+	if limit <= 0 {
+		limit = defaultPriceChangesLimit
+	}
+
+	changes, err := s.repo.GetBiggestChanges(since, limit, onlyIncreases)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		fillDelta(&changes[i])
+	}
+	return changes, nil
+}
+
+func fillDelta(change *models.PriceChange) {
+	change.Delta = change.NewPrice - change.OldPrice
+	if change.OldPrice != 0 {
+		change.Percent = math.Round(float64(change.Delta)/float64(change.OldPrice)*10000) / 100
+	}
+}
@@ -4,4 +4,5 @@ type PriceService interface {
 	GetPriceById(id int) (int, error)
 	GetPricesById(ids []int) (map[int]interface{}, error)
 	GetPrices(all bool) (interface{}, error)
+	Calculate(query PriceQuery) (map[int]PriceResult, error)
 }
@@ -0,0 +1,135 @@
+package business
+
+import (
+	"fmt"
+	"gomarketplace_api/config"
+	"gomarketplace_api/config/values"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"strings"
+)
+
+type profileKey struct {
+	marketplace string
+	category    string
+}
+
+// PricingProfiles выбирает профиль ценообразования для площадки и категории товара.
+// Порядок поиска: площадка+категория, площадка, категория, общий профиль, профиль по умолчанию.
+type PricingProfiles struct {
+	profiles    map[profileKey]values.PricingProfile
+	hasCategory bool
+}
+
+// NewPricingProfiles собирает профили. Профили из более поздних списков переопределяют
+// более ранние с теми же площадкой и категорией.
+func NewPricingProfiles(lists ...[]values.PricingProfile) *PricingProfiles {
+	p := &PricingProfiles{profiles: make(map[profileKey]values.PricingProfile)}
+	for _, list := range lists {
+		for _, profile := range list {
+			key := newProfileKey(profile.Marketplace, profile.Category)
+			if key.category != "" {
+				p.hasCategory = true
+			}
+			p.profiles[key] = withDefaults(profile)
+		}
+	}
+	return p
+}
+
+// LoadPricingProfiles читает профили из конфига и из wholesaler.pricing_profiles.
+func LoadPricingProfiles(cfg *config.PricingConfig, repo *repositories.PricingProfileRepository) (*PricingProfiles, error) {
+	var configProfiles []values.PricingProfile
+	if cfg != nil {
+		configProfiles = cfg.Profiles
+	}
+	dbProfiles, err := repo.GetProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range [][]values.PricingProfile{configProfiles, dbProfiles} {
+		for _, profile := range list {
+			if err := validateProfile(profile); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return NewPricingProfiles(configProfiles, dbProfiles), nil
+}
+
+// validateProfile отклоняет параметры, с которыми CalculatePrice делит на ноль или считает отрицательные цены.
+func validateProfile(profile values.PricingProfile) error {
+	name := profile.Name
+	if name == "" {
+		name = strings.Trim(profile.Marketplace+"/"+profile.Category, "/")
+	}
+	if profile.CommissionPercent >= 100 {
+		return fmt.Errorf("pricing profile %q: commission_percent %.2f must be below 100", name, profile.CommissionPercent)
+	}
+	if profile.Logistics.Min < 0 || profile.Logistics.Max < 0 {
+		return fmt.Errorf("pricing profile %q: logistics min and max must not be negative", name)
+	}
+	return nil
+}
+
+// Resolve возвращает профиль для площадки и категории товара.
+func (p *PricingProfiles) Resolve(marketplace, category string) values.PricingProfile {
+	key := newProfileKey(marketplace, category)
+	for _, candidate := range []profileKey{
+		key,
+		{marketplace: key.marketplace},
+		{category: key.category},
+		{},
+	} {
+		if profile, ok := p.profiles[candidate]; ok {
+			return profile
+		}
+	}
+	return values.DefaultPricingProfile()
+}
+
+// HasCategoryProfiles сообщает, нужны ли категории товаров для выбора профиля.
+func (p *PricingProfiles) HasCategoryProfiles() bool {
+	return p.hasCategory
+}
+
+func newProfileKey(marketplace, category string) profileKey {
+	return profileKey{
+		marketplace: strings.ToLower(strings.TrimSpace(marketplace)),
+		category:    strings.ToLower(strings.TrimSpace(category)),
+	}
+}
+
+// withDefaults заполняет незаданные параметры профиля значениями профиля по умолчанию. Прибыль и расходы
+// заданы, если они не нулевые или явно указаны в YAML/JSON профиля: так можно задать, например, min_profit: 0.
+// Наценки и множитель цены нулём не задаются. Jitter не заполняется: его отсутствие означает расчёт без добавки.
+func withDefaults(profile values.PricingProfile) values.PricingProfile {
+	def := values.DefaultPricingProfile()
+	if profile.Name == "" {
+		profile.Name = strings.Trim(profile.Marketplace+"/"+profile.Category, "/")
+	}
+	if len(profile.MarkupTiers) == 0 {
+		profile.MarkupTiers = def.MarkupTiers
+	}
+	if profile.MinProfit == 0 && !profile.Has("min_profit") {
+		profile.MinProfit = def.MinProfit
+	}
+	if profile.CommissionPercent == 0 && !profile.Has("commission_percent") {
+		profile.CommissionPercent = def.CommissionPercent
+	}
+	if profile.Logistics == (values.LogisticsFee{}) && !profile.Has("logistics") {
+		profile.Logistics = def.Logistics
+	}
+	if profile.AcquiringFee == 0 && !profile.Has("acquiring_fee") {
+		profile.AcquiringFee = def.AcquiringFee
+	}
+	if profile.HandlingFee == 0 && !profile.Has("handling_fee") {
+		profile.HandlingFee = def.HandlingFee
+	}
+	if profile.PriceCoefficient == 0 {
+		profile.PriceCoefficient = def.PriceCoefficient
+	}
+	if profile.PremiumDiscount == (values.PremiumDiscount{}) && !profile.Has("premium_discount") {
+		profile.PremiumDiscount = def.PremiumDiscount
+	}
+	return profile
+}
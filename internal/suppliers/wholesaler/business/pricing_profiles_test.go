@@ -0,0 +1,217 @@
+package business
+
+import (
+	"encoding/json"
+	"gomarketplace_api/config/values"
+	"gopkg.in/yaml.v3"
+	"math"
+	"testing"
+)
+
+func TestResolveOrder(t *testing.T) {
+	profiles := NewPricingProfiles([]values.PricingProfile{
+		{Name: "common", MinProfit: 1},
+		{Name: "ozon", Marketplace: "ozon", MinProfit: 2},
+		{Name: "shoes", Category: "Обувь", MinProfit: 3},
+		{Name: "ozon-shoes", Marketplace: "Ozon", Category: "обувь ", MinProfit: 4},
+	})
+	tests := []struct {
+		marketplace, category string
+		want                  string
+	}{
+		{"ozon", "обувь", "ozon-shoes"},
+		{" OZON", "ОБУВЬ", "ozon-shoes"},
+		{"ozon", "одежда", "ozon"},
+		{"wildberries", "обувь", "shoes"},
+		{"wildberries", "одежда", "common"},
+		{"", "", "common"},
+	}
+	for _, tt := range tests {
+		if got := profiles.Resolve(tt.marketplace, tt.category).Name; got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %s, want %s", tt.marketplace, tt.category, got, tt.want)
+		}
+	}
+	if !profiles.HasCategoryProfiles() {
+		t.Error("HasCategoryProfiles = false with category profiles")
+	}
+
+	if got := NewPricingProfiles().Resolve("ozon", "обувь").Name; got != values.DefaultPricingProfile().Name {
+		t.Errorf("Resolve without profiles = %s, want default", got)
+	}
+}
+
+func TestExplicitZeroKept(t *testing.T) {
+	def := values.DefaultPricingProfile()
+	tests := []struct {
+		name    string
+		decode  func(*values.PricingProfile) error
+		check   func(values.PricingProfile) bool
+		explain string
+	}{
+		{
+			name: "yaml zero",
+			decode: func(p *values.PricingProfile) error {
+				return yaml.Unmarshal([]byte("name: zero\nmin_profit: 0\nhandling_fee: 0\nlogistics: {percent: 0, min: 0, max: 0}\n"), p)
+			},
+			check: func(p values.PricingProfile) bool {
+				return p.MinProfit == 0 && p.HandlingFee == 0 && p.Logistics == (values.LogisticsFee{}) &&
+					p.CommissionPercent == def.CommissionPercent && p.AcquiringFee == def.AcquiringFee
+			},
+			explain: "explicit zeros kept, missing keys defaulted",
+		},
+		{
+			name: "json zero",
+			decode: func(p *values.PricingProfile) error {
+				return json.Unmarshal([]byte(`{"name":"zero","commission_percent":0,"acquiring_fee":0}`), p)
+			},
+			check: func(p values.PricingProfile) bool {
+				return p.CommissionPercent == 0 && p.AcquiringFee == 0 && p.MinProfit == def.MinProfit
+			},
+			explain: "explicit zeros kept, missing keys defaulted",
+		},
+		{
+			name: "yaml missing",
+			decode: func(p *values.PricingProfile) error {
+				return yaml.Unmarshal([]byte("name: empty\n"), p)
+			},
+			check: func(p values.PricingProfile) bool {
+				return p.MinProfit == def.MinProfit && p.Logistics == def.Logistics && p.PremiumDiscount == def.PremiumDiscount
+			},
+			explain: "missing keys defaulted",
+		},
+		{
+			name: "zero coefficient and tiers",
+			decode: func(p *values.PricingProfile) error {
+				return yaml.Unmarshal([]byte("name: tiers\nprice_coefficient: 0\nmarkup_tiers: []\n"), p)
+			},
+			check: func(p values.PricingProfile) bool {
+				return p.PriceCoefficient == def.PriceCoefficient && len(p.MarkupTiers) == len(def.MarkupTiers)
+			},
+			explain: "markup tiers and price coefficient are never zero",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var profile values.PricingProfile
+			if err := tt.decode(&profile); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got := withDefaults(profile); !tt.check(got) {
+				t.Errorf("%s: %+v", tt.explain, got)
+			}
+		})
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile values.PricingProfile
+		wantErr bool
+	}{
+		{name: "default", profile: values.DefaultPricingProfile()},
+		{name: "zero commission", profile: values.PricingProfile{CommissionPercent: 0}},
+		{name: "commission 99.9", profile: values.PricingProfile{CommissionPercent: 99.9}},
+		{name: "commission 100", profile: values.PricingProfile{CommissionPercent: 100}, wantErr: true},
+		{name: "commission 120", profile: values.PricingProfile{CommissionPercent: 120}, wantErr: true},
+		{name: "negative logistics min", profile: values.PricingProfile{Logistics: values.LogisticsFee{Min: -1, Max: 100}}, wantErr: true},
+		{name: "negative logistics max", profile: values.PricingProfile{Logistics: values.LogisticsFee{Max: -1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateProfile(tt.profile); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateProfile = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestJitterDeterministic(t *testing.T) {
+	cfg := &values.Jitter{Max: 10, Seed: 7}
+	seen := make(map[int]bool)
+	for id := 1; id <= 200; id++ {
+		first := jitter(cfg, id, 1000)
+		if first < 1 || first > cfg.Max {
+			t.Fatalf("jitter(%d) = %d, want 1..%d", id, first, cfg.Max)
+		}
+		if again := jitter(cfg, id, 1000); again != first {
+			t.Fatalf("jitter(%d) = %d then %d, want the same value", id, first, again)
+		}
+		seen[first] = true
+	}
+	if len(seen) < cfg.Max/2 {
+		t.Errorf("jitter took %d distinct values over 200 products", len(seen))
+	}
+
+	tests := []struct {
+		name string
+		cfg  *values.Jitter
+	}{
+		{name: "nil", cfg: nil},
+		{name: "zero max", cfg: &values.Jitter{Max: 0, Seed: 7}},
+	}
+	for _, tt := range tests {
+		if got := jitter(tt.cfg, 1, 1000); got != 0 {
+			t.Errorf("%s: jitter = %d, want 0", tt.name, got)
+		}
+	}
+
+	differs := false
+	for id := 1; id <= 20 && !differs; id++ {
+		differs = jitter(&values.Jitter{Max: 10, Seed: 1}, id, 1000) != jitter(&values.Jitter{Max: 10, Seed: 2}, id, 1000)
+	}
+	if !differs {
+		t.Error("jitter does not depend on seed")
+	}
+}
+
+// legacyPrice - прежний расчёт PriceEngine с захардкоженными коэффициентами; random - его случайная добавка 1..10.
+func legacyPrice(P int, random float64) PriceResult {
+	D := []int{38, 37, 34, 29, 26, 25, 25, 25, 24, 22, 22, 21, 21, 21, 21, 20, 20, 19, 18, 17, 16, 15, 14, 13}
+	bounds := []int{100, 200, 400, 700, 1000, 1300, 1600, 1900, 2300, 2700, 3000, 4000, 5000, 6000, 7000,
+		8000, 9000, 10000, 12000, 14000, 16000, 18000, 20000}
+	d := D[len(D)-1]
+	for i, bound := range bounds {
+		if P <= bound {
+			d = D[i]
+			break
+		}
+	}
+
+	p := float64(P)
+	Q := math.Max(math.Ceil(p*float64(d)/100+random), 30)
+	R := math.Round(((p+Q+55+20)*100/(100-23))*100) / 100
+	S := R / 100 * 5
+	if S < 55 {
+		S = 55
+	} else if S > 205 {
+		S = 205
+	} else {
+		S = math.Round(S*100) / 100
+	}
+	Z := math.Round((p+Q+S+25+20)*100/(100-23)*100*1.05) / 100
+	T := Z * 5 / 100
+	if T >= 500 {
+		T = 500
+	} else if T <= 20 {
+		T = 20
+	} else {
+		T = math.Round(T*100) / 100
+	}
+	X := Z - T - 1
+	if T >= 0.5*Q {
+		X = 0
+	}
+	return PriceResult{X: int(X), Y: int(math.Round(Z)), Z: int(Z), T: int(T), Q: int(Q), S: int(S), R: int(R)}
+}
+
+func TestDefaultProfileMatchesLegacyPrices(t *testing.T) {
+	profile := NewPricingProfiles().Resolve("", "")
+	for _, P := range []int{1, 50, 100, 101, 350, 999, 1000, 1001, 2500, 7000, 15000, 20000, 20001, 80000} {
+		globalID := P * 7
+		got := CalculatePrice(globalID, P, profile)
+		got.Explanation = nil
+		want := legacyPrice(P, float64(jitter(profile.Jitter, globalID, P)))
+		if got != want {
+			t.Errorf("P=%d: got %+v, want %+v", P, got, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package business
+
+import (
+	"fmt"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"strings"
+)
+
+// Имена правил качества - по ним правила понижаются до предупреждений в config.QualityConfig.Warnings
+// и фильтруются результаты в /api/quality.
+const (
+	RuleRequiredFields = "required_fields"
+	RuleBarcodeEAN13   = "barcode_ean13"
+	RulePricePositive  = "price_positive"
+	RuleMediaPresent   = "media_present"
+	RuleKnownBrand     = "known_brand"
+	RuleKnownCategory  = "known_category"
+)
+
+// QualityRule проверяет товар и возвращает описания нарушений. Пустой результат - товар прошёл проверку.
+type QualityRule interface {
+	Name() string
+	Check(product *models.QualityProduct) []string
+}
+
+// RequiredFieldsRule требует непустые значения полей товара.
+type RequiredFieldsRule struct {
+	Fields []string
+}
+
+func (r RequiredFieldsRule) Name() string { return RuleRequiredFields }
+
+func (r RequiredFieldsRule) Check(product *models.QualityProduct) []string {
+	var problems []string
+	for _, field := range r.Fields {
+		if strings.TrimSpace(qualityField(product, field)) == "" {
+			problems = append(problems, fmt.Sprintf("пустое поле %s", field))
+		}
+	}
+	return problems
+}
+
+// qualityField возвращает значение поля товара по имени колонки.
+func qualityField(product *models.QualityProduct, field string) string {
+	switch field {
+	case "appellation":
+		return product.Appellation
+	case "brand":
+		return product.Brand
+	case "category":
+		return product.Category
+	case "model":
+		return product.Model
+	case "country":
+		return product.Country
+	case "description":
+		return product.Description
+	case "media":
+		return product.Media
+	case "barcodes":
+		return product.Barcodes
+	default:
+		return ""
+	}
+}
+
+// BarcodeEAN13Rule проверяет контрольную цифру каждого баркода товара (баркоды разделены '#').
+type BarcodeEAN13Rule struct{}
+
+func (BarcodeEAN13Rule) Name() string { return RuleBarcodeEAN13 }
+
+func (BarcodeEAN13Rule) Check(product *models.QualityProduct) []string {
+	var problems []string
+	found := false
+	for _, barcode := range strings.Split(product.Barcodes, "#") {
+		barcode = strings.TrimSpace(barcode)
+		if barcode == "" {
+			continue
+		}
+		found = true
+		if !ValidEAN13(barcode) {
+			problems = append(problems, fmt.Sprintf("баркод %q не EAN-13", barcode))
+		}
+	}
+	if !found {
+		problems = append(problems, "нет баркодов")
+	}
+	return problems
+}
+
+// ValidEAN13 проверяет длину, цифры и контрольную цифру EAN-13.
+func ValidEAN13(code string) bool {
+	if len(code) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(code[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := int(code[12] - '0')
+	return check >= 0 && check <= 9 && (10-sum%10)%10 == check
+}
+
+// PricePositiveRule требует закупочную цену больше нуля.
+type PricePositiveRule struct{}
+
+func (PricePositiveRule) Name() string { return RulePricePositive }
+
+func (PricePositiveRule) Check(product *models.QualityProduct) []string {
+	if product.Price == nil {
+		return []string{"нет закупочной цены"}
+	}
+	if *product.Price <= 0 {
+		return []string{fmt.Sprintf("закупочная цена %d", *product.Price)}
+	}
+	return nil
+}
+
+// MediaPresentRule требует хотя бы один ключ изображения в колонке media.
+type MediaPresentRule struct{}
+
+func (MediaPresentRule) Name() string { return RuleMediaPresent }
+
+func (MediaPresentRule) Check(product *models.QualityProduct) []string {
+	if len(strings.Fields(product.Media)) == 0 {
+		return []string{"нет ключей изображений"}
+	}
+	return nil
+}
+
+// KnownValueRule требует значение поля из списка допустимых (без учёта регистра).
+// Пустой список - любое непустое значение.
+type KnownValueRule struct {
+	RuleName string
+	Field    string
+	Known    map[string]bool
+}
+
+func NewKnownValueRule(name, field string, known []string) KnownValueRule {
+	set := make(map[string]bool, len(known))
+	for _, value := range known {
+		set[strings.ToLower(strings.TrimSpace(value))] = true
+	}
+	return KnownValueRule{RuleName: name, Field: field, Known: set}
+}
+
+func (r KnownValueRule) Name() string { return r.RuleName }
+
+func (r KnownValueRule) Check(product *models.QualityProduct) []string {
+	value := strings.TrimSpace(qualityField(product, r.Field))
+	if value == "" {
+		return []string{fmt.Sprintf("пустое поле %s", r.Field)}
+	}
+	if len(r.Known) > 0 && !r.Known[strings.ToLower(value)] {
+		return []string{fmt.Sprintf("неизвестное значение %s %q", r.Field, value)}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package business
+
+import (
+	"context"
+	"gomarketplace_api/config"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"time"
+)
+
+var defaultRequiredFields = []string{"appellation", "brand", "category"}
+
+// QualityService проверяет товары поставщика правилами качества и хранит результаты по товарам.
+type QualityService struct {
+	repo     *repositories.ProductQualityRepository
+	rules    []QualityRule
+	warnings map[string]bool
+}
+
+func NewQualityService(repo *repositories.ProductQualityRepository, cfg *config.QualityConfig) *QualityService {
+	if cfg == nil {
+		cfg = &config.QualityConfig{}
+	}
+	required := cfg.RequiredFields
+	if len(required) == 0 {
+		required = defaultRequiredFields
+	}
+	warnings := make(map[string]bool, len(cfg.Warnings))
+	for _, rule := range cfg.Warnings {
+		warnings[rule] = true
+	}
+
+	return &QualityService{
+		repo: repo,
+		rules: []QualityRule{
+			RequiredFieldsRule{Fields: required},
+			BarcodeEAN13Rule{},
+			PricePositiveRule{},
+			MediaPresentRule{},
+			NewKnownValueRule(RuleKnownBrand, "brand", cfg.KnownBrands),
+			NewKnownValueRule(RuleKnownCategory, "category", cfg.KnownCategories),
+		},
+		warnings: warnings,
+	}
+}
+
+// Run проверяет все товары и заменяет сохранённые результаты. Возвращает сводку по правилам.
+func (s *QualityService) Run(ctx context.Context) ([]models.QualitySummary, error) {
+	products, err := s.repo.GetProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]models.ProductQuality, 0, len(products))
+	for i := range products {
+		result := s.Check(&products[i])
+		result.CheckedAt = now
+		results = append(results, result)
+	}
+
+	if err := s.repo.ReplaceResults(ctx, results); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSummary(ctx)
+}
+
+// Check проверяет товар всеми правилами.
+func (s *QualityService) Check(product *models.QualityProduct) models.ProductQuality {
+	result := models.ProductQuality{GlobalID: product.GlobalID, Status: models.QualityStatusOK}
+	for _, rule := range s.rules {
+		severity := models.QualitySeverityError
+		if s.warnings[rule.Name()] {
+			severity = models.QualitySeverityWarning
+		}
+		for _, problem := range rule.Check(product) {
+			result.Issues = append(result.Issues, models.QualityIssue{Rule: rule.Name(), Severity: severity, Message: problem})
+			if severity == models.QualitySeverityError {
+				result.Status = models.QualityStatusError
+			} else if result.Status == models.QualityStatusOK {
+				result.Status = models.QualityStatusWarning
+			}
+		}
+	}
+	return result
+}
+
+func (s *QualityService) Results(ctx context.Context, ids []int, status, rule string, limit int) ([]models.ProductQuality, error) {
+	return s.repo.GetResults(ctx, ids, status, rule, limit)
+}
+
+func (s *QualityService) Summary(ctx context.Context) ([]models.QualitySummary, error) {
+	return s.repo.GetSummary(ctx)
+}
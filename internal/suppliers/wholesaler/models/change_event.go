@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ChangeEvent - изменение строки таблицы wholesaler при импорте фида (wholesaler.change_events).
+// Key - значение ключевой колонки (для товарных таблиц - global_id).
+type ChangeEvent struct {
+	ID             int64     `json:"id"`
+	Table          string    `json:"table"`
+	Key            string    `json:"key"`
+	Kind           string    `json:"kind"`
+	ChangedColumns []string  `json:"changed_columns,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
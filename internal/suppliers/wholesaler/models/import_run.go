@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ImportRun - запуск импорта фида из журнала wholesaler.import_runs.
+// RowsRead включает отклонённые строки, RowsUpserted - добавленные и изменённые.
+type ImportRun struct {
+	ID            int64      `json:"id"`
+	JobName       string     `json:"job_name"`
+	InfURL        string     `json:"inf_url"`
+	CSVURL        string     `json:"csv_url"`
+	InfTime       *time.Time `json:"inf_time,omitempty"`
+	Status        string     `json:"status"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	RowsRead      int        `json:"rows_read"`
+	RowsRejected  int        `json:"rows_rejected"`
+	RejectSamples []string   `json:"reject_samples,omitempty"`
+	RowsInserted  int64      `json:"rows_inserted"`
+	RowsUpdated   int64      `json:"rows_updated"`
+	RowsUpserted  int64      `json:"rows_upserted"`
+	RowsDeleted   int64      `json:"rows_deleted"`
+	Error         string     `json:"error,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PriceChange - изменение закупочной цены товара из wholesaler.price_history.
+// Для сводки изменений OldPrice - цена до первого изменения в периоде, NewPrice - после последнего.
+type PriceChange struct {
+	GlobalID  int       `json:"global_id"`
+	OldPrice  int       `json:"old_price"`
+	NewPrice  int       `json:"new_price"`
+	Delta     int       `json:"delta"`
+	Percent   float64   `json:"percent"`
+	Changes   int       `json:"changes,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
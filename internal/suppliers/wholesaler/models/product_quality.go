@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	QualityStatusOK      = "ok"
+	QualityStatusWarning = "warning"
+	QualityStatusError   = "error"
+
+	QualitySeverityWarning = "warning"
+	QualitySeverityError   = "error"
+)
+
+// QualityIssue - нарушение правила качества товара.
+type QualityIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ProductQuality - результат проверки качества товара из wholesaler.product_quality.
+type ProductQuality struct {
+	GlobalID  int            `json:"global_id"`
+	Status    string         `json:"status"`
+	Issues    []QualityIssue `json:"issues,omitempty"`
+	CheckedAt time.Time      `json:"checked_at"`
+}
+
+// QualitySummary - количество товаров, нарушивших правило.
+type QualitySummary struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Products int    `json:"products"`
+}
+
+// QualityProduct - данные товара, по которым проверяется качество.
+type QualityProduct struct {
+	GlobalID    int
+	Appellation string
+	Brand       string
+	Category    string
+	Model       string
+	Country     string
+	Description string
+	Media       string
+	Barcodes    string
+	Price       *int
+}
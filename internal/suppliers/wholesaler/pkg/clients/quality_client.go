@@ -0,0 +1,24 @@
+package clients
+
+import (
+	"context"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"io"
+	"net/http"
+)
+
+type QualityClient struct {
+	BaseClient
+}
+
+func NewQualityClient(apiURL string, writer io.Writer) *QualityClient {
+	return &QualityClient{
+		BaseClient: *NewBaseClient(apiURL, writer, "[WS QualityClient]"),
+	}
+}
+
+func (c *QualityClient) Fetch(ctx context.Context, requestBody interface{}) (interface{}, error) {
+	var results []models.ProductQuality
+	err := c.doRequest(ctx, http.MethodPost, "/api/quality", requestBody, &results)
+	return results, err
+}
@@ -0,0 +1,23 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type StocksClient struct {
+	BaseClient
+}
+
+func NewStocksClient(apiURL string, writer io.Writer) *StocksClient {
+	return &StocksClient{
+		BaseClient: *NewBaseClient(apiURL, writer, "[WS StocksClient]"),
+	}
+}
+
+func (c *StocksClient) Fetch(ctx context.Context, requestBody interface{}) (interface{}, error) {
+	var stocks map[int]int
+	err := c.doRequest(ctx, http.MethodPost, "/api/stocks", requestBody, &stocks)
+	return stocks, err
+}
@@ -0,0 +1,12 @@
+package requests
+
+// ChangesRequest - запрос событий изменений для потребителя Consumer. Если Ack > 0, курсор
+// потребителя сначала сдвигается на Ack - так подтверждаются события предыдущего ответа.
+type ChangesRequest struct {
+	Consumer string   `json:"consumer"`
+	Table    string   `json:"table,omitempty"` // например, wholesaler.products
+	Kinds    []string `json:"kinds,omitempty"`
+	Columns  []string `json:"columns,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+	Ack      int64    `json:"ack,omitempty"`
+}
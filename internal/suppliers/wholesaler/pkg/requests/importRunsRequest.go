@@ -0,0 +1,10 @@
+package requests
+
+// ImportRunsRequest - запрос журнала импорта. LatestOnly - только последний запуск каждого импорта,
+// остальные поля при этом не учитываются.
+type ImportRunsRequest struct {
+	JobName    string `json:"job_name,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+	LatestOnly bool   `json:"latest_only,omitempty"`
+}
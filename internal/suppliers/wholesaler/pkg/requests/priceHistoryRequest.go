@@ -0,0 +1,14 @@
+package requests
+
+// PriceHistoryRequest - запрос истории цены товара. Since в формате RFC3339 или 2006-01-02.
+type PriceHistoryRequest struct {
+	GlobalID int    `json:"global_id"`
+	Since    string `json:"since,omitempty"`
+}
+
+// PriceChangesRequest - запрос наибольших изменений цен с даты Since.
+type PriceChangesRequest struct {
+	Since         string `json:"since"`
+	Limit         int    `json:"limit,omitempty"`
+	OnlyIncreases bool   `json:"only_increases,omitempty"`
+}
@@ -0,0 +1,13 @@
+package requests
+
+// PriceQuarantineRequest - запрос списка цен в карантине. Пустой Status - ожидающие решения.
+type PriceQuarantineRequest struct {
+	Status      string `json:"status,omitempty"`
+	Marketplace string `json:"marketplace,omitempty"`
+}
+
+// PriceQuarantineResolveRequest - одобрение (Approve = true) или отклонение цен из карантина.
+type PriceQuarantineResolveRequest struct {
+	IDs     []int64 `json:"ids"`
+	Approve bool    `json:"approve"`
+}
@@ -2,5 +2,7 @@ package requests
 
 type PriceRequest struct {
 	FilterRequest
-	All bool `json:"all"`
+	All         bool   `json:"all"`
+	Marketplace string `json:"marketplace,omitempty"` // площадка для выбора профиля цен
+	Explain     bool   `json:"explain,omitempty"`     // добавить разбор расчёта цены
 }
@@ -0,0 +1,10 @@
+package requests
+
+// QualityRequest - запрос результатов проверок качества. Status - ok, warning или error,
+// Rule - имя правила (barcode_ean13, price_positive и т.д.). Пустые поля - без фильтра.
+type QualityRequest struct {
+	FilterRequest
+	Status string `json:"status,omitempty"`
+	Rule   string `json:"rule,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
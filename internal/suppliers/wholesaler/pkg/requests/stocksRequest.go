@@ -0,0 +1,5 @@
+package requests
+
+type StocksRequest struct {
+	FilterRequest
+}
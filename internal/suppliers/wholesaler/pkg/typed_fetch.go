@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gomarketplace_api/internal/suppliers/wholesaler/business"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
+	"gomarketplace_api/internal/suppliers/wholesaler/storage/repositories"
+	"time"
+)
+
+// FetchTimeout - таймаут запросов к сервису wholesaler через FetcherChain.
+const FetchTimeout = 30 * time.Second
+
+// FetchPrices получает цены PriceEngine у сервиса wholesaler по профилю цен площадки.
+func FetchPrices(ctx context.Context, chain *FetcherChain, marketplace string, ids []int) (map[int]business.PriceResult, error) {
+	request := requests.PriceRequest{FilterRequest: requests.FilterRequest{ProductIDs: ids}, Marketplace: marketplace}
+	raw, err := chain.FetchWithTimeout(ctx, "prices", request, FetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	pricesRaw, ok := raw.(map[int]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected type from Fetch: %T", raw)
+	}
+
+	prices := make(map[int]business.PriceResult, len(pricesRaw))
+	for id, value := range pricesRaw {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode price for %d: %w", id, err)
+		}
+		var price business.PriceResult
+		if err := json.Unmarshal(encoded, &price); err != nil {
+			return nil, fmt.Errorf("failed to decode price for %d: %w", id, err)
+		}
+		prices[id] = price
+	}
+	return prices, nil
+}
+
+// FetchStocks получает остатки у сервиса wholesaler.
+func FetchStocks(ctx context.Context, chain *FetcherChain, ids []int) (map[int]int, error) {
+	raw, err := chain.FetchWithTimeout(ctx, "stocks", requests.StocksRequest{FilterRequest: requests.FilterRequest{ProductIDs: ids}}, FetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	stocks, ok := raw.(map[int]int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type from Fetch: %T", raw)
+	}
+	return stocks, nil
+}
+
+// FetchStrings получает строковые значения (названия, описания, бренды) у сервиса wholesaler.
+func FetchStrings(ctx context.Context, chain *FetcherChain, name string, request interface{}) (map[int]string, error) {
+	raw, err := chain.FetchWithTimeout(ctx, name, request, FetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := raw.(map[int]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected type from Fetch: %T", raw)
+	}
+
+	result := make(map[int]string, len(values))
+	for id, value := range values {
+		if str, ok := value.(string); ok && str != "" {
+			result[id] = str
+		}
+	}
+	return result, nil
+}
+
+// FetchBarcodes получает баркоды у сервиса wholesaler.
+func FetchBarcodes(ctx context.Context, chain *FetcherChain, ids []int) (map[int][]string, error) {
+	raw, err := chain.FetchWithTimeout(ctx, "barcodes", requests.BarcodeRequest{FilterRequest: requests.FilterRequest{ProductIDs: ids}}, FetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := raw.(map[int]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected type from Fetch: %T", raw)
+	}
+
+	result := make(map[int][]string, len(values))
+	for id, value := range values {
+		list, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, barcode := range list {
+			if str, ok := barcode.(string); ok && str != "" {
+				result[id] = append(result[id], str)
+			}
+		}
+	}
+	return result, nil
+}
+
+// FetchMedia получает ссылки на изображения у сервиса wholesaler.
+func FetchMedia(ctx context.Context, chain *FetcherChain, ids []int) (map[int][]string, error) {
+	raw, err := chain.FetchWithTimeout(ctx, "media", requests.MediaRequest{
+		FilterRequest: requests.FilterRequest{ProductIDs: ids},
+		Censored:      false,
+		ImageSize:     repositories.BigSize,
+	}, FetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	media, ok := raw.(map[int][]string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type from Fetch: %T", raw)
+	}
+	return media, nil
+}
+
+// FetchGlobalIDs получает все global_id товаров wholesaler.
+func FetchGlobalIDs(ctx context.Context, chain *FetcherChain) ([]int, error) {
+	raw, err := chain.FetchWithTimeout(ctx, "globalIDs", nil, FetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	ids, ok := raw.([]int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type from Fetch: %T", raw)
+	}
+	return ids, nil
+}
+
+// FetchQualityErrors возвращает товары из ids, не прошедшие проверки качества (статус error), с описанием нарушений.
+// Товары без результатов проверки считаются прошедшими.
+func FetchQualityErrors(ctx context.Context, chain *FetcherChain, ids []int) (map[int][]models.QualityIssue, error) {
+	raw, err := chain.FetchWithTimeout(ctx, "quality", requests.QualityRequest{
+		FilterRequest: requests.FilterRequest{ProductIDs: ids},
+		Status:        models.QualityStatusError,
+		Limit:         len(ids),
+	}, FetchTimeout)
+	if err != nil {
+		return nil, err
+	}
+	results, ok := raw.([]models.ProductQuality)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type from Fetch: %T", raw)
+	}
+
+	failed := make(map[int][]models.QualityIssue, len(results))
+	for _, result := range results {
+		failed[result.GlobalID] = result.Issues
+	}
+	return failed, nil
+}
@@ -15,7 +15,7 @@ func NewBarcodeRepository(prodRepo *ProductRepository) *BarcodeRepository {
 }
 
 func (r *BarcodeRepository) GetAllBarcodes() (map[int]interface{}, error) {
-	query := `SELECT global_id, barcodes FROM wholesaler.products)`
+	query := `SELECT global_id, barcodes FROM wholesaler.products`
 
 	rows, err := r.prodRepo.db.Query(query)
 	if err != nil {
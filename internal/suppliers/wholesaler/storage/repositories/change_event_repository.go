@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"strconv"
+)
+
+const defaultChangesLimit = 10000
+
+// ChangeFilter отбирает события: пустые поля - без ограничения. Columns отбирает события updated,
+// в которых изменилась хотя бы одна из колонок; события inserted и deleted проходят всегда.
+type ChangeFilter struct {
+	Table   string
+	Kinds   []string
+	Columns []string
+	Limit   int
+}
+
+// ChangeEventRepository читает журнал изменений wholesaler.change_events. Каждый потребитель
+// хранит свой курсор в wholesaler.change_consumers и читает только события после него.
+type ChangeEventRepository struct {
+	db *sql.DB
+}
+
+func NewChangeEventRepository(db *sql.DB) *ChangeEventRepository {
+	return &ChangeEventRepository{db: db}
+}
+
+// GetPending возвращает события после курсора потребителя в порядке id.
+func (r *ChangeEventRepository) GetPending(ctx context.Context, consumer string, filter ChangeFilter) ([]models.ChangeEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultChangesLimit
+	}
+	query := `
+		SELECT e.id, e.table_name, e.key_value, e.kind, e.changed_columns, e.created_at
+		FROM wholesaler.change_events e
+		WHERE e.id > COALESCE((SELECT last_event_id FROM wholesaler.change_consumers WHERE consumer = $1), 0)
+		  AND ($2 = '' OR e.table_name = $2)
+		  AND (cardinality($3::TEXT[]) = 0 OR e.kind = ANY($3))
+		  AND (cardinality($4::TEXT[]) = 0 OR e.kind <> 'updated' OR e.changed_columns && $4::TEXT[])
+		ORDER BY e.id
+		LIMIT $5`
+
+	rows, err := r.db.QueryContext(ctx, query, consumer, filter.Table,
+		pq.Array(filter.Kinds), pq.Array(filter.Columns), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса событий изменений: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.ChangeEvent
+	for rows.Next() {
+		var event models.ChangeEvent
+		if err := rows.Scan(&event.ID, &event.Table, &event.Key, &event.Kind,
+			pq.Array(&event.ChangedColumns), &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования события изменений: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return events, nil
+}
+
+// Ack сдвигает курсор потребителя на lastEventID. Курсор не сдвигается назад.
+func (r *ChangeEventRepository) Ack(ctx context.Context, consumer string, lastEventID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO wholesaler.change_consumers (consumer, last_event_id, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (consumer) DO UPDATE
+		SET last_event_id = GREATEST(wholesaler.change_consumers.last_event_id, EXCLUDED.last_event_id),
+		    updated_at = NOW()`, consumer, lastEventID)
+	if err != nil {
+		return fmt.Errorf("failed to ack change events for %s: %w", consumer, err)
+	}
+	return nil
+}
+
+// ChangedIDs возвращает global_id товаров, добавленных или изменённых в колонках columns после курсора
+// потребителя, и id последнего просмотренного события для Ack.
+func (r *ChangeEventRepository) ChangedIDs(ctx context.Context, consumer, table string, columns []string) ([]int, int64, error) {
+	events, err := r.GetPending(ctx, consumer, ChangeFilter{Table: table, Columns: columns})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var lastID int64
+	seen := make(map[int]bool, len(events))
+	ids := make([]int, 0, len(events))
+	for _, event := range events {
+		lastID = event.ID
+		if event.Kind == "deleted" {
+			continue
+		}
+		id, err := strconv.Atoi(event.Key)
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, lastID, nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"gomarketplace_api/pkg/business/service/csv_to_postgres"
+)
+
+const defaultImportRunsLimit = 50
+
+type ImportRunRepository struct {
+	db *sql.DB
+}
+
+func NewImportRunRepository(db *sql.DB) *ImportRunRepository {
+	return &ImportRunRepository{db: db}
+}
+
+// GetRuns возвращает запуски импорта от последнего к первому. Пустые jobName и status - без фильтра.
+func (r *ImportRunRepository) GetRuns(ctx context.Context, jobName, status string, limit int) ([]models.ImportRun, error) {
+	if limit <= 0 {
+		limit = defaultImportRunsLimit
+	}
+	query := `
+		SELECT id, job_name, COALESCE(inf_url, ''), COALESCE(csv_url, ''), inf_time, status, started_at, finished_at,
+		       rows_read, rows_rejected, reject_samples, rows_inserted, rows_updated, rows_deleted, COALESCE(error, '')
+		FROM wholesaler.import_runs
+		WHERE ($1 = '' OR job_name = $1) AND ($2 = '' OR status = $2)
+		ORDER BY started_at DESC, id DESC
+		LIMIT $3`
+	return r.queryRuns(ctx, query, jobName, status, limit)
+}
+
+// GetLatest возвращает последний запуск каждого импорта.
+func (r *ImportRunRepository) GetLatest(ctx context.Context) ([]models.ImportRun, error) {
+	query := `
+		SELECT DISTINCT ON (job_name)
+		       id, job_name, COALESCE(inf_url, ''), COALESCE(csv_url, ''), inf_time, status, started_at, finished_at,
+		       rows_read, rows_rejected, reject_samples, rows_inserted, rows_updated, rows_deleted, COALESCE(error, '')
+		FROM wholesaler.import_runs
+		ORDER BY job_name, started_at DESC, id DESC`
+	return r.queryRuns(ctx, query)
+}
+
+func (r *ImportRunRepository) queryRuns(ctx context.Context, query string, args ...interface{}) ([]models.ImportRun, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса журнала импорта: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.ImportRun
+	for rows.Next() {
+		var run models.ImportRun
+		var infTime, finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.JobName, &run.InfURL, &run.CSVURL, &infTime, &run.Status, &run.StartedAt,
+			&finishedAt, &run.RowsRead, &run.RowsRejected, pq.Array(&run.RejectSamples), &run.RowsInserted,
+			&run.RowsUpdated, &run.RowsDeleted, &run.Error); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования журнала импорта: %w", err)
+		}
+		if infTime.Valid {
+			run.InfTime = &infTime.Time
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		// запуски до маскирования паролей могли сохранить URL с паролем
+		run.InfURL, run.CSVURL = csv_to_postgres.RedactURL(run.InfURL), csv_to_postgres.RedactURL(run.CSVURL)
+		run.RowsUpserted = run.RowsInserted + run.RowsUpdated
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return runs, nil
+}
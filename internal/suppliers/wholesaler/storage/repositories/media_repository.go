@@ -118,6 +118,13 @@ func (r *MediaRepository) GetMediaSourceByProductID(productID int, censored bool
 		return nil, fmt.Errorf("failed to get product media: %w", err)
 	}
 
+	mediaUrls := BuildMediaUrls(productID, media, censored, imageSize)
+
+	return mediaUrls, nil
+}
+
+// BuildMediaUrls строит ссылки на изображения товара по строке ключей из колонки media.
+func BuildMediaUrls(productID int, media string, censored bool, imageSize ImageSize) []string {
 	sourceKeys := strings.Fields(media)
 	mediaUrls := make([]string, len(sourceKeys))
 
@@ -141,5 +148,5 @@ func (r *MediaRepository) GetMediaSourceByProductID(productID int, censored bool
 		//}
 	}
 
-	return mediaUrls, nil
+	return mediaUrls
 }
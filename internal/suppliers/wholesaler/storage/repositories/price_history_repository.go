@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+	"time"
+)
+
+type PriceHistoryRepository struct {
+	db *sql.DB
+}
+
+func NewPriceHistoryRepository(db *sql.DB) *PriceHistoryRepository {
+	return &PriceHistoryRepository{db: db}
+}
+
+// GetHistory возвращает изменения цены товара начиная с since, от новых к старым.
+func (r *PriceHistoryRepository) GetHistory(globalID int, since time.Time) ([]models.PriceChange, error) {
+	query := `
+		SELECT global_id, COALESCE(old_price, 0), COALESCE(new_price, 0), changed_at
+		FROM wholesaler.price_history
+		WHERE global_id = $1 AND changed_at >= $2
+		ORDER BY changed_at DESC, id DESC
+	`
+	rows, err := r.db.Query(query, globalID, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса истории цен: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.PriceChange
+	for rows.Next() {
+		var change models.PriceChange
+		if err := rows.Scan(&change.GlobalID, &change.OldPrice, &change.NewPrice, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования истории цен: %w", err)
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return history, nil
+}
+
+// GetBiggestChanges сводит изменения каждого товара с since в одно (цена до первого и после
+// последнего изменения) и возвращает limit товаров с наибольшим относительным изменением.
+// onlyIncreases оставляет только подорожания.
+func (r *PriceHistoryRepository) GetBiggestChanges(since time.Time, limit int, onlyIncreases bool) ([]models.PriceChange, error) {
+	query := `
+		WITH period AS (
+			SELECT global_id,
+			       (array_agg(old_price ORDER BY changed_at, id))[1] AS old_price,
+			       (array_agg(new_price ORDER BY changed_at DESC, id DESC))[1] AS new_price,
+			       COUNT(*) AS changes,
+			       MAX(changed_at) AS changed_at
+			FROM wholesaler.price_history
+			WHERE changed_at >= $1
+			GROUP BY global_id
+		)
+		SELECT global_id, old_price, new_price, changes, changed_at
+		FROM period
+		WHERE old_price IS NOT NULL AND new_price IS NOT NULL AND old_price <> new_price
+		  AND (NOT $3 OR new_price > old_price)
+		ORDER BY ABS(new_price - old_price)::float / NULLIF(old_price, 0) DESC NULLS LAST,
+		         ABS(new_price - old_price) DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(query, since, limit, onlyIncreases)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса изменений цен: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.PriceChange
+	for rows.Next() {
+		var change models.PriceChange
+		if err := rows.Scan(&change.GlobalID, &change.OldPrice, &change.NewPrice, &change.Changes, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования изменений цен: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return changes, nil
+}
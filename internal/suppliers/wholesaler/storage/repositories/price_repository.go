@@ -109,3 +109,34 @@ func (r *PriceRepository) GetPriceById(id int) (float32, error) {
 
 	return price, nil
 }
+
+// GetCategories возвращает категории товаров для выбора профиля цен. Пустой ids - все товары.
+func (r *PriceRepository) GetCategories(ids []int) (map[int]string, error) {
+	query := `SELECT global_id, COALESCE(category, '') FROM wholesaler.products`
+	args := []interface{}{}
+	if len(ids) > 0 {
+		query += ` WHERE global_id = ANY($1)`
+		args = append(args, pq.Array(ids))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения категорий: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make(map[int]string)
+	for rows.Next() {
+		var globalId int
+		var category string
+		if err := rows.Scan(&globalId, &category); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования категорий: %w", err)
+		}
+		categories[globalId] = category
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return categories, nil
+}
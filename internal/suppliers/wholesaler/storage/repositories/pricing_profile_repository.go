@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gomarketplace_api/config/values"
+)
+
+type PricingProfileRepository struct {
+	db *sql.DB
+}
+
+func NewPricingProfileRepository(db *sql.DB) *PricingProfileRepository {
+	return &PricingProfileRepository{db: db}
+}
+
+// GetProfiles возвращает профили ценообразования из wholesaler.pricing_profiles.
+// Площадка и категория берутся из колонок таблицы, а не из JSON.
+func (r *PricingProfileRepository) GetProfiles() ([]values.PricingProfile, error) {
+	rows, err := r.db.Query(`SELECT name, marketplace, category, profile FROM wholesaler.pricing_profiles`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения профилей цен: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []values.PricingProfile
+	for rows.Next() {
+		var name, marketplace, category string
+		var raw []byte
+		if err := rows.Scan(&name, &marketplace, &category, &raw); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования профиля цен: %w", err)
+		}
+		var profile values.PricingProfile
+		if err := json.Unmarshal(raw, &profile); err != nil {
+			return nil, fmt.Errorf("invalid pricing profile %q: %w", name, err)
+		}
+		profile.Name, profile.Marketplace, profile.Category = name, marketplace, category
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return profiles, nil
+}
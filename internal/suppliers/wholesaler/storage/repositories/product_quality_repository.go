@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/suppliers/wholesaler/models"
+)
+
+const defaultQualityLimit = 1000
+
+type ProductQualityRepository struct {
+	db *sql.DB
+}
+
+func NewProductQualityRepository(db *sql.DB) *ProductQualityRepository {
+	return &ProductQualityRepository{db: db}
+}
+
+// GetProducts возвращает данные всех товаров для проверки качества.
+func (r *ProductQualityRepository) GetProducts(ctx context.Context) ([]models.QualityProduct, error) {
+	query := `
+		SELECT p.global_id, COALESCE(p.appellation, ''), COALESCE(p.brand, ''), COALESCE(p.category, ''),
+		       COALESCE(p.model, ''), COALESCE(p.country, ''), COALESCE(d.product_description, ''),
+		       COALESCE(p.media, ''), COALESCE(p.barcodes, ''), pr.price
+		FROM wholesaler.products p
+		LEFT JOIN wholesaler.descriptions d ON d.global_id = p.global_id
+		LEFT JOIN wholesaler.price pr ON pr.global_id = p.global_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса товаров для проверки качества: %w", err)
+	}
+	defer rows.Close()
+
+	var products []models.QualityProduct
+	for rows.Next() {
+		var product models.QualityProduct
+		var price sql.NullInt64
+		if err := rows.Scan(&product.GlobalID, &product.Appellation, &product.Brand, &product.Category,
+			&product.Model, &product.Country, &product.Description, &product.Media, &product.Barcodes, &price); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования товара: %w", err)
+		}
+		if price.Valid {
+			value := int(price.Int64)
+			product.Price = &value
+		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return products, nil
+}
+
+// ReplaceResults заменяет результаты проверок всех товаров результатами нового прогона.
+func (r *ProductQualityRepository) ReplaceResults(ctx context.Context, results []models.ProductQuality) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM wholesaler.product_quality`); err != nil {
+		return fmt.Errorf("clear product quality error: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wholesaler.product_quality (global_id, status, issues, checked_at)
+		VALUES ($1, $2, $3, $4)`)
+	if err != nil {
+		return fmt.Errorf("prepare product quality insert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		issueList := result.Issues
+		if issueList == nil {
+			issueList = []models.QualityIssue{}
+		}
+		issues, err := json.Marshal(issueList)
+		if err != nil {
+			return fmt.Errorf("encode issues of %d error: %w", result.GlobalID, err)
+		}
+		if _, err := stmt.ExecContext(ctx, result.GlobalID, result.Status, issues, result.CheckedAt); err != nil {
+			return fmt.Errorf("save product quality %d error: %w", result.GlobalID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// GetResults возвращает результаты проверок. Пустые ids, status и rule - без фильтра.
+func (r *ProductQualityRepository) GetResults(ctx context.Context, ids []int, status, rule string, limit int) ([]models.ProductQuality, error) {
+	if limit <= 0 {
+		limit = defaultQualityLimit
+	}
+	query := `
+		SELECT global_id, status, issues, checked_at
+		FROM wholesaler.product_quality
+		WHERE (cardinality($1::INT[]) = 0 OR global_id = ANY($1))
+		  AND ($2 = '' OR status = $2)
+		  AND ($3 = '' OR issues @> jsonb_build_array(jsonb_build_object('rule', $3::text)))
+		ORDER BY global_id
+		LIMIT $4`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), status, rule, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса проверок качества: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.ProductQuality
+	for rows.Next() {
+		var result models.ProductQuality
+		var issues []byte
+		if err := rows.Scan(&result.GlobalID, &result.Status, &issues, &result.CheckedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования проверки качества: %w", err)
+		}
+		if err := json.Unmarshal(issues, &result.Issues); err != nil {
+			return nil, fmt.Errorf("ошибка разбора issues товара %d: %w", result.GlobalID, err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return results, nil
+}
+
+// GetSummary возвращает количество товаров по каждому нарушенному правилу.
+func (r *ProductQualityRepository) GetSummary(ctx context.Context) ([]models.QualitySummary, error) {
+	query := `
+		SELECT issue->>'rule', issue->>'severity', COUNT(DISTINCT q.global_id)
+		FROM wholesaler.product_quality q, jsonb_array_elements(q.issues) AS issue
+		GROUP BY 1, 2
+		ORDER BY 3 DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса сводки качества: %w", err)
+	}
+	defer rows.Close()
+
+	var summary []models.QualitySummary
+	for rows.Next() {
+		var item models.QualitySummary
+		if err := rows.Scan(&item.Rule, &item.Severity, &item.Products); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования сводки качества: %w", err)
+		}
+		summary = append(summary, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return summary, nil
+}
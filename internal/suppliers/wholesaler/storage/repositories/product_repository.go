@@ -41,6 +41,39 @@ func (r *ProductRepository) GetProductByID(id int) (*models.Product, error) {
 	return &product, nil
 }
 
+// GetProducts возвращает все товары поставщика.
+func (r *ProductRepository) GetProducts() ([]*models.Product, error) {
+	query := `SELECT global_id, model, appellation, category, brand, country, product_type, features, 
+				sex, color, dimension, package, media, barcodes, material, package_battery
+			  FROM wholesaler.products`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения товаров: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(
+			&product.ID, &product.Model, &product.Appellation, &product.Category, &product.Brand, &product.Country,
+			&product.ProductType, &product.Features, &product.Sex, &product.Color,
+			&product.Dimension, &product.Package, &product.Media, &product.Barcodes,
+			&product.Material, &product.PackageBattery,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования товара: %w", err)
+		}
+		products = append(products, &product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+
+	return products, nil
+}
+
 func (r *ProductRepository) GetGlobalIDs() ([]int, error) {
 	query := `SELECT global_id FROM wholesaler.products`
 
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
 	"gomarketplace_api/internal/suppliers/wholesaler/models"
 	"gomarketplace_api/internal/suppliers/wholesaler/storage"
 	"log"
@@ -40,6 +41,56 @@ func (r *StocksRepository) GetStocksByProductID(id int) (*models.Stocks, error)
 	return &stocks, nil
 }
 
+// GetStocks возвращает остатки по всем товарам.
+func (r *StocksRepository) GetStocks() (map[int]int, error) {
+	rows, err := r.db.Query(`SELECT global_id, stocks FROM wholesaler.stocks`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения stocks: %w", err)
+	}
+	defer rows.Close()
+
+	stocks := make(map[int]int)
+	for rows.Next() {
+		var globalId int
+		var amount sql.NullInt64
+		if err := rows.Scan(&globalId, &amount); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования stocks: %w", err)
+		}
+		stocks[globalId] = int(amount.Int64)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+
+	return stocks, nil
+}
+
+// GetStocksByIDs возвращает остатки по указанным товарам.
+func (r *StocksRepository) GetStocksByIDs(ids []int) (map[int]int, error) {
+	rows, err := r.db.Query(`SELECT global_id, stocks FROM wholesaler.stocks WHERE global_id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения stocks: %w", err)
+	}
+	defer rows.Close()
+
+	stocks := make(map[int]int, len(ids))
+	for rows.Next() {
+		var globalId int
+		var amount sql.NullInt64
+		if err := rows.Scan(&globalId, &amount); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования stocks: %w", err)
+		}
+		stocks[globalId] = int(amount.Int64)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+
+	return stocks, nil
+}
+
 // Update args - аргументы для обновления. пока что поддерживается только ренейминг колонок
 func (r *StocksRepository) Update(args ...[]string) error {
 	return r.updater.Update(args...)
@@ -2,19 +2,13 @@ package storage
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"errors"
 	"fmt"
-	"github.com/lib/pq"
-	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/transform"
-	"io/ioutil"
+	"gomarketplace_api/pkg/business/service/csv_to_postgres"
 	"log"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -64,177 +58,28 @@ func (pu *PostgresUpdater) fetchInfTime() (time.Time, error) {
 
 	return time.Parse("2006-01-02 15:04:05", modTimeStr)
 }
-func (pu *PostgresUpdater) fetchCSVData(renaming []string) ([][]string, error) {
-	resp, err := http.Get(pu.CSVURL)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	log.Printf("Fetched CSV file size: %d bytes", len(body))
-
-	// Use Windows-1251 encoding directly with charmap
-	reader := transform.NewReader(bytes.NewReader(body), charmap.Windows1251.NewDecoder())
-
-	// Ensure the CSV reader handles the correct delimiter and quoted fields correctly
-	r := csv.NewReader(reader)
-	r.Comma = ';'
-	r.LazyQuotes = true
-	r.FieldsPerRecord = -1 // Allow variable number of fields per record
-
-	allRows, err := r.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-
-	log.Printf("Number of rows read: %d", len(allRows))
-
-	var header []string
-	var data [][]string
-
-	// Check if the first row contains the header by checking the first value
-	if len(allRows) > 0 && isHeader(allRows[0], pu.Columns) {
-		header = allRows[0]
-		data = allRows[1:]
-	} else {
-		header = pu.Columns
-		data = allRows
-	}
-
-	// Create a map for quick lookup of desired columns
-	columnMap := make(map[string]int)
-	for i, col := range header {
-		columnMap[col] = i
-	}
-
-	// Initialize filteredRows with the provided Columns as header
-	filteredRows := [][]string{pu.Columns}
-	for _, row := range data {
-		filteredRow := make([]string, len(pu.Columns))
-		for i, col := range pu.Columns {
-			if index, found := columnMap[col]; found && index < len(row) {
-				filteredRow[i] = row[index]
-			} else {
-				// If the column is not in the original data, set it to an empty string
-				filteredRow[i] = ""
-			}
-		}
-		filteredRows = append(filteredRows, filteredRow)
-	}
-
-	var str strings.Builder
-	str.WriteString("[|] ")
-	if renaming != nil {
-		for i, v := range renaming {
-			str.WriteString("[" + filteredRows[0][i] + "] renamed to [" + v + "]")
-			filteredRows[0][i] = v
-
-			if i < len(renaming)-1 {
-				str.WriteString(", ")
-			}
-		}
-	}
-	str.WriteString(" [|]")
-	log.Printf("Header renaming: %s", str.String())
-
-	return filteredRows, nil
-}
-
-func isHeader(row, columns []string) bool {
-	for _, col := range columns {
-		if indexOf(row, col) >= 0 {
-			return true
-		}
-	}
-	return false
-}
-
-// Вспомогательная функция для поиска индекса элемента в срезе
-func indexOf(slice []string, str string) int {
-	for i, s := range slice {
-		if s == str {
-			return i
-		}
-	}
-	return -1
-}
-
-func (pu *PostgresUpdater) updateDatabase(csvData [][]string) error {
-	tx, err := pu.DB.Begin()
-	log.Printf("Update transasction began")
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Create a temporary table without specifying the schema
-	tempTableName := "temp_" + pu.TableName
-	_, err = tx.Exec(fmt.Sprintf(`
-        CREATE TEMP TABLE %s AS
-        SELECT * FROM %s.%s WHERE 1=0
-    `, tempTableName, pu.Schema, pu.TableName))
-	if err != nil {
-		return err
-	}
-	log.Printf("Created temp table %s", tempTableName)
 
-	// Prepare the COPY command to insert data into the temporary table
-	stmt, err := tx.Prepare(pq.CopyIn(tempTableName, pu.Columns...))
+// importCSV потоково читает CSV и загружает его пачками через csv_to_postgres, не держа файл в памяти.
+func (pu *PostgresUpdater) importCSV() error {
+	resp, err := http.Get(pu.CSVURL)
 	if err != nil {
 		return err
 	}
-	log.Printf("Prepared table Copy")
-
-	for _, row := range csvData[1:] {
-		_, err = stmt.Exec(convertRowToInterfaceSlice(row)...)
-		if err != nil {
-			return err
-		}
-	}
-	log.Printf("Copied from %s to %s", pu.TableName, tempTableName)
-
-	_, err = stmt.Exec()
-	if err != nil {
-		return err
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
-	err = stmt.Close()
+	stream, err := csv_to_postgres.NewProcessor(pu.Columns).Stream(resp.Body)
 	if err != nil {
 		return err
 	}
 
-	insertQuery := fmt.Sprintf(`
-			INSERT INTO %s.%s (%s)
-			SELECT %s 
-			FROM %s AS temp
-			LEFT JOIN %s.%s AS main
-			ON temp.%s = main.%s
-			WHERE main.%s IS NULL 
-			AND temp.%s IS NOT NULL
-			AND NOT EXISTS (
-				SELECT 1 FROM %s.%s p WHERE p.global_id = temp.global_id
-			)
-			ON CONFLICT (%s) DO NOTHING
-		`,
-		pu.Schema, pu.TableName, strings.Join(pu.Columns, ","), // Схема, таблица и колонки для вставки
-		strings.Join(pu.ColumnsWithPrefix("temp."), ","), // Данные для вставки
-		tempTableName, pu.Schema, pu.TableName, // Временная таблица и основная таблица
-		pu.Columns[0], pu.Columns[0], // Условие соединения по ID
-		pu.Columns[0], pu.Columns[0], // WHERE условие
-		pu.Schema, pu.TableName, pu.Columns[0]) // Проверка существования в таблице products
-
-	log.Printf("Insert query: %s", insertQuery)
-	startTime := time.Now()
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	_, err = tx.ExecContext(ctx, insertQuery)
-	log.Printf("Insert execution time: %v", time.Since(startTime))
+	startTime := time.Now()
+	err = csv_to_postgres.NewPostgresUpdater(pu.DB, pu.Schema, pu.TableName, pu.Columns).UpdateStream(ctx, stream)
 	if err != nil {
 		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 			log.Printf("Failed to update data due to timeout: %v", err)
@@ -242,38 +87,10 @@ func (pu *PostgresUpdater) updateDatabase(csvData [][]string) error {
 		}
 		return err
 	}
-
-	// Commit the transaction
-	log.Printf("Attempting to commit changes")
-	commitStartTime := time.Now()
-	if err = tx.Commit(); err != nil {
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			log.Printf("Commit failed due to timeout: %v", err)
-			return fmt.Errorf("commit failed due to timeout: %w", err)
-		}
-		log.Printf("Error during commit: %v", err)
-		return err
-	}
-	log.Printf("Transaction committed successfully, execution time: %v", time.Since(commitStartTime))
+	log.Printf("Imported %d rows (%d malformed skipped) in %v", stream.Read, stream.Skipped, time.Since(startTime))
 	return nil
 }
 
-func (pu *PostgresUpdater) ColumnsWithPrefix(prefix string) []string {
-	cols := make([]string, len(pu.Columns))
-	for i, col := range pu.Columns {
-		cols[i] = prefix + col
-	}
-	return cols
-}
-
-func convertRowToInterfaceSlice(row []string) []any {
-	result := make([]any, len(row))
-	for i, v := range row {
-		result[i] = v
-	}
-	return result
-}
-
 func (pu *PostgresUpdater) Update(args ...[]string) error {
 	modTime, err := pu.fetchInfTime()
 	if err != nil {
@@ -297,20 +114,10 @@ func (pu *PostgresUpdater) Update(args ...[]string) error {
 	if modTime.After(storedTime) {
 		log.Printf("Updating data from %s...", pu.CSVURL)
 
-		var renamedCols []string
-		if len(args) > 0 {
-			renamedCols = args[0]
-		}
-
-		log.SetPrefix("CSV DATA FETCHER ")
-		csvData, err := pu.fetchCSVData(renamedCols)
-		if err != nil {
-			return err
-		}
-		log.SetPrefix("")
-
+		// переименование колонок не влияет на загрузку: COPY идёт по pu.Columns
 		log.SetPrefix("DATABASE UPDATER ")
-		if err := pu.updateDatabase(csvData); err != nil {
+		if err := pu.importCSV(); err != nil {
+			log.SetPrefix("")
 			return err
 		}
 		log.SetPrefix("")
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"gomarketplace_api/internal/wildberries/storage"
+	"net/http"
+	"strconv"
+)
+
+// defaultRunsLimit - сколько записей возвращается без параметра limit.
+const defaultRunsLimit = 50
+
+// RunsHandler возвращает HTTP API запусков обновления карточек:
+// /api/wildberries/runs?limit=50 - последние запуски с числом карточек по исходам,
+// /api/wildberries/runs/failures?run_id=...&limit=50 - карточки запуска, которые не удалось обновить.
+// Вызывается после Init.
+func (s *WildberriesServer) RunsHandler() http.Handler {
+	repo := storage.NewUpdateRunRepository(s.db)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/wildberries/runs", func(w http.ResponseWriter, r *http.Request) {
+		limit, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		runs, err := repo.ListRuns(r.Context(), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, runs)
+	})
+	mux.HandleFunc("/api/wildberries/runs/failures", func(w http.ResponseWriter, r *http.Request) {
+		runID := r.URL.Query().Get("run_id")
+		if runID == "" {
+			http.Error(w, "run_id is required", http.StatusBadRequest)
+			return
+		}
+		limit, err := queryLimit(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		failures, err := repo.ListFailures(r.Context(), runID, limit)
+		switch {
+		case errors.Is(err, storage.ErrRunNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, failures)
+	})
+	return mux
+}
+
+// queryLimit читает параметр limit запроса; без параметра - defaultRunsLimit.
+func queryLimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultRunsLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("limit must be a positive number")
+	}
+	return limit, nil
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
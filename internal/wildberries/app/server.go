@@ -3,8 +3,13 @@ package app
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"golang.org/x/time/rate"
 	"gomarketplace_api/config"
+	"gomarketplace_api/internal/core"
+	coreservices "gomarketplace_api/internal/core/services"
+	registry "gomarketplace_api/internal/registry/business"
+	registrystorage "gomarketplace_api/internal/registry/storage"
 	request2 "gomarketplace_api/internal/wildberries/business/models/dto/request"
 	"gomarketplace_api/internal/wildberries/business/models/dto/response"
 	"gomarketplace_api/internal/wildberries/business/services"
@@ -12,24 +17,31 @@ import (
 	"gomarketplace_api/internal/wildberries/business/services/parse"
 	update2 "gomarketplace_api/internal/wildberries/business/services/update"
 	"gomarketplace_api/internal/wildberries/business/services/update/operations/domain"
+	"gomarketplace_api/internal/wildberries/channel"
 	clients2 "gomarketplace_api/internal/wildberries/pkg/clients"
 	"gomarketplace_api/internal/wildberries/storage"
+	"gomarketplace_api/migrations/infrastructure"
 	"gomarketplace_api/migrations/marketplaces/wb"
 	"gomarketplace_api/pkg/business/service"
 	"gomarketplace_api/pkg/dbconnect"
 	"gomarketplace_api/pkg/dbconnect/migration"
 	"gomarketplace_api/pkg/logger"
 	"io"
-	"log"
 	"time"
 )
 
 type WildberriesServer struct {
 	cardUpdateService *update2.CardUpdateService
+	db                *sql.DB
+	preview           *update2.Preview
+	// articular - артикулы поставщика карточек, готовится в Init
+	articular *registry.ArticularService
 	dbconnect.Database
 	config.WildberriesConfig
-	log    logger.Logger
-	writer io.Writer
+	marginGuard *config.MarginGuardConfig
+	pricing     *config.PricingConfig
+	log         logger.Logger
+	writer      io.Writer
 }
 
 func NewWbServer(connector dbconnect.Database, wbConfig config.WildberriesConfig, writer io.Writer) *WildberriesServer {
@@ -37,34 +49,23 @@ func NewWbServer(connector dbconnect.Database, wbConfig config.WildberriesConfig
 	return &WildberriesServer{Database: connector, WildberriesConfig: wbConfig, log: _log, writer: writer}
 }
 
-func (s *WildberriesServer) Run() {
-	var authEngine services.AuthEngine
-	authEngine = services.NewBearerAuth(s.ApiKey)
+// WithMarginGuard включает проверку маржи цен перед выгрузкой в WB.
+func (s *WildberriesServer) WithMarginGuard(guard *config.MarginGuardConfig, pricing *config.PricingConfig) *WildberriesServer {
+	s.marginGuard, s.pricing = guard, pricing
+	return s
+}
 
-	var db, err = s.Connect()
-	if err != nil {
-		s.log.Log("Error connecting to PostgreSQL: %s\n", err)
-	}
-	defer db.Close()
+// wsUrl - адрес HTTP API поставщика, из которого берутся данные для карточек.
+const wsUrl = "http://localhost:8081"
 
-	searchConfig := get2.Config{
-		WorkerCount:    5,
-		MaxRetries:     get2.MaxRetries,
-		RetryInterval:  get2.RetryInterval,
-		RequestTimeout: get2.RequestTimeout,
+// Init подключается к БД, применяет миграции WB и готовит сервис обновления карточек.
+// Задачи WB (SyncNomenclatures, UpdateMedia и т.д.) вызываются после Init.
+func (s *WildberriesServer) Init() error {
+	db, err := s.Connect()
+	if err != nil {
+		return fmt.Errorf("error connecting to PostgreSQL: %w", err)
 	}
 
-	nomenclatureUpdGet := get2.NewSearchEngine(db, authEngine, s.writer, searchConfig)
-	s.cardUpdateService = update2.NewCardUpdateService(
-		nomenclatureUpdGet,
-		service.NewTextService(),
-		"http://localhost:8081",
-		authEngine,
-		s.log,
-		parse.NewBrandServiceWildberries(s.WbBanned.BannedBrands),
-		s.WbValues,
-	)
-
 	migrationApply := []migration.MigrationInterface{
 		&wb.CreateWBSchema{},
 		&wb.CreateWBCategoriesTable{},
@@ -74,85 +75,190 @@ func (s *WildberriesServer) Run() {
 		&wb.WBCardsActual{},
 		&wb.WBNomenclaturesHistory{},
 		&wb.WBChanges{},
+		&wb.WBCardsHistory{},
+		&wb.WBCardsActualIndex{},
+		&wb.WBUpdateRuns{},
+		&wb.WBNomenclatureSizes{},
+		&wb.WBStocksPushed{},
+		&wb.WBPricesPushed{},
+		&infrastructure.RegistrySchema{},
+		&infrastructure.RegistrySupplierTable{},
+		&infrastructure.RegistryArticularAccountingTable{},
+		&infrastructure.RegistryArticularSequence{},
+		&core.CoreSuppliers{},
+		&core.CoreProducts{},
+		&core.CoreProductsSupplierArticul{},
+		&core.CoreProductGroups{},
 	}
 
 	for _, _migration := range migrationApply {
 		if err := _migration.UpMigration(db); err != nil {
-			log.Fatalf("Migration failed: %v", err)
+			db.Close()
+			return fmt.Errorf("migration failed: %w", err)
 		}
 	}
 	s.log.Log("WB migrations applied successfully!")
 
-	//err = s.loadCharcs(db, authEngine)
-	//if err != nil {
-	//	s.log.FatalLog("Error loading Charcs: %w\n", err)
-	//}
-
-	//categories := []int{2865, 5071, 5073, 5067}
-	//for _, categoryID := range categories {
-	//	s.uploadProducts(authEngine, categoryID)
-	//	time.Sleep(30 * time.Second)
-	//}
-	//
-
-	//
-	//time.Sleep(5 * time.Second)
-	//
-	//_, err = s.cardUpdateService.UpdateDBNomenclatures(request2.Settings{
-	//	Sort:   request2.Sort{Ascending: false},
-	//	Filter: request2.Filter{WithPhoto: -1, TagIDs: []int{}, TextSearch: "", AllowedCategoriesOnly: true, ObjectIDs: []int{}, Brands: []string{}, ImtID: 0},
-	//	Cursor: request2.Cursor{Limit: 10000},
-	//}, "")
-	//if err != nil {
-	//	return
-	//}
-
-	//count, err := s.cardUpdateService.CheckSearchEngine(request2.Settings{
-	//	Sort:   request2.Sort{Ascending: false},
-	//	Filter: request2.Filter{WithPhoto: -1, TagIDs: []int{}, TextSearch: "", AllowedCategoriesOnly: true, ObjectIDs: []int{}, Brands: []string{}, ImtID: 0},
-	//	Cursor: request2.Cursor{Limit: 10000},
-	//}, "")
-	//if err != nil {
-	//	return
-	//}
-	//
-	//log.Printf("Search engine found %d nm's", count)
-
-	s.updateMediaFiles("http://localhost:8081")
+	s.db = db
+	s.articular = registry.NewArticularService(registrystorage.NewArticularRepository(db), s.WbIdentity.Code, s.WbIdentity.Supplier)
+	s.cardUpdateService = update2.NewCardUpdateService(
+		s.searchEngine(s.writer, s.searchConfig()),
+		service.NewTextService(),
+		wsUrl,
+		s.auth(),
+		s.log,
+		parse.NewBrandServiceWildberries(s.WbBanned.BannedBrands),
+		s.WbValues,
+		s.articular,
+	)
+	s.cardUpdateService.SetPreview(s.preview)
+	s.cardUpdateService.SetChangeRepository(storage.NewChangeRepository(db))
+	s.cardUpdateService.SetHistoryRepository(storage.NewNomenclatureHistoryRepository(db))
+	if s.preview == nil {
+		s.cardUpdateService.SetRunRepository(storage.NewUpdateRunRepository(db))
+	}
+	return nil
 }
 
-func (s *WildberriesServer) updateMediaFiles(wsClientUrl string) {
-	s.log.SetPrefix("[ Media Updater ] ")
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// searchEngine создаёт поиск карточек WB, который сохраняет полученные карточки
+// в wildberries.nomenclatures_history. В режиме dry-run версии не сохраняются.
+func (s *WildberriesServer) searchEngine(writer io.Writer, config get2.Config) *get2.SearchEngine {
+	engine := get2.NewSearchEngine(s.db, s.auth(), writer, config, s.articular)
+	if s.preview == nil {
+		engine.SetHistoryRepository(storage.NewNomenclatureHistoryRepository(s.db))
+	}
+	return engine
+}
 
-	var authEngine services.AuthEngine
-	authEngine = services.NewBearerAuth(s.ApiKey)
+func (s *WildberriesServer) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
 
-	var db, err = s.Connect()
-	if err != nil {
-		s.log.Log("Error connecting to PostgreSQL: %s\n", err)
+// Run однократно выгружает остатки, цены и медиа.
+func (s *WildberriesServer) Run() {
+	if err := s.Init(); err != nil {
+		s.log.Log("WB server not started: %v", err)
+		return
 	}
-	defer db.Close()
+	defer s.Close()
 
-	client, err := clients2.NewWServiceClient(wsClientUrl, s.log)
-	if err != nil {
-		log.Fatal(err)
+	ctx := context.Background()
+	if err := s.SyncStocks(ctx); err != nil {
+		s.log.Log("Stocks sync failed: %v", err)
 	}
+	if err := s.SyncPrices(ctx); err != nil {
+		s.log.Log("Prices sync failed: %v", err)
+	}
+	if err := s.UpdateMedia(ctx); err != nil {
+		s.log.Log("Media update failed: %v", err)
+	}
+}
 
-	searchConfig := get2.Config{
+func (s *WildberriesServer) auth() services.AuthEngine {
+	return services.NewBearerAuth(s.ApiKey)
+}
+
+func (s *WildberriesServer) searchConfig() get2.Config {
+	return get2.Config{
 		WorkerCount:    5,
 		MaxRetries:     get2.MaxRetries,
 		RetryInterval:  get2.RetryInterval,
 		RequestTimeout: get2.RequestTimeout,
 	}
+}
 
-	nmSearchEngine := get2.NewSearchEngine(db, authEngine, s.log, searchConfig)
+// allCardsSettings - выборка всех карточек продавца.
+func allCardsSettings(withPhoto int) request2.Settings {
+	return request2.Settings{
+		Sort:   request2.Sort{Ascending: false},
+		Filter: request2.Filter{WithPhoto: withPhoto, TagIDs: []int{}, TextSearch: "", AllowedCategoriesOnly: true, ObjectIDs: []int{}, Brands: []string{}, ImtID: 0},
+		Cursor: request2.Cursor{Limit: 10000},
+	}
+}
 
-	updateOp := domain.NewMediaUpdateOperation(client)
-	_, err = updateOp.MediaUrls(ctx, false)
+// SyncNomenclatures загружает номенклатуры WB в wildberries.nomenclatures.
+func (s *WildberriesServer) SyncNomenclatures(ctx context.Context) error {
+	count, err := s.cardUpdateService.UpdateDBNomenclatures(ctx, allCardsSettings(-1), "")
 	if err != nil {
-		return
+		return err
+	}
+	s.log.Log("Nomenclatures synced: %d", count)
+	return nil
+}
+
+// RefreshCharacteristics обновляет характеристики всех категорий WB.
+func (s *WildberriesServer) RefreshCharacteristics(ctx context.Context) error {
+	_, err := s.LoadCharacteristics(ctx, nil)
+	return err
+}
+
+// SyncPrices выгружает цены, если выгрузка включена в конфиге.
+func (s *WildberriesServer) SyncPrices(ctx context.Context) error {
+	if !s.Prices.Enabled {
+		s.log.Log("Prices sync disabled. Skipping.")
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	wbChannel, err := s.channel()
+	if err != nil {
+		return err
+	}
+	wsClient, err := clients2.NewWServiceClient(wsUrl, s.writer)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler client: %w", err)
+	}
+
+	guard, err := coreservices.LoadMarginGuard(s.db, s.marginGuard, s.pricing, s.writer)
+	if err != nil {
+		return fmt.Errorf("margin guard failed: %w", err)
+	}
+
+	priceService := update2.NewPriceSyncService(wbChannel, wsClient, storage.NewPriceRepository(s.db), guard, s.Prices.DryRun, s.writer)
+	_, err = priceService.Sync(ctx)
+	return err
+}
+
+// SyncStocks выгружает остатки, если задан склад продавца.
+func (s *WildberriesServer) SyncStocks(ctx context.Context) error {
+	if s.WarehouseID == 0 {
+		s.log.Log("Warehouse not configured, stocks sync disabled. Skipping.")
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	wbChannel, err := s.channel()
+	if err != nil {
+		return err
+	}
+
+	stockService := update2.NewStockSyncService(wbChannel, s.searchEngine(s.writer, s.searchConfig()), storage.NewStockRepository(s.db), s.WarehouseID, s.writer)
+	_, err = stockService.Sync(ctx)
+	return err
+}
+
+// UpdateMedia обновляет фотографии карточек по медиа поставщика.
+func (s *WildberriesServer) UpdateMedia(ctx context.Context) error {
+	mediaLog := s.log.WithPrefix("[Media Updater]")
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	authEngine := s.auth()
+	client, err := clients2.NewWServiceClient(wsUrl, mediaLog)
+	if err != nil {
+		return err
+	}
+
+	nmSearchEngine := s.searchEngine(mediaLog, s.searchConfig())
+
+	updateOp := domain.NewMediaUpdateOperation(client, s.articular)
+	if _, err = updateOp.MediaUrls(ctx, false); err != nil {
+		return fmt.Errorf("failed to load media urls: %w", err)
 	}
 	limiter := rate.NewLimiter(rate.Every(time.Minute/50), 15)
 
@@ -162,59 +268,145 @@ func (s *WildberriesServer) updateMediaFiles(wsClientUrl string) {
 		limiter,
 		5,
 		authEngine)
+	mediaUpateService.SetPreview(s.preview)
+	mediaUpateService.SetChangeRepository(storage.NewChangeRepository(s.db))
+	if s.preview == nil {
+		mediaUpateService.SetRunRepository(storage.NewUpdateRunRepository(s.db))
+	}
+	settings := allCardsSettings(-1)
+	mediaUpateService.SetSettings(settings)
 
-	nomenclatureChan := make(chan response.Nomenclature)
-	go func() {
+	updatedCount, err := mediaUpateService.Update(ctx, func(ctx context.Context, nomenclatureChan chan response.Nomenclature) error {
+		return nmSearchEngine.GetNomenclaturesWithLimitConcurrentlyPutIntoChannel(ctx, settings, "", nomenclatureChan)
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка обновления: %w", err)
+	}
 
-		settings := request2.Settings{
-			Sort:   request2.Sort{Ascending: false},
-			Filter: request2.Filter{WithPhoto: -1, TagIDs: []int{}, TextSearch: "", AllowedCategoriesOnly: true, ObjectIDs: []int{}, Brands: []string{}, ImtID: 0},
-			Cursor: request2.Cursor{Limit: 10000}}
-		locale := ""
+	metrics := mediaUpateService.Metrics()
 
-		if err := nmSearchEngine.GetNomenclaturesWithLimitConcurrentlyPutIntoChannel(ctx, settings, locale, nomenclatureChan); err != nil {
-			log.Printf("Error fetching nomenclatures concurrently: %s", err)
-		}
-	}()
+	mediaLog.Log("Обновлено карточек: %d (запуск %s)", updatedCount, mediaUpateService.LastRunID())
+	mediaLog.Log("Обработано карточек: %d, с ошибками: %d, без изменений: %d",
+		metrics.ProcessedCount.Load(), metrics.ErroredNomenclatures.Load(), metrics.UnchangedCount.Load())
+	return nil
+}
 
-	updatedCount, err := mediaUpateService.Update(ctx, nomenclatureChan)
+// UpdateNames обновляет наименования и описания карточек с фото.
+func (s *WildberriesServer) UpdateNames(ctx context.Context) error {
+	updated, err := s.RenameCards(ctx, allCardsSettings(1))
 	if err != nil {
-		log.Fatalf("Ошибка обновления: %s", err)
+		return err
 	}
+	s.log.Log("Updated %d nomenclatures", updated)
+	return nil
+}
 
-	metrics := mediaUpateService.Metrics()
+// WithPreview включает режим dry-run: операции с карточками готовят и проверяют запросы к WB
+// и записывают их вместе с изменениями карточек в sink, ничего не отправляя. Вызывается до Init.
+func (s *WildberriesServer) WithPreview(sink update2.PreviewSink) *WildberriesServer {
+	s.preview = update2.NewPreview(sink)
+	return s
+}
 
-	log.Printf("Обновлено карточек: %d", updatedCount)
-	log.Printf("Обработано карточек: %d, с ошибками: %d",
-		metrics.ProcessedCount.Load(), metrics.ErroredNomenclatures.Load())
+// Preview возвращает dry-run операций с карточками; nil, если режим выключен.
+func (s *WildberriesServer) Preview() *update2.Preview {
+	return s.preview
 }
 
-func (s *WildberriesServer) updateNames() interface{} {
+// RenameCards обновляет наименования и описания карточек по выборке settings.
+func (s *WildberriesServer) RenameCards(ctx context.Context, settings request2.Settings) (int, error) {
 	s.log.Log("Naming updater ")
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	updateMedia, err := s.cardUpdateService.UpdateCardNaming(ctx, request2.Settings{
-		Sort:   request2.Sort{Ascending: false},
-		Filter: request2.Filter{WithPhoto: 1, TagIDs: []int{}, TextSearch: "", AllowedCategoriesOnly: true, ObjectIDs: []int{}, Brands: []string{}, ImtID: 0},
-		Cursor: request2.Cursor{Limit: 10000},
-	})
-
+	updated, err := s.cardUpdateService.UpdateCardNaming(ctx, settings)
 	if err != nil {
-		s.log.FatalLog("Error updating nomenclatures: %s\n", err)
+		return 0, fmt.Errorf("error updating nomenclatures: %w", err)
 	}
-	s.log.Log("\n\n\nUpdated %d nomenclatures\n", updateMedia)
+	s.log.Log("Skipped %d unchanged nomenclatures", s.cardUpdateService.Metrics().UnchangedCount.Load())
+	return updated, nil
+}
 
-	return updateMedia
+// RollbackCard повторно загружает в WB версию version карточки nmID из wildberries.nomenclatures_history.
+func (s *WildberriesServer) RollbackCard(ctx context.Context, nmID, version int) (int, error) {
+	return s.cardUpdateService.RollbackCard(ctx, nmID, version)
 }
 
-func (s *WildberriesServer) uploadProducts(ctx context.Context, auth services.AuthEngine, categoryID int) interface{} {
-	wsUrl := "http://localhost:8081"
-	textService := service.NewTextService()
-	db, err := s.Database.Connect()
+// RollbackRun возвращает карточки, обновлённые в запуске runID, к версиям до запуска.
+func (s *WildberriesServer) RollbackRun(ctx context.Context, runID string) (int, error) {
+	return s.cardUpdateService.RollbackRun(ctx, runID)
+}
+
+// LastRunID возвращает ID последнего запуска обновления карточек.
+func (s *WildberriesServer) LastRunID() string {
+	return s.cardUpdateService.LastRunID()
+}
+
+// CategoryUpdate - итог UpdateCategoryCards: обновлённые карточки и ID запусков наименований и упаковки.
+type CategoryUpdate struct {
+	Names    int
+	Packages int
+	RunIDs   []string
+}
+
+// UpdateCategoryCards обновляет наименования и габариты упаковки карточек по выборке settings
+// (обычно по ObjectIDs категорий). Каждое обновление - отдельный запуск, в RunIDs - ID начатых.
+func (s *WildberriesServer) UpdateCategoryCards(ctx context.Context, settings request2.Settings) (CategoryUpdate, error) {
+	var update CategoryUpdate
+	var err error
+	update.Names, err = s.cardUpdateService.UpdateCardNaming(ctx, settings)
+	update.RunIDs = append(update.RunIDs, s.cardUpdateService.LastRunID())
+	if err != nil {
+		return update, fmt.Errorf("error updating appellations: %w", err)
+	}
+	update.Packages, err = s.cardUpdateService.UpdateCardPackages(ctx, settings)
+	update.RunIDs = append(update.RunIDs, s.cardUpdateService.LastRunID())
 	if err != nil {
-		s.log.FatalLog("Error connecting to PostgreSQL: %s\n", err)
+		return update, fmt.Errorf("error updating packages: %w", err)
+	}
+	s.log.Log("Updated appellations for %d nomenclatures (run %s), packages for %d nomenclatures (run %s)",
+		update.Names, update.RunIDs[0], update.Packages, update.RunIDs[1])
+	return update, nil
+}
+
+// UploadNomenclatures загружает номенклатуры WB по выборке settings в wildberries.nomenclatures.
+// В режиме dry-run номенклатуры только считаются.
+func (s *WildberriesServer) UploadNomenclatures(ctx context.Context, settings request2.Settings) (int, error) {
+	if s.preview != nil {
+		return s.CountNomenclatures(settings)
+	}
+	return s.cardUpdateService.UpdateDBNomenclatures(ctx, settings, "")
+}
+
+// CountNomenclatures возвращает число номенклатур WB по выборке settings.
+func (s *WildberriesServer) CountNomenclatures(settings request2.Settings) (int, error) {
+	return s.cardUpdateService.CheckSearchEngine(settings, "")
+}
+
+// LoadCharacteristics обновляет характеристики категорий subjectIDs, пустой список - всех категорий.
+// Возвращает число обновлённых категорий; в режиме dry-run - число категорий к обновлению.
+func (s *WildberriesServer) LoadCharacteristics(ctx context.Context, subjectIDs []int) (int, error) {
+	if len(subjectIDs) == 0 {
+		cats, err := get2.NewDBCategories(s.db).Categories()
+		if err != nil {
+			return 0, err
+		}
+		subjectIDs = make([]int, len(cats))
+		for i, v := range cats {
+			subjectIDs[i] = v.SubjectID
+		}
+	}
+	if s.preview != nil {
+		return len(subjectIDs), nil
 	}
 
+	charcUpdate := get2.NewUpdateDBCharcs(s.db, *get2.NewCharacteristicService(s.auth()))
+	if _, err := charcUpdate.UpdateDBCharcs(ctx, subjectIDs); err != nil {
+		return 0, err
+	}
+	return len(subjectIDs), nil
+}
+
+// UploadProducts создаёт в категории categoryID карточки товаров поставщика, которых ещё нет на WB.
+// accuracy - допустимая доля несовпадения категории поставщика. Возвращает число отправленных карточек.
+func (s *WildberriesServer) UploadProducts(ctx context.Context, categoryID int, accuracy float32) (int, error) {
 	searchConfig := get2.Config{
 		WorkerCount:    get2.WorkerCount,
 		MaxRetries:     get2.MaxRetries,
@@ -222,83 +414,67 @@ func (s *WildberriesServer) uploadProducts(ctx context.Context, auth services.Au
 		RequestTimeout: get2.RequestTimeout,
 	}
 
-	engine := get2.NewSearchEngine(db, auth, s.writer, searchConfig)
-	repo := storage.NewNomenclatureRepository(db)
-
+	engine := s.searchEngine(s.writer, searchConfig)
+	repo := storage.NewNomenclatureRepository(s.db)
 	nmService := update2.NewNomenclatureService(*engine, *repo)
-	cardService := update2.NewCardService(wsUrl, textService, s.writer, s.WildberriesConfig)
 
-	accuracy := float32(0.3)
+	wbChannel, err := s.channel()
+	if err != nil {
+		return 0, err
+	}
+
 	result, err := nmService.GetSetOfUncreatedItemsWithCategories(accuracy, true, categoryID)
+	if err != nil {
+		return 0, err
+	}
 
-	ids := make([]int, len(result))
-	for k, _ := range result {
+	ids := make([]int, 0, len(result))
+	for k := range result {
 		ids = append(ids, k)
 	}
 
 	uploadContext, cancel := context.WithTimeout(ctx, 3*time.Minute)
 	defer cancel()
 
-	resultIDs, err := cardService.PrepareAndUpload(uploadContext, ids)
-	if err != nil {
-		return nil
-	}
-
-	req := []request2.CreateCardRequestWrapper{}
-	for i, v := range resultIDs.([]request2.CreateCardRequestData) {
-		req = append(req, request2.CreateCardRequestWrapper{})
-		req[i].Variants = append(req[i].Variants, v)
-		req[i].SubjectID = categoryID
-	}
+	return wbChannel.CreateCards(uploadContext, categoryID, ids)
+}
 
-	_, _, err = cardService.SendToServerModels(req)
+// SyncCategories загружает предметы WB в wildberries.categories: по ним обновляются характеристики.
+// В режиме dry-run предметы только считаются.
+func (s *WildberriesServer) SyncCategories(ctx context.Context) (int, error) {
+	wbChannel, err := s.channel()
 	if err != nil {
-		return nil
+		return 0, err
 	}
-
-	s.log.Log("Category %d. Total count of items : %d", categoryID, len(resultIDs.([]request2.CreateCardRequestData)))
-	return struct{}{}
-}
-
-func (s *WildberriesServer) loadCharcs(db *sql.DB, engine services.AuthEngine) error {
-	charcUpdate := get2.NewUpdateDBCharcs(db, *get2.NewCharacteristicService(engine))
-	catsRepo := get2.NewDBCategories(db)
-	cats, err := catsRepo.Categories()
+	categories, err := wbChannel.Categories(ctx)
 	if err != nil {
-		s.log.FatalLog(err.Error())
-		return err
+		return 0, err
 	}
-	catIDs := make([]int, len(cats))
-	for i, v := range cats {
-		catIDs[i] = v.SubjectID
+	if s.preview != nil {
+		return len(categories), nil
 	}
-	_, err = charcUpdate.UpdateDBCharcs(catIDs)
-	if err != nil {
-		s.log.FatalLog(err.Error())
-		return err
+
+	rows := make([]response.Category, len(categories))
+	for i, category := range categories {
+		rows[i] = response.Category{
+			SubjectID:   category.ID,
+			ParentID:    category.ParentID,
+			SubjectName: category.Name,
+			ParentName:  category.ParentName,
+		}
 	}
-	return nil
+	if err := storage.NewWbCategoriesRepository(s.db).SaveCategories(ctx, rows); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
 }
 
-func (s *WildberriesServer) updateByCategoryId() error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	updateAppellation, err := s.cardUpdateService.UpdateCardNaming(ctx, request2.Settings{
-		Sort:   request2.Sort{Ascending: false},
-		Filter: request2.Filter{WithPhoto: -1, TagIDs: []int{}, TextSearch: "", AllowedCategoriesOnly: true, ObjectIDs: []int{5067}, Brands: []string{}, ImtID: 0},
-		Cursor: request2.Cursor{Limit: 1500},
-	})
-	updatePackages, err := s.cardUpdateService.UpdateCardPackages(request2.Settings{
-		Sort:   request2.Sort{Ascending: false},
-		Filter: request2.Filter{WithPhoto: -1, TagIDs: []int{}, TextSearch: "", AllowedCategoriesOnly: true, ObjectIDs: []int{5067}, Brands: []string{}, ImtID: 0},
-		Cursor: request2.Cursor{Limit: 1500},
-	})
+// channel создаёт канал WB. В режиме dry-run создаваемые каналом карточки записываются в preview.
+func (s *WildberriesServer) channel() (*channel.WildberriesChannel, error) {
+	wbChannel, err := channel.NewWildberriesChannel(s.db, s.WildberriesConfig, wsUrl, s.writer)
 	if err != nil {
-		s.log.FatalLog("Error updating nomenclatures: %s\n", err)
+		return nil, fmt.Errorf("failed to create wildberries channel: %w", err)
 	}
-	s.log.Log(
-		"Updated appellations for %d nomenclatures\n"+
-			"Updated media for %d nomenclatures\n"+
-			"Updated packages for %d nomenclatures", updateAppellation, updatePackages)
-	return nil
+	wbChannel.SetPreview(s.preview)
+	return wbChannel, nil
 }
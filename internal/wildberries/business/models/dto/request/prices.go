@@ -0,0 +1,12 @@
+package request
+
+// PricesUploadRequest - тело POST /api/v2/upload/task.
+type PricesUploadRequest struct {
+	Data []PriceItem `json:"data"`
+}
+
+type PriceItem struct {
+	NmID     int `json:"nmID"`
+	Price    int `json:"price"`    // цена до скидки
+	Discount int `json:"discount"` // скидка в процентах
+}
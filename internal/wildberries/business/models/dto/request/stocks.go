@@ -0,0 +1,11 @@
+package request
+
+// StocksRequest - тело PUT /api/v3/stocks/{warehouseId}.
+type StocksRequest struct {
+	Stocks []StockItem `json:"stocks"`
+}
+
+type StockItem struct {
+	Sku    string `json:"sku"`    // баркод размера
+	Amount int    `json:"amount"` // остаток на складе продавца
+}
@@ -1,11 +1,5 @@
 package response
 
-import (
-	"fmt"
-	"regexp"
-	"strconv"
-)
-
 type Nomenclature struct {
 	NmID            int        `json:"nmID"`
 	ImtID           int        `json:"imtID"`
@@ -15,6 +9,7 @@ type Nomenclature struct {
 	SubjectName     string     `json:"subjectName"`
 	Brand           string     `json:"brand"`
 	Title           string     `json:"title"`
+	Description     string     `json:"description"`
 	Photos          []Photo    `json:"photos"`
 	Video           string     `json:"video"`
 	Dimensions      Dimensions `json:"dimensions"`
@@ -24,22 +19,3 @@ type Nomenclature struct {
 	CreatedAt       string     `json:"createdAt"`
 	UpdatedAt       string     `json:"updatedAt"`
 }
-
-func (n *Nomenclature) GlobalID() (int, error) {
-	pattern := `\w*-(\d*)-\w*`
-	re := regexp.MustCompile(pattern)
-	match := re.FindAllStringSubmatch(n.VendorCode, -1)
-
-	// Проверяем, что найдено хотя бы одно совпадение и нужная группа
-	if len(match) == 0 || len(match[0]) < 2 {
-		return 0, fmt.Errorf("no match found in VendorCode: %s", n.VendorCode)
-	}
-
-	// Преобразуем найденное значение в int
-	globalID, err := strconv.Atoi(match[0][1])
-	if err != nil {
-		return 0, fmt.Errorf("failed to convert global ID: %w", err)
-	}
-
-	return globalID, nil
-}
@@ -0,0 +1,11 @@
+package response
+
+// PricesUploadResponse - ответ на загрузку цен и скидок.
+type PricesUploadResponse struct {
+	Data struct {
+		ID            int  `json:"id"`
+		AlreadyExists bool `json:"alreadyExists"`
+	} `json:"data"`
+	Error     bool   `json:"error"`
+	ErrorText string `json:"errorText"`
+}
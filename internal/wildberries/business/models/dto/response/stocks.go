@@ -0,0 +1,13 @@
+package response
+
+// StockError - элемент ответа 409 на обновление остатков.
+type StockError struct {
+	Code    string           `json:"code"`
+	Message string           `json:"message"`
+	Data    []StockErrorData `json:"data"`
+}
+
+type StockErrorData struct {
+	Sku    string `json:"sku"`
+	Amount int    `json:"amount"`
+}
@@ -0,0 +1,20 @@
+package get
+
+import "encoding/json"
+
+// CardChange - обновление карточки WB, отправленное в WB. Запрос сохраняется новой версией
+// в wildberries.cards_history, изменения - в wildberries.changes: по строке на каждое изменённое поле
+// с той же версией.
+type CardChange struct {
+	NmID        int
+	VendorCode  string
+	RunID       string          // запуск обновления
+	Payload     json.RawMessage // отправленный в WB запрос по карточке
+	Description []string        // описания изменённых полей, например: Бренд: «A» → «B»
+}
+
+// CardVersion - версия карточки из wildberries.nomenclatures_history.
+type CardVersion struct {
+	NmID    int
+	Version int
+}
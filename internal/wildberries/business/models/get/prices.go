@@ -0,0 +1,29 @@
+package get
+
+import "math"
+
+// PriceTarget - карточка WB с последними выгруженными ценой и скидкой.
+type PriceTarget struct {
+	GlobalID     int
+	NmID         int
+	LastPrice    *int // nil, если цена ещё не выгружалась
+	LastDiscount *int
+}
+
+// PricePushed - выгруженные в WB цена до скидки и скидка в процентах.
+type PricePushed struct {
+	GlobalID int
+	NmID     int
+	Price    int
+	Discount int
+}
+
+// PriceAndDiscount переводит цену до скидки и цену продажи в формат WB: цена и целая скидка в процентах.
+// Скидка округляется вниз, чтобы цена продажи на WB не оказалась ниже рассчитанной.
+func PriceAndDiscount(price, discountedPrice int) (int, int) {
+	if price <= discountedPrice || price <= 0 {
+		return max(discountedPrice, price), 0
+	}
+	discount := int(math.Floor((1 - float64(discountedPrice)/float64(price)) * 100))
+	return price, min(max(discount, 0), 99)
+}
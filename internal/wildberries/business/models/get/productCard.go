@@ -23,6 +23,7 @@ func (c *WildberriesCard) FromNomenclature(n response2.Nomenclature) *Wildberrie
 	card.VendorCode = n.VendorCode
 	card.Brand = n.Brand
 	card.Title = n.Title
+	card.Description = n.Description
 	card.Characteristics = n.Characteristics
 	card.Sizes = n.Sizes
 	card.Dimensions = *n.Dimensions.Unwrap()
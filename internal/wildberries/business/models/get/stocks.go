@@ -0,0 +1,19 @@
+package get
+
+// NomenclatureSize - размер карточки WB с его баркодами. Хранится в wildberries.nomenclature_sizes.
+type NomenclatureSize struct {
+	ChrtID   int
+	NmID     int
+	TechSize string
+	Skus     []string
+}
+
+// StockTarget - баркод размера карточки с остатком wholesaler и последним выгруженным значением.
+type StockTarget struct {
+	GlobalID   int
+	NmID       int
+	ChrtID     int
+	Sku        string
+	Stocks     int
+	LastPushed *int // nil, если остаток по баркоду ещё не выгружался
+}
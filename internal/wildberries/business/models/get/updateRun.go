@@ -0,0 +1,45 @@
+package get
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы запуска обновления карточек.
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusFailed  = "failed"
+)
+
+// Исходы обработки карточки в запуске обновления.
+const (
+	OutcomeSkippedInvalid = "skipped_invalid" // карточка не прошла проверку или для неё нет данных поставщика
+	OutcomeUnchanged      = "unchanged"       // обновление ничего не меняет в карточке
+	OutcomeProcessed      = "processed"       // запрос подготовлен (в dry-run - записан в preview)
+	OutcomeUploaded       = "uploaded"        // WB принял запрос
+	OutcomeRejected       = "rejected"        // WB отклонил запрос, текст ошибки в Error
+	OutcomeBanned         = "banned"          // артикул забанен на WB
+)
+
+// UpdateRun - запуск обновления карточек WB. Хранится в wildberries.update_runs.
+type UpdateRun struct {
+	RunID      string          `json:"run_id"`
+	Operation  string          `json:"operation"`
+	Settings   json.RawMessage `json:"settings,omitempty"`
+	Status     string          `json:"status"`
+	Error      string          `json:"error,omitempty"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Outcomes   map[string]int  `json:"outcomes"` // число карточек по исходам
+}
+
+// UpdateRunCard - исход обработки карточки в запуске. Хранится в wildberries.update_run_cards.
+type UpdateRunCard struct {
+	RunID      string    `json:"run_id"`
+	NmID       int       `json:"nm_id"`
+	VendorCode string    `json:"vendor_code,omitempty"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
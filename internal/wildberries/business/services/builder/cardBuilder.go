@@ -24,6 +24,7 @@ func (b *CardBuilder) FromNomenclature(n response.Nomenclature) *CardBuilder {
 	b.card.VendorCode = n.VendorCode
 	b.card.Brand = n.Brand
 	b.card.Title = n.Title
+	b.card.Description = n.Description
 	b.card.Characteristics = n.Characteristics
 	b.card.Sizes = n.Sizes
 	b.card.Dimensions = *n.Dimensions.Unwrap()
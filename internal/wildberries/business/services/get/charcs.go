@@ -26,7 +26,7 @@ func NewCharacteristicService(auth services.AuthEngine) *CharacteristicsEngine {
 	}
 }
 
-func (s *CharacteristicsEngine) GetItemCharcs(subjectID int, locale string) (*responses.CharacteristicsResponse, error) {
+func (s *CharacteristicsEngine) GetItemCharcs(ctx context.Context, subjectID int, locale string) (*responses.CharacteristicsResponse, error) {
 	url := fmt.Sprintf(characteristicsURL, subjectID)
 	if locale != "" {
 		url = fmt.Sprintf("%s?locale=%s", url, locale)
@@ -34,7 +34,7 @@ func (s *CharacteristicsEngine) GetItemCharcs(subjectID int, locale string) (*re
 
 	client := &http.Client{Timeout: 10 * time.Second}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +68,8 @@ func NewUpdateDBCharcs(db *sql.DB, service CharacteristicsEngine) *UpdateDBCharc
 	return &UpdateDBCharcs{db: db, CharacteristicsEngine: service}
 }
 
-func (d *UpdateDBCharcs) UpdateDBCharcs(subjectIDs []int) (int, error) {
+// UpdateDBCharcs загружает характеристики категорий subjectIDs; отмена ctx останавливает загрузку.
+func (d *UpdateDBCharcs) UpdateDBCharcs(ctx context.Context, subjectIDs []int) (int, error) {
 	const batchSize = 5 // Размер пачки для вставки
 	updated := 0
 	var batch []interface{}
@@ -82,10 +83,10 @@ func (d *UpdateDBCharcs) UpdateDBCharcs(subjectIDs []int) (int, error) {
 	for _, subjectID := range subjectIDs {
 
 		log.Printf("%d", subjectID)
-		if err := limiter.Wait(context.Background()); err != nil {
+		if err := limiter.Wait(ctx); err != nil {
 			return -1, err
 		}
-		response, err := d.GetItemCharcs(subjectID, "")
+		response, err := d.GetItemCharcs(ctx, subjectID, "")
 		if err != nil {
 			return -1, err
 		}
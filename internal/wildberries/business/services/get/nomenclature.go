@@ -7,11 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"golang.org/x/time/rate"
+	registry "gomarketplace_api/internal/registry/business"
 	"gomarketplace_api/internal/suppliers/wholesaler/pkg/clients"
 	"gomarketplace_api/internal/wildberries/business/dto/responses"
 	request2 "gomarketplace_api/internal/wildberries/business/models/dto/request"
 	"gomarketplace_api/internal/wildberries/business/models/dto/response"
 	"gomarketplace_api/internal/wildberries/business/services"
+	"gomarketplace_api/internal/wildberries/storage"
 	"io"
 	"log"
 	"net/http"
@@ -50,21 +52,32 @@ type SearchEngine struct {
 	writer  io.Writer
 	config  Config
 	limiter *rate.Limiter
+	// history - версии полученных карточек; nil - не сохранять
+	history *storage.NomenclatureHistoryRepository
+	// articular - артикулы поставщика карточек по vendor code
+	articular *registry.ArticularService
 }
 
-func NewSearchEngine(db *sql.DB, auth services.AuthEngine, writer io.Writer, config Config) *SearchEngine {
+func NewSearchEngine(db *sql.DB, auth services.AuthEngine, writer io.Writer, config Config, articular *registry.ArticularService) *SearchEngine {
 	return &SearchEngine{
-		db:      db,
-		auth:    auth,
-		writer:  writer,
-		config:  config,
-		limiter: rate.NewLimiter(rate.Every(time.Minute/70), 10),
+		db:        db,
+		auth:      auth,
+		writer:    writer,
+		config:    config,
+		limiter:   rate.NewLimiter(rate.Every(time.Minute/70), 10),
+		articular: articular,
 	}
 }
 
+// SetHistoryRepository включает сохранение полученных карточек в wildberries.nomenclatures_history
+// и wildberries.cards_actual.
+func (d *SearchEngine) SetHistoryRepository(history *storage.NomenclatureHistoryRepository) {
+	d.history = history
+}
+
 const postNomenclature = "https://content-api.wildberries.ru/content/v2/get/cards/list"
 
-func (d *SearchEngine) GetNomenclatures(settings request2.Settings, locale string) (*responses.NomenclatureResponse, error) {
+func (d *SearchEngine) GetNomenclatures(ctx context.Context, settings request2.Settings, locale string) (*responses.NomenclatureResponse, error) {
 	url := postNomenclature
 	if locale != "" {
 		url = fmt.Sprintf("%s?locale=%s", url, locale)
@@ -77,7 +90,7 @@ func (d *SearchEngine) GetNomenclatures(settings request2.Settings, locale strin
 		return nil, fmt.Errorf("creating request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, requestBody)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -100,6 +113,11 @@ func (d *SearchEngine) GetNomenclatures(settings request2.Settings, locale strin
 		return nil, fmt.Errorf("decoding response: %w", err)
 	}
 
+	if d.history != nil {
+		if err := d.history.SaveSnapshots(ctx, nomenclatureResponse.Data); err != nil {
+			log.Printf("Error saving nomenclature versions: %s", err)
+		}
+	}
 	return &nomenclatureResponse, nil
 }
 
@@ -351,7 +369,7 @@ func (d *SearchEngine) retryGetNomenclatures(
 		}
 
 		settings.Cursor = *cursor
-		nomenclatureResponse, err := d.GetNomenclatures(settings, locale)
+		nomenclatureResponse, err := d.GetNomenclatures(ctx, settings, locale)
 		if err == nil {
 			return nomenclatureResponse, nil
 		}
@@ -359,7 +377,11 @@ func (d *SearchEngine) retryGetNomenclatures(
 		if errors.Is(err, ErrConnectionAborted) {
 			log.Printf("Retrying to get nomenclatures due to connection error. Attempt: %d", retry+1)
 			lastErr = err
-			time.Sleep(d.config.RetryInterval)
+			select {
+			case <-ctx.Done():
+				return nil, ErrContextCanceled
+			case <-time.After(d.config.RetryInterval):
+			}
 			continue
 		}
 
@@ -393,9 +415,9 @@ func (d *SearchEngine) prepareGlobalIDs() (map[int]struct{}, error) {
 }
 
 /*
-Возвращает число обновленных(добавленных) карточек
+Возвращает число обновленных(добавленных) карточек. Отмена ctx останавливает загрузку.
 */
-func (d *SearchEngine) UploadToDb(settings request2.Settings, locale string) (int, error) {
+func (d *SearchEngine) UploadToDb(ctx context.Context, settings request2.Settings, locale string) (int, error) {
 	log.Printf("Updating wildberries.nomenclatures")
 	log.SetPrefix("NM UPDATER | ")
 
@@ -409,10 +431,10 @@ func (d *SearchEngine) UploadToDb(settings request2.Settings, locale string) (in
 	}
 	client := clients.NewGlobalIDsClient("http://localhost:8081", d.writer)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	result, err := client.Fetch(ctx, nil)
+	result, err := client.Fetch(fetchCtx, nil)
 	if err != nil {
 		return -1, fmt.Errorf("error fetching Global IDs: %w", err)
 	}
@@ -428,13 +450,17 @@ func (d *SearchEngine) UploadToDb(settings request2.Settings, locale string) (in
 	}
 
 	nomenclatureChan := make(chan response.Nomenclature)
-	ctx, cancel = context.WithCancel(context.Background())
 	log.Println("Fetching and sending nomenclatures to the channel...")
 
-	defer cancel()
+	// канал номенклатур закрывается только после последней страницы: при ошибке или отмене ctx
+	// чтение заканчивается по fetched
+	fetched := make(chan struct{})
+	var fetchErr error
 	go func() {
+		defer close(fetched)
 		if err := d.GetNomenclaturesWithLimitConcurrentlyPutIntoChannel(ctx, settings, locale, nomenclatureChan); err != nil {
 			log.Printf("Error fetching nomenclatures concurrently: %s", err)
+			fetchErr = err
 		}
 	}()
 
@@ -449,11 +475,22 @@ func (d *SearchEngine) UploadToDb(settings request2.Settings, locale string) (in
 			wg.Done()
 			log.Printf("Nomenclature upload channel closed. Returning")
 		}()
-		for nomenclature := range nomenclatureChan {
+		for {
+			var nomenclature response.Nomenclature
+			select {
+			case nom, ok := <-nomenclatureChan:
+				if !ok {
+					return
+				}
+				nomenclature = nom
+			case <-fetched:
+				return
+			}
+
 			mu.Lock()
 			saw++
 			mu.Unlock()
-			id, err := nomenclature.GlobalID()
+			id, err := d.articular.SupplierArticul(nomenclature.VendorCode)
 			if err != nil {
 				mu.Lock()
 				errs[id] = fmt.Sprintf("ID: %s -- Nomenclature upload failed: %s", nomenclature.VendorCode, err)
@@ -513,6 +550,13 @@ func (d *SearchEngine) UploadToDb(settings request2.Settings, locale string) (in
 	}
 
 	log.SetPrefix("")
+	<-fetched
+	if err := ctx.Err(); err != nil {
+		return updated, err
+	}
+	if fetchErr != nil {
+		return updated, fmt.Errorf("error fetching nomenclatures: %w", fetchErr)
+	}
 	return updated, nil
 }
 
@@ -538,7 +582,7 @@ func (d *SearchEngine) CheckTotalNmCount(settings request2.Settings, locale stri
 	}()
 
 	for nm := range nomenclatureChan {
-		if _, err := nm.GlobalID(); err != nil {
+		if _, err := d.articular.SupplierArticul(nm.VendorCode); err != nil {
 			msc++
 		}
 		count++
@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"gomarketplace_api/config"
+	corestorage "gomarketplace_api/internal/core/storage"
+	registry "gomarketplace_api/internal/registry/business"
+	pkg2 "gomarketplace_api/internal/suppliers/wholesaler/pkg"
 	requests2 "gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
 	"gomarketplace_api/internal/wildberries/business/models/dto/request"
 	"gomarketplace_api/internal/wildberries/business/services"
@@ -26,12 +29,20 @@ import (
 
 const uploadCardsUrl = "https://content-api.wildberries.ru/content/v2/cards/upload"
 
+// marketplaceName - площадка для выбора профиля цен PriceEngine.
+const marketplaceName = "wildberries"
+
 type CardService struct {
 	cardBuilder  builder.Proxy
 	textService  service.ITextService
 	brandService parse2.BrandService
 	nmService    NomenclatureService
 	wsclient     *clients2.WServiceClient
+	articular    *registry.ArticularService
+	// groups - группы дублей товаров: карточки создаются только для предпочтительных предложений
+	groups *corestorage.ProductGroupRepository
+	// preview - режим dry-run: карточки записываются в preview, а не отправляются в WB
+	preview *Preview
 
 	config.WildberriesConfig
 	logger.Logger
@@ -42,7 +53,8 @@ func NewCardService(
 	wsClientUrl string,
 	textService service.ITextService,
 	writer io.Writer,
-	wildberriesConfig config.WildberriesConfig) *CardService {
+	wildberriesConfig config.WildberriesConfig,
+	articular *registry.ArticularService) *CardService {
 	_log := logger.NewLogger(writer, "[CardService]")
 	cardBuilder := parse2.NewCardBuilderEngine(writer, wildberriesConfig.WbValues)
 	wsClient, err := clients2.NewWServiceClient(wsClientUrl, writer)
@@ -56,6 +68,7 @@ func NewCardService(
 		WildberriesConfig: wildberriesConfig,
 		brandService:      parse2.NewBrandServiceWildberries(wildberriesConfig.WbBanned.BannedBrands),
 		wsclient:          wsClient,
+		articular:         articular,
 		textService:       textService,
 		AuthEngine:        services.NewBearerAuth(wildberriesConfig.ApiKey),
 	}
@@ -109,6 +122,14 @@ func (s *CardService) Prepare(ctx context.Context, ids []int) (interface{}, erro
 
 	ids = s.extractKeys(barcodesMap)
 
+	qualityMap, err := s.filterQuality(preparationsContext, ids)
+	if err != nil {
+		return nil, err
+	}
+	s.logFilteredIDs(&filtered, ids, qualityMap, "Quality filtering")
+
+	ids = s.extractKeys(qualityMap)
+
 	filtered.Range(func(key, value any) bool {
 		preparationLogger.Log("Excluded ID: %v, Reason: %v", key, value)
 		return true
@@ -120,11 +141,26 @@ func (s *CardService) Prepare(ctx context.Context, ids []int) (interface{}, erro
 	return ids, nil
 }
 
+// SetProductGroups включает отсев дублей: товары, которые не являются предпочтительным предложением
+// своей группы в core.product_groups, не попадают в карточки.
+func (s *CardService) SetProductGroups(groups *corestorage.ProductGroupRepository) {
+	s.groups = groups
+}
+
 func (s *CardService) PrepareAndUpload(ctx context.Context, ids []int) (interface{}, error) {
 
 	preparationsContext, cancel := context.WithTimeout(ctx, time.Minute*3)
 	defer cancel()
 
+	if s.groups != nil {
+		preferred, err := s.groups.ExcludeNonPreferred(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		s.Log("Excluded %d duplicate products", len(ids)-len(preferred))
+		ids = preferred
+	}
+
 	preparingResult, err := s.Prepare(ctx, ids)
 	if err != nil {
 		return nil, err
@@ -155,12 +191,17 @@ func (s *CardService) PrepareAndUpload(ctx context.Context, ids []int) (interfac
 		return nil, err
 	}
 
+	vendorCodes, err := s.articular.VendorCodes(ids)
+	if err != nil {
+		return nil, err
+	}
+
 	var cards []request.CreateCardRequestData
 	for _, id := range ids {
 		card, err := s.cardBuilder.WithBrand(brands[id].(string)).
 			WithDescription(descriptions[id].(string)).
 			WithTitle(appellations[id].(string)).
-			WithVendorCode(fmt.Sprintf("id-%d-%d", id, s.WbIdentity.Code)).
+			WithVendorCode(vendorCodes[id]).
 			WithPrice(prices[id].(int) * 2).
 			Build()
 		if err != nil {
@@ -171,7 +212,18 @@ func (s *CardService) PrepareAndUpload(ctx context.Context, ids []int) (interfac
 	return cards, nil
 }
 
+// SetPreview включает режим dry-run: SendToServerModels записывает карточки в preview и не отправляет их в WB.
+func (s *CardService) SetPreview(preview *Preview) {
+	s.preview = preview
+}
+
 func (s *CardService) SendToServerModels(models interface{}) ([]byte, int, error) {
+	if s.preview != nil {
+		if _, err := s.preview.Write("", uploadCardsUrl, models); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		return nil, http.StatusOK, nil
+	}
 	return s.sendToServer(uploadCardsUrl, models)
 }
 
@@ -390,7 +442,7 @@ func (s *CardService) filterPrice(ctx context.Context, ids []int) (map[int]inter
 
 	pricesRaw, err := s.wsclient.FetcherChain.Fetch(filterContext, "prices", requests2.PriceRequest{FilterRequest: requests2.FilterRequest{
 		ProductIDs: ids,
-	}})
+	}, Marketplace: marketplaceName})
 	if err != nil {
 		return nil, err
 	}
@@ -520,6 +572,30 @@ func (s *CardService) logFilteredIDs(filtered *sync.Map, originalIDs []int, filt
 	}
 }
 
+// filterQuality исключает товары, не прошедшие проверки качества wholesaler (статус error).
+func (s *CardService) filterQuality(ctx context.Context, ids []int) (map[int]interface{}, error) {
+	filterContext, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	result := make(map[int]interface{}, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	failed, err := pkg2.FetchQualityErrors(filterContext, s.wsclient.FetcherChain, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if issues, ok := failed[id]; ok {
+			s.Log("Product %d failed quality checks: %v", id, issues)
+			continue
+		}
+		result[id] = struct{}{}
+	}
+	return result, nil
+}
+
 // extractKeys извлекает ключи из мапы
 func (s *CardService) extractKeys(data map[int]interface{}) []int {
 	keys := make([]int, 0, len(data))
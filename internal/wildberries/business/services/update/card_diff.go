@@ -0,0 +1,385 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	request2 "gomarketplace_api/internal/wildberries/business/models/dto/request"
+	response2 "gomarketplace_api/internal/wildberries/business/models/dto/response"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+	"gomarketplace_api/internal/wildberries/business/services/update/operations"
+	domainmodels "gomarketplace_api/internal/wildberries/business/services/update/operations/domain/models"
+	"gomarketplace_api/internal/wildberries/storage"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxChangeValueLength - сколько символов старого и нового значения попадает в описание изменения.
+const maxChangeValueLength = 200
+
+// pushedMediaLookback - сколько последних версий cards_history просматривается в поисках отправленных медиа.
+const pushedMediaLookback = 20
+
+// mediaPageSize - сколько карточек обрабатывается страницей, для которой история медиа читается одним запросом.
+const mediaPageSize = 100
+
+var fieldNames = map[string]string{
+	"title":           "Наименование",
+	"description":     "Описание",
+	"brand":           "Бренд",
+	"dimensions":      "Габариты упаковки",
+	"characteristics": "Характеристики",
+	"sizes":           "Размеры",
+	"media":           "Медиа",
+}
+
+// FieldChange - изменение одного поля карточки.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// String возвращает описание изменения для wildberries.changes, например: Бренд: «A» → «B».
+func (c FieldChange) String() string {
+	name, ok := fieldNames[c.Field]
+	if !ok {
+		name = c.Field
+	}
+	return fmt.Sprintf("%s: «%s» → «%s»", name, shorten(c.Old), shorten(c.New))
+}
+
+// CardDiff сравнивает текущую карточку WB с запросом на её обновление.
+// Поля, которых нет в запросе (например, фото в запросе наименования), не сравниваются.
+// Медиа всегда считаются изменёнными: WB хранит загруженные фото по своим адресам, сравнить их
+// с адресами поставщика нельзя. Сравнение с отправленными ранее медиа - CardDiffWithMedia.
+func CardDiff(nom response2.Nomenclature, model interface{}) []FieldChange {
+	return CardDiffWithMedia(nom, model, nil)
+}
+
+// CardDiffWithMedia сравнивает карточку с запросом как CardDiff, а медиа запроса - со списком адресов
+// pushedMedia, последним отправленным в WB по карточке: медиа не изменились, только если адреса
+// совпадают вместе с порядком. nil - медиа карточки ещё не отправлялись.
+func CardDiffWithMedia(nom response2.Nomenclature, model interface{}, pushedMedia []string) []FieldChange {
+	var diff []FieldChange
+	add := func(field, old, new string) {
+		if old != new {
+			diff = append(diff, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	addMedia := func(urls []string) {
+		if pushedMedia != nil && equalMedia(pushedMedia, urls) {
+			return
+		}
+		diff = append(diff, FieldChange{Field: "media", Old: formatMedia(nomenclatureMedia(nom)), New: formatMedia(urls)})
+	}
+
+	switch m := model.(type) {
+	case *models.WildberriesCard:
+		add("title", nom.Title, m.Title)
+		add("description", nom.Description, m.Description)
+		add("brand", nom.Brand, m.Brand)
+		add("dimensions", formatDimensions(*nom.Dimensions.Unwrap()), formatDimensions(m.Dimensions))
+		add("characteristics", formatCharacteristics(nom.Characteristics), formatCharacteristics(m.Characteristics))
+		add("sizes", formatSizes(nom.Sizes), formatSizes(m.Sizes))
+	case *request2.MediaRequest:
+		addMedia(m.Data)
+	case domainmodels.MediaModel:
+		addMedia(m.URLs)
+	case *domainmodels.MediaModel:
+		addMedia(m.URLs)
+	case domainmodels.BrandModel:
+		add("brand", nom.Brand, m.Brand)
+	case *domainmodels.BrandModel:
+		add("brand", nom.Brand, m.Brand)
+	case domainmodels.AppellationModel:
+		return CardDiffWithMedia(nom, &m, pushedMedia)
+	case *domainmodels.AppellationModel:
+		add("title", nom.Title, m.Title)
+		if m.Description != "" {
+			add("description", nom.Description, m.Description)
+		}
+	case operations.SequentialModel:
+		return CardDiffWithMedia(nom, &m, pushedMedia)
+	case *operations.SequentialModel:
+		for _, sub := range m.Models {
+			diff = append(diff, CardDiffWithMedia(nom, sub, pushedMedia)...)
+		}
+	case domainmodels.CompositeModel:
+		return CardDiffWithMedia(nom, &m, pushedMedia)
+	case *domainmodels.CompositeModel:
+		if len(m.Media) > 0 {
+			addMedia(m.Media)
+		}
+		if m.Brand != "" {
+			add("brand", nom.Brand, m.Brand)
+		}
+		if m.Title != "" {
+			add("title", nom.Title, m.Title)
+		}
+		if m.Description != "" {
+			add("description", nom.Description, m.Description)
+		}
+	}
+	return diff
+}
+
+func nomenclatureMedia(nom response2.Nomenclature) []string {
+	urls := make([]string, len(nom.Photos))
+	for i, photo := range nom.Photos {
+		urls[i] = photo.Big
+	}
+	return urls
+}
+
+func equalMedia(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// modelMedia возвращает адреса медиа из запроса; false - запрос не меняет медиа.
+func modelMedia(model interface{}) ([]string, bool) {
+	switch m := model.(type) {
+	case *request2.MediaRequest:
+		return m.Data, true
+	case domainmodels.MediaModel:
+		return m.URLs, true
+	case *domainmodels.MediaModel:
+		return m.URLs, true
+	case domainmodels.CompositeModel:
+		return modelMedia(&m)
+	case *domainmodels.CompositeModel:
+		return m.Media, len(m.Media) > 0
+	case operations.SequentialModel:
+		return modelMedia(&m)
+	case *operations.SequentialModel:
+		for _, sub := range m.Models {
+			if urls, ok := modelMedia(sub); ok {
+				return urls, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// payloadMedia возвращает адреса медиа из сохранённого в cards_history запроса: поле data запроса медиа,
+// media составного запроса или их же в запросах последовательности (Models).
+func payloadMedia(payload json.RawMessage) ([]string, bool) {
+	var stored struct {
+		Data   []string          `json:"data"`
+		Media  []string          `json:"media"`
+		Models []json.RawMessage `json:"Models"`
+	}
+	if err := json.Unmarshal(payload, &stored); err != nil {
+		return nil, false
+	}
+	switch {
+	case stored.Data != nil:
+		return stored.Data, true
+	case len(stored.Media) > 0:
+		return stored.Media, true
+	}
+	for _, sub := range stored.Models {
+		if urls, ok := payloadMedia(sub); ok {
+			return urls, true
+		}
+	}
+	return nil, false
+}
+
+func formatDimensions(d response2.DimensionWrapper) string {
+	return fmt.Sprintf("%dx%dx%d", d.Length, d.Width, d.Height)
+}
+
+func formatMedia(urls []string) string {
+	return fmt.Sprintf("%d: %s", len(urls), strings.Join(urls, " "))
+}
+
+// formatCharacteristics выводит характеристики в порядке ID: "Цвет=["красный"]; Вес=0.5".
+func formatCharacteristics(charcs []response2.Charc) string {
+	sorted := append([]response2.Charc(nil), charcs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Id < sorted[j].Id })
+
+	parts := make([]string, len(sorted))
+	for i, charc := range sorted {
+		value, err := json.Marshal(charc.Value)
+		if err != nil {
+			value = []byte(fmt.Sprint(charc.Value))
+		}
+		name := charc.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", charc.Id)
+		}
+		parts[i] = fmt.Sprintf("%s=%s", name, value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatSizes выводит размеры с баркодами: "42 (48): 2000000000012; 44 (50): 2000000000029".
+func formatSizes(sizes []response2.Size) string {
+	parts := make([]string, len(sizes))
+	for i, size := range sizes {
+		parts[i] = fmt.Sprintf("%s (%s): %s", size.TechSize, size.WbSize, strings.Join(size.Skus, ","))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+func shorten(value string) string {
+	if utf8.RuneCountInString(value) <= maxChangeValueLength {
+		return value
+	}
+	return string([]rune(value)[:maxChangeValueLength]) + "…"
+}
+
+type pendingChange struct {
+	vendorCode string
+	notes      []string
+	diff       []FieldChange
+}
+
+// pendingKey - карточка запуска: одну карточку могут обновлять одновременные запуски.
+type pendingKey struct {
+	runID string
+	nmID  int
+}
+
+// trackedModel - запрос на обновление карточки вместе с её текущим состоянием.
+type trackedModel struct {
+	nom   response2.Nomenclature
+	model request2.Model
+}
+
+// CardChanges отсекает обновления, которые ничего не меняют в карточке, и сохраняет отправленные
+// в WB обновления: запрос - версией в wildberries.cards_history, изменения - в wildberries.changes.
+// Без репозитория ничего не сохраняется. Изменения помечаются запуском, который передаётся в Track:
+// одновременные запуски не путают изменения друг друга.
+type CardChanges struct {
+	repo    *storage.ChangeRepository
+	pending sync.Map // pendingKey -> pendingChange
+}
+
+func NewCardChanges(repo *storage.ChangeRepository) *CardChanges {
+	return &CardChanges{repo: repo}
+}
+
+// Track сравнивает запрос запуска runID с текущей карточкой. false - запрос ничего не меняет и отправлять его не нужно.
+// Медиа запроса сравниваются с pushedMedia - последними отправленными по карточке (PushedMedia), nil - не сравниваются.
+// notes добавляются к описаниям изменений, например "Откат к версии 3".
+func (c *CardChanges) Track(runID string, nom response2.Nomenclature, model interface{}, pushedMedia []string, notes ...string) bool {
+	diff := CardDiffWithMedia(nom, model, pushedMedia)
+	if len(diff) == 0 {
+		return false
+	}
+	c.pending.Store(pendingKey{runID: runID, nmID: nom.NmID}, pendingChange{vendorCode: nom.VendorCode, notes: notes, diff: diff})
+	return true
+}
+
+// PushedMedia возвращает адреса медиа, последними отправленные в WB по карточкам запросов requests, которые меняют медиа.
+// История всех карточек читается одним запросом. Карточек без отправленных медиа в ответе нет; если историю
+// прочитать не удалось, ответ пустой: тогда медиа отправляются.
+func (c *CardChanges) PushedMedia(ctx context.Context, requests []trackedModel) map[int][]string {
+	if c.repo == nil {
+		return nil
+	}
+	var nmIDs []int
+	for _, tracked := range requests {
+		if _, ok := modelMedia(tracked.model); ok {
+			nmIDs = append(nmIDs, tracked.nom.NmID)
+		}
+	}
+	if len(nmIDs) == 0 {
+		return nil
+	}
+	payloads, err := c.repo.GetRecentPayloadsByCards(ctx, nmIDs, pushedMediaLookback)
+	if err != nil {
+		log.Printf("Media history of %d cards not loaded: %v", len(nmIDs), err)
+		return nil
+	}
+	pushed := make(map[int][]string, len(payloads))
+	for nmID, cardPayloads := range payloads {
+		for _, payload := range cardPayloads {
+			if urls, ok := payloadMedia(payload); ok {
+				pushed[nmID] = urls
+				break
+			}
+		}
+	}
+	return pushed
+}
+
+// readPage читает из ch до size карточек, пока канал не закрыт или не закрыт done - получение карточек
+// завершилось. false - карточек больше нет.
+func readPage(ch <-chan response2.Nomenclature, done <-chan struct{}, size int) ([]response2.Nomenclature, bool) {
+	page := make([]response2.Nomenclature, 0, size)
+	for len(page) < size {
+		select {
+		case nom, ok := <-ch:
+			if !ok {
+				return page, len(page) > 0
+			}
+			page = append(page, nom)
+		case <-done:
+			return page, len(page) > 0
+		}
+	}
+	return page, true
+}
+
+// Discard удаляет неотправленные изменения запуска runID: вызывается, когда запуск завершён.
+func (c *CardChanges) Discard(runID string) {
+	if c == nil {
+		return
+	}
+	c.pending.Range(func(key, _ interface{}) bool {
+		if key.(pendingKey).runID == runID {
+			c.pending.Delete(key)
+		}
+		return true
+	})
+}
+
+// Commit сохраняет обновления карточек из отправленного в WB запроса data (модель или срез моделей) запуска runID.
+func (c *CardChanges) Commit(ctx context.Context, runID string, data interface{}) error {
+	var changes []models.CardChange
+	for _, item := range previewItems(data) {
+		nmID, _ := modelIdentity(item)
+		value, ok := c.pending.LoadAndDelete(pendingKey{runID: runID, nmID: nmID})
+		if !ok {
+			continue
+		}
+		payload, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal card %d: %w", nmID, err)
+		}
+		pending := value.(pendingChange)
+		change := models.CardChange{
+			NmID:        nmID,
+			VendorCode:  pending.vendorCode,
+			RunID:       runID,
+			Payload:     payload,
+			Description: append([]string(nil), pending.notes...),
+		}
+		for _, field := range pending.diff {
+			change.Description = append(change.Description, field.String())
+		}
+		changes = append(changes, change)
+	}
+	if c.repo == nil || len(changes) == 0 {
+		return nil
+	}
+	if err := c.repo.SaveChanges(ctx, changes); err != nil {
+		return fmt.Errorf("failed to save card changes: %w", err)
+	}
+	return nil
+}
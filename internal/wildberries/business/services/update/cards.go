@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"golang.org/x/time/rate"
 	"gomarketplace_api/config/values"
+	registry "gomarketplace_api/internal/registry/business"
 	"gomarketplace_api/internal/suppliers/wholesaler/pkg/clients"
 	requests2 "gomarketplace_api/internal/suppliers/wholesaler/pkg/requests"
 	request2 "gomarketplace_api/internal/wildberries/business/models/dto/request"
@@ -17,6 +18,7 @@ import (
 	"gomarketplace_api/internal/wildberries/business/services/get"
 	"gomarketplace_api/internal/wildberries/business/services/parse"
 	clients2 "gomarketplace_api/internal/wildberries/pkg/clients"
+	"gomarketplace_api/internal/wildberries/storage"
 	"gomarketplace_api/metrics"
 	"gomarketplace_api/pkg/business/service"
 	"io"
@@ -27,7 +29,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
@@ -51,11 +52,23 @@ type CardUpdateService struct {
 	nomenclatureService get.SearchEngine
 	wsclient            *clients2.WServiceClient
 	textService         service.ITextService
-	cardBuilder         *builder.CardBuilder
 	brandService        parse.BrandService
 	defaultValues       values.WildberriesValues
-	metrics             *metrics.UpdateMetrics
+	// articular - артикулы поставщика карточек по vendor code
+	articular *registry.ArticularService
+	// preview - режим dry-run: запросы записываются в preview, а не отправляются в WB
+	preview *Preview
+	// changes - отсечение обновлений без изменений и журнал wildberries.changes
+	changes *CardChanges
+	// history - версии карточек для отката
+	history *storage.NomenclatureHistoryRepository
+	// runs - запуски обновления и исходы карточек; nil - не сохранять
+	runs *storage.UpdateRunRepository
 	services.AuthEngine
+
+	mu sync.Mutex
+	// lastRun - последний начатый запуск, для LastRunID и Metrics
+	lastRun *UpdateRun
 }
 
 type batchProcessor struct {
@@ -87,7 +100,7 @@ func (bp *batchProcessor) flush() {
 	}
 }
 
-func NewCardUpdateService(searchNms *get.SearchEngine, textService service.ITextService, wsClientUrl string, auth services.AuthEngine, writer io.Writer, brandService parse.BrandService, wbDefaultValues values.WildberriesValues) *CardUpdateService {
+func NewCardUpdateService(searchNms *get.SearchEngine, textService service.ITextService, wsClientUrl string, auth services.AuthEngine, writer io.Writer, brandService parse.BrandService, wbDefaultValues values.WildberriesValues, articular *registry.ArticularService) *CardUpdateService {
 
 	client, err := clients2.NewWServiceClient(wsClientUrl, writer)
 	if err != nil {
@@ -100,20 +113,67 @@ func NewCardUpdateService(searchNms *get.SearchEngine, textService service.IText
 		textService:         textService,
 		brandService:        brandService,
 		AuthEngine:          auth,
-		cardBuilder:         builder.NewCardBuilder(textService),
 		defaultValues:       wbDefaultValues,
+		articular:           articular,
+		changes:             NewCardChanges(nil),
 	}
 }
 
 const updateCardsUrl = "https://content-api.wildberries.ru/content/v2/cards/update"
 
-var numberOfErroredNomenclatures atomic.Int32
-var updatedCount atomic.Int32
+// SetPreview включает режим dry-run: запросы обновления записываются в preview и не отправляются в WB.
+// nil выключает режим.
+func (cu *CardUpdateService) SetPreview(preview *Preview) {
+	cu.preview = preview
+}
+
+// SetChangeRepository включает запись изменений отправленных карточек в wildberries.changes.
+func (cu *CardUpdateService) SetChangeRepository(repo *storage.ChangeRepository) {
+	cu.changes = NewCardChanges(repo)
+}
+
+// SetRunRepository включает сохранение запусков обновления и исходов карточек в wildberries.update_runs.
+func (cu *CardUpdateService) SetRunRepository(repo *storage.UpdateRunRepository) {
+	cu.runs = repo
+}
+
+// startRun начинает запуск обновления operation с выборкой settings. Запуск передаётся в обработку
+// параметром, поэтому операции сервиса могут выполняться одновременно.
+func (cu *CardUpdateService) startRun(operation string, settings interface{}) *UpdateRun {
+	run := startUpdateRun(cu.runs, cu.preview, cu.changes, operation, settings)
+	cu.mu.Lock()
+	defer cu.mu.Unlock()
+	cu.lastRun = run
+	return run
+}
+
+// LastRunID возвращает ID последнего начатого запуска обновления: по нему запуск можно откатить (RollbackRun).
+func (cu *CardUpdateService) LastRunID() string {
+	cu.mu.Lock()
+	defer cu.mu.Unlock()
+	if cu.lastRun == nil {
+		return ""
+	}
+	return cu.lastRun.ID
+}
+
+// Metrics возвращает счётчики последнего начатого запуска обновления.
+func (cu *CardUpdateService) Metrics() *metrics.UpdateMetrics {
+	cu.mu.Lock()
+	defer cu.mu.Unlock()
+	if cu.lastRun == nil {
+		return &metrics.UpdateMetrics{}
+	}
+	return cu.lastRun.Metrics()
+}
 
 func (cu *CardUpdateService) UpdateCardNaming(ctx context.Context, settings request2.Settings) (int, error) {
+	run := cu.startRun("naming", settings)
+
 	// Подготовка данных
 	appellationsMap, descriptionsMap, err := cu.fetchRequiredData(ctx)
 	if err != nil {
+		run.Finish(err)
 		return 0, err
 	}
 
@@ -133,13 +193,14 @@ func (cu *CardUpdateService) UpdateCardNaming(ctx context.Context, settings requ
 	processedItems := &sync.Map{}
 
 	// Запуск получения номенклатур
-	go cu.fetchNomenclatures(ctx, settings, nomenclatureCh)
+	go cu.fetchNomenclatures(ctx, run, settings, nomenclatureCh)
 
 	// Запуск обработчиков
 	for i := 0; i < goroutineCount; i++ {
 		processWg.Add(1)
 		go cu.processNomenclatures(
 			ctx,
+			run,
 			i,
 			nomenclatureCh,
 			batchProc,
@@ -152,20 +213,24 @@ func (cu *CardUpdateService) UpdateCardNaming(ctx context.Context, settings requ
 
 	// Запуск загрузчика
 	uploadWg.Add(1)
-	go cu.uploadWorker(ctx, uploadCh, uploadLimiter, &uploadWg)
+	go cu.uploadWorker(ctx, run, uploadCh, uploadLimiter, &uploadWg)
 
 	// Ожидание завершения и обработка оставшихся данных
 	processWg.Wait()
+	batchProc.flush()
 	close(uploadCh)
 	uploadWg.Wait()
 
-	cu.logResults()
-	return int(cu.metrics.UpdatedCount.Load()), nil
+	cu.logResults(run)
+	err = ctx.Err()
+	run.Finish(err)
+	return int(run.Metrics().UpdatedCount.Load()), err
 }
 
 // считываем канал номенклатур, валидируем и отдаем
 func (cu *CardUpdateService) processNomenclatures(
 	ctx context.Context,
+	run *UpdateRun,
 	workerID int,
 	nomenclatureCh <-chan response2.Nomenclature,
 	batchProc *batchProcessor,
@@ -176,14 +241,15 @@ func (cu *CardUpdateService) processNomenclatures(
 	defer wg.Done()
 
 	for nomenclature := range nomenclatureCh {
-		cu.metrics.GoroutinesNmsCount.Add(1)
+		run.Metrics().GoroutinesNmsCount.Add(1)
 
+		cu.preview.Remember(run.ID, nomenclature)
 		processor := &CardProcessor{nomenclature: nomenclature}
-		if !cu.validateAndPrepareProcessor(processor, processedItems, appellationsMap, descriptionsMap) {
+		if !cu.validateAndPrepareProcessor(run, processor, processedItems, appellationsMap, descriptionsMap) {
 			continue
 		}
 
-		card := cu.cardBuilder.
+		card := builder.NewCardBuilder(cu.textService).
 			FromNomenclature(nomenclature).
 			WithUpdatedTitle(processor.appellation, maxTitleLength)
 
@@ -193,11 +259,20 @@ func (cu *CardUpdateService) processNomenclatures(
 			card.WithFallbackDescription(processor.appellation, maxDescLength)
 		}
 
-		batchProc.add(card.Build())
+		wbCard := card.Build()
+		if !cu.changes.Track(run.ID, nomenclature, wbCard, nil) {
+			run.Metrics().UnchangedCount.Add(1)
+			run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeUnchanged, "")
+			continue
+		}
+		run.Metrics().ProcessedCount.Add(1)
+		run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeProcessed, "")
+		batchProc.add(wbCard)
 	}
 }
 
 func (cu *CardUpdateService) validateAndPrepareProcessor(
+	run *UpdateRun,
 	processor *CardProcessor,
 	processedItems *sync.Map,
 	appellationsMap, descriptionsMap map[int]interface{},
@@ -208,16 +283,18 @@ func (cu *CardUpdateService) validateAndPrepareProcessor(
 	}
 
 	// Получение и валидация globalID
-	globalID, err := processor.nomenclature.GlobalID()
+	globalID, err := cu.articular.SupplierArticul(processor.nomenclature.VendorCode)
 	if err != nil || globalID == 0 {
-		cu.metrics.ErroredNomenclatures.Add(1)
+		run.Metrics().ErroredNomenclatures.Add(1)
+		run.Record(processor.nomenclature.NmID, processor.nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
 		return false
 	}
 
 	// Проверка наличия appellation
 	appellation, ok := appellationsMap[globalID]
 	if !ok {
-		cu.metrics.ErroredNomenclatures.Add(1)
+		run.Metrics().ErroredNomenclatures.Add(1)
+		run.Record(processor.nomenclature.NmID, processor.nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
 		return false
 	}
 
@@ -262,6 +339,7 @@ func (cu *CardUpdateService) fetchRequiredData(ctx context.Context) (map[int]int
 
 func (cu *CardUpdateService) fetchNomenclatures(
 	ctx context.Context,
+	run *UpdateRun,
 	settings request2.Settings,
 	nomenclatureCh chan response2.Nomenclature) {
 	defer close(nomenclatureCh)
@@ -273,12 +351,13 @@ func (cu *CardUpdateService) fetchNomenclatures(
 		nomenclatureCh)
 	if err != nil {
 		log.Printf("Error fetching nomenclatures concurrently: %s", err)
-		return
+		run.Fail(fmt.Errorf("error fetching nomenclatures: %w", err))
 	}
 }
 
 func (cu *CardUpdateService) uploadWorker(
 	ctx context.Context,
+	run *UpdateRun,
 	uploadCh <-chan []request2.Model,
 	uploadLimiter *rate.Limiter,
 	wg *sync.WaitGroup,
@@ -286,37 +365,107 @@ func (cu *CardUpdateService) uploadWorker(
 	defer wg.Done()
 
 	for batch := range uploadCh {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			log.Println("Uploading batch of cards...")
+		// после отмены ctx пачки не отправляются, но канал дочитывается, чтобы не блокировать обработчики
+		if err := uploadLimiter.Wait(ctx); err != nil {
+			continue
+		}
 
-			if err := uploadLimiter.Wait(ctx); err != nil {
-				log.Printf("Error waiting for rate limiter: %s", err)
-				return
-			}
+		log.Println("Uploading batch of cards...")
+		cards, err := cu.processAndUpload(run, updateCardsUrl, batch)
+		if err != nil {
+			log.Printf("Error during uploading: %s", err)
+			continue // Продолжаем с следующим батчем вместо полной остановки
+		}
 
-			cards, err := cu.processAndUpload(updateCardsUrl, batch)
-			if err != nil {
-				log.Printf("Error during uploading: %s", err)
-				continue // Продолжаем с следующим батчем вместо полной остановки
+		run.Metrics().UpdatedCount.Add(int32(cards))
+	}
+}
+
+func (cu *CardUpdateService) logResults(run *UpdateRun) {
+	log.Printf("Goroutines fetchers got (%d) nomenclatures",
+		run.Metrics().GoroutinesNmsCount.Load())
+	log.Printf("Update completed, total updated count: %d. Unfetched count: %d. Unchanged count: %d",
+		run.Metrics().UpdatedCount.Load(),
+		run.Metrics().ErroredNomenclatures.Load(),
+		run.Metrics().UnchangedCount.Load())
+}
+
+// ContentChange - новые наименование, описание и бренд карточки WB; пустое поле не меняется.
+type ContentChange struct {
+	Nomenclature response2.Nomenclature
+	Title        string
+	Description  string
+	Brand        string
+}
+
+// UpdateCardContent отправляет изменения контента карточек в /content/v2/cards/update запуском "content".
+// Карточки без изменений не отправляются, в режиме dry-run запросы записываются в preview.
+func (cu *CardUpdateService) UpdateCardContent(ctx context.Context, changes []ContentChange) (int, error) {
+	run := cu.startRun("content", nil)
+	uploadLimiter := rate.NewLimiter(rate.Every(time.Minute/uploadRateLimit), uploadRateLimit)
+
+	var batch []request2.Model
+	var uploadErr error
+	upload := func() {
+		defer func() { batch = nil }()
+		if len(batch) == 0 || ctx.Err() != nil {
+			return
+		}
+		if err := uploadLimiter.Wait(ctx); err != nil {
+			return
+		}
+		cards, err := cu.processAndUpload(run, updateCardsUrl, batch)
+		if err != nil {
+			log.Printf("Error during uploading: %s", err)
+			if uploadErr == nil {
+				uploadErr = err
 			}
+			return
+		}
+		run.Metrics().UpdatedCount.Add(int32(cards))
+	}
+
+	for _, change := range changes {
+		nomenclature := change.Nomenclature
+		run.Metrics().GoroutinesNmsCount.Add(1)
+		cu.preview.Remember(run.ID, nomenclature)
 
-			cu.metrics.UpdatedCount.Add(int32(cards))
+		card := builder.NewCardBuilder(cu.textService).FromNomenclature(nomenclature)
+		if change.Description != "" {
+			card.WithDescription(change.Description, maxDescLength)
+		}
+		wbCard := card.Build()
+		if change.Title != "" {
+			wbCard.Title = cu.textService.ClearAndReduce(change.Title, maxTitleLength)
+		}
+		if change.Brand != "" {
+			wbCard.Brand = change.Brand
+		}
+
+		if !cu.changes.Track(run.ID, nomenclature, wbCard, nil) {
+			run.Metrics().UnchangedCount.Add(1)
+			run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeUnchanged, "")
+			continue
+		}
+		run.Metrics().ProcessedCount.Add(1)
+		run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeProcessed, "")
+		batch = append(batch, wbCard)
+		if len(batch) >= uploadBatchSize {
+			upload()
 		}
 	}
-}
+	upload()
 
-func (cu *CardUpdateService) logResults() {
-	log.Printf("Goroutines fetchers got (%d) nomenclatures",
-		cu.metrics.GoroutinesNmsCount.Load())
-	log.Printf("Update completed, total updated count: %d. Unfetched count: %d",
-		cu.metrics.UpdatedCount.Load(),
-		cu.metrics.ErroredNomenclatures.Load())
+	cu.logResults(run)
+	err := ctx.Err()
+	if err == nil {
+		err = uploadErr
+	}
+	run.Finish(err)
+	return int(run.Metrics().UpdatedCount.Load()), err
 }
 
-func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int, error) {
+func (cu *CardUpdateService) UpdateCardPackages(ctx context.Context, settings request2.Settings) (int, error) {
 	const UPLOAD_SIZE = 2000
 	const MaxBatchSize = 1 << 20 // 1 MB
 	const GOROUTINE_COUNT = 5
@@ -324,7 +473,6 @@ func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int
 	var currentBatch []request2.Model
 	var currentBatchSize int
 	var gotData []response2.Nomenclature
-	var goroutinesNmsCount atomic.Int32
 
 	var processWg sync.WaitGroup
 	var uploadWg sync.WaitGroup
@@ -334,13 +482,15 @@ func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int
 	uploadChan := make(chan []request2.Model) // Канал для отправки данных
 
 	uploadToServerLimiter := rate.NewLimiter(rate.Every(time.Minute/UPLOAD_RATE_LIMIT), UPLOAD_RATE_LIMIT)
+	run := cu.startRun("packages", settings)
 
 	log.Println("Fetching and sending nomenclatures to the channel...")
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	go func() {
 		if err := cu.nomenclatureService.GetNomenclaturesWithLimitConcurrentlyPutIntoChannel(ctx, settings, "", nomenclatureChan); err != nil {
 			log.Printf("Error fetching nomenclatures concurrently: %s", err)
+			run.Fail(fmt.Errorf("error fetching nomenclatures: %w", err))
 		}
 	}()
 
@@ -351,7 +501,8 @@ func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int
 		go func(i int) {
 			defer processWg.Done()
 			for nomenclature := range nomenclatureChan {
-				goroutinesNmsCount.Add(1)
+				run.Metrics().GoroutinesNmsCount.Add(1)
+				cu.preview.Remember(run.ID, nomenclature)
 
 				var wbCard models.WildberriesCard
 				wbCard = *wbCard.FromNomenclature(nomenclature)
@@ -360,10 +511,11 @@ func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int
 					continue // Если запись уже была обработана, пропускаем её
 				}
 
-				globalId, err := nomenclature.GlobalID()
+				globalId, err := cu.articular.SupplierArticul(nomenclature.VendorCode)
 				if err != nil || globalId == 0 {
 					log.Printf("(G%d) (globalID=%s) parse error (not SPB aricular)", i, nomenclature.VendorCode)
-					numberOfErroredNomenclatures.Add(1)
+					run.Metrics().ErroredNomenclatures.Add(1)
+					run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
 					continue
 				}
 
@@ -372,6 +524,13 @@ func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int
 					Width:  cu.defaultValues.PackageWidth,
 					Height: cu.defaultValues.PackageHeight,
 				}
+				if !cu.changes.Track(run.ID, nomenclature, &wbCard, nil) {
+					run.Metrics().UnchangedCount.Add(1)
+					run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeUnchanged, "")
+					continue
+				}
+				run.Metrics().ProcessedCount.Add(1)
+				run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeProcessed, "")
 
 				mu.Lock()
 				gotData = append(gotData, nomenclature)
@@ -394,18 +553,17 @@ func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int
 		defer uploadWg.Done()
 		for batch := range uploadChan {
 			log.Println("Uploading batch of cards...")
-			// Лимитирование запросов на загрузку
-			if err := uploadToServerLimiter.Wait(context.Background()); err != nil {
-				log.Printf("Error waiting for rate limiter: %s", err)
-				return
+			// Лимитирование запросов на загрузку; после отмены ctx канал только дочитывается
+			if err := uploadToServerLimiter.Wait(ctx); err != nil {
+				continue
 			}
 
-			cards, err := cu.processAndUpload(updateCardsUrl, batch)
+			cards, err := cu.processAndUpload(run, updateCardsUrl, batch)
 			if err != nil {
 				log.Printf("Error during uploading %s", err)
-				return
+				continue
 			}
-			updatedCount.Add(int32(cards))
+			run.Metrics().UpdatedCount.Add(int32(cards))
 		}
 	}()
 
@@ -421,9 +579,11 @@ func (cu *CardUpdateService) UpdateCardPackages(settings request2.Settings) (int
 	close(uploadChan)
 	uploadWg.Wait()
 
-	log.Printf("Goroutines fetchers got (%d) nomenclautres", goroutinesNmsCount.Load())
-	log.Printf("Update completed, total updated count: %d. Unfetched count : %d", updatedCount.Load(), numberOfErroredNomenclatures.Load())
-	return int(updatedCount.Load()), nil
+	log.Printf("Goroutines fetchers got (%d) nomenclautres", run.Metrics().GoroutinesNmsCount.Load())
+	log.Printf("Update completed, total updated count: %d. Unfetched count : %d", run.Metrics().UpdatedCount.Load(), run.Metrics().ErroredNomenclatures.Load())
+	err := ctx.Err()
+	run.Finish(err)
+	return int(run.Metrics().UpdatedCount.Load()), err
 }
 
 const updateCardsMediaUrl = "https://content-api.wildberries.ru/content/v3/media/save"
@@ -433,15 +593,13 @@ func (cu *CardUpdateService) UpdateCardMedia(ctx context.Context, settings reque
 	const REQUEST_RATE_LIMIT = 60 // 100 запросов в минуту = max
 	const UPLOAD_RATE_LIMIT = 60
 
-	var updatedCount = 0
-	var goroutinesNmsCount atomic.Int32
-
 	var processWg sync.WaitGroup
 	var uploadWg sync.WaitGroup
 	var mu sync.Mutex
 	var processedItems sync.Map
 
 	log.Println("Fetching media urls...")
+	run := cu.startRun("media", settings)
 
 	mediaRequest := clients.ImageRequest{
 		Censored: false,
@@ -453,12 +611,16 @@ func (cu *CardUpdateService) UpdateCardMedia(ctx context.Context, settings reque
 
 	mediaMapRaw, err := cu.wsclient.FetcherChain.Fetch(mediaMapContext, "media", mediaRequest)
 	if err != nil {
-		return 0, fmt.Errorf("error fetching media urls: %w", err)
+		err = fmt.Errorf("error fetching media urls: %w", err)
+		run.Finish(err)
+		return 0, err
 	}
 
 	mediaMap, ok := mediaMapRaw.(map[int][]string)
 	if !ok {
-		return 0, fmt.Errorf("unexpected type for mediaMap: expected map[int][]string, got %T", mediaMapRaw)
+		err = fmt.Errorf("unexpected type for mediaMap: expected map[int][]string, got %T", mediaMapRaw)
+		run.Finish(err)
+		return 0, err
 	}
 
 	nomenclatureChan := make(chan response2.Nomenclature)
@@ -466,12 +628,15 @@ func (cu *CardUpdateService) UpdateCardMedia(ctx context.Context, settings reque
 
 	responseLimiter := rate.NewLimiter(rate.Every(time.Minute/REQUEST_RATE_LIMIT), REQUEST_RATE_LIMIT)
 	uploadToServerLimiter := rate.NewLimiter(rate.Every(time.Minute/UPLOAD_RATE_LIMIT), UPLOAD_RATE_LIMIT)
-	ctx, cancel = context.WithCancel(context.Background())
+	ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
 	log.Println("Fetching and sending nomenclatures to the channel...")
+	fetched := make(chan struct{})
 	go func() {
+		defer close(fetched)
 		if err := cu.nomenclatureService.GetNomenclaturesWithLimitConcurrentlyPutIntoChannel(ctx, settings, "", nomenclatureChan); err != nil {
 			log.Printf("Error fetching nomenclatures concurrently: %s", err)
+			run.Fail(fmt.Errorf("error fetching nomenclatures: %w", err))
 		}
 	}()
 
@@ -481,54 +646,69 @@ func (cu *CardUpdateService) UpdateCardMedia(ctx context.Context, settings reque
 		processWg.Add(1)
 		go func(i int) {
 			defer processWg.Done()
-			for nomenclature := range nomenclatureChan {
-				goroutinesNmsCount.Add(1)
-
-				_, loaded := processedItems.LoadOrStore(nomenclature.VendorCode, true)
-				if loaded {
-					continue // Если запись уже была обработана, пропускаем её
+			for {
+				// карточки читаются страницами: отправленные ранее медиа страницы загружаются одним запросом
+				page, ok := readPage(nomenclatureChan, fetched, mediaPageSize)
+				if !ok {
+					return
 				}
 
-				globalId, err := nomenclature.GlobalID()
-				if err != nil || globalId == 0 {
-					log.Printf("(G%d) (globalID=%s) parse error (not SPB aricular)", i, nomenclature.VendorCode)
-					numberOfErroredNomenclatures.Add(1)
-					continue
-				}
+				var requests []trackedModel
+				for _, nomenclature := range page {
+					run.Metrics().GoroutinesNmsCount.Add(1)
+					cu.preview.Remember(run.ID, nomenclature)
 
-				if len(nomenclature.Photos) != 1 {
-					continue
-				}
+					_, loaded := processedItems.LoadOrStore(nomenclature.VendorCode, true)
+					if loaded {
+						continue // Если запись уже была обработана, пропускаем её
+					}
 
-				var urls []string
-				var ok bool
-				if urls, ok = mediaMap[globalId]; !ok && len(urls) <= 0 {
-					log.Printf("(G%d) (globalID=%s) not found urls !", i, nomenclature.VendorCode)
-					numberOfErroredNomenclatures.Add(1)
-					continue
-				}
+					globalId, err := cu.articular.SupplierArticul(nomenclature.VendorCode)
+					if err != nil || globalId == 0 {
+						log.Printf("(G%d) (globalID=%s) parse error (not SPB aricular)", i, nomenclature.VendorCode)
+						run.Metrics().ErroredNomenclatures.Add(1)
+						run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
+						continue
+					}
 
-				// +1 photo (copy) if only 1 exits (to improve card quality)
-				if len(urls) == 1 {
-					urls = append(urls, urls[0])
-				}
+					if len(nomenclature.Photos) != 1 {
+						continue
+					}
 
-				if len(urls) == 1 {
-					urls = append(urls, urls[0])
-				}
-				mediaRequest := request2.NewMediaRequest(nomenclature.NmID, urls)
+					var urls []string
+					var ok bool
+					if urls, ok = mediaMap[globalId]; !ok && len(urls) <= 0 {
+						log.Printf("(G%d) (globalID=%s) not found urls !", i, nomenclature.VendorCode)
+						run.Metrics().ErroredNomenclatures.Add(1)
+						run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
+						continue
+					}
 
-				if err := responseLimiter.Wait(context.Background()); err != nil {
-					log.Printf("Rate limiter error: %s", err)
-					return
+					// +1 photo (copy) if only 1 exits (to improve card quality)
+					if len(urls) == 1 {
+						urls = append(urls, urls[0])
+					}
+					requests = append(requests, trackedModel{nom: nomenclature, model: request2.NewMediaRequest(nomenclature.NmID, urls)})
 				}
-				if err != nil {
-					log.Printf("Error fetching nomenclature %d: %s", i, err)
-					return
+
+				pushed := cu.changes.PushedMedia(ctx, requests)
+				for _, req := range requests {
+					if !cu.changes.Track(run.ID, req.nom, req.model, pushed[req.nom.NmID]) {
+						run.Metrics().UnchangedCount.Add(1)
+						run.Record(req.nom.NmID, req.nom.VendorCode, models.OutcomeUnchanged, "")
+						continue
+					}
+					run.Metrics().ProcessedCount.Add(1)
+					run.Record(req.nom.NmID, req.nom.VendorCode, models.OutcomeProcessed, "")
+
+					if err := responseLimiter.Wait(ctx); err != nil {
+						log.Printf("Rate limiter error: %s", err)
+						return
+					}
+					mu.Lock()
+					uploadChan <- req.model
+					mu.Unlock()
 				}
-				mu.Lock()
-				uploadChan <- mediaRequest
-				mu.Unlock()
 			}
 		}(i)
 	}
@@ -539,18 +719,17 @@ func (cu *CardUpdateService) UpdateCardMedia(ctx context.Context, settings reque
 		defer uploadWg.Done()
 		for batch := range uploadChan {
 			log.Println("Uploading batch of media...")
-			// Лимитирование запросов на загрузку
-			if err := uploadToServerLimiter.Wait(context.Background()); err != nil {
-				log.Printf("Error waiting for rate limiter: %s", err)
-				return
+			// Лимитирование запросов на загрузку; после отмены ctx канал только дочитывается
+			if err := uploadToServerLimiter.Wait(ctx); err != nil {
+				continue
 			}
 
-			media, err := cu.processAndUpload(updateCardsMediaUrl, batch)
+			media, err := cu.processAndUpload(run, updateCardsMediaUrl, batch)
 			if err != nil {
 				log.Printf("Error during uploading %s", err)
 				continue
 			}
-			updatedCount += media
+			run.Metrics().UpdatedCount.Add(int32(media))
 		}
 	}()
 
@@ -558,9 +737,11 @@ func (cu *CardUpdateService) UpdateCardMedia(ctx context.Context, settings reque
 	close(uploadChan)
 	uploadWg.Wait()
 
-	log.Printf("Goroutines fetchers got (%d) nomenclautres", goroutinesNmsCount.Load())
-	log.Printf("Media update completed, total updated count: %d. Unfetched count : %d", updatedCount, numberOfErroredNomenclatures.Load())
-	return updatedCount, nil
+	log.Printf("Goroutines fetchers got (%d) nomenclautres", run.Metrics().GoroutinesNmsCount.Load())
+	log.Printf("Media update completed, total updated count: %d. Unfetched count : %d", run.Metrics().UpdatedCount.Load(), run.Metrics().ErroredNomenclatures.Load())
+	err = ctx.Err()
+	run.Finish(err)
+	return int(run.Metrics().UpdatedCount.Load()), err
 }
 
 func (cu *CardUpdateService) UpdateCardBrand(ctx context.Context, settings request2.Settings) (int, error) {
@@ -572,18 +753,22 @@ func (cu *CardUpdateService) UpdateCardBrand(ctx context.Context, settings reque
 	var currentBatch []request2.Model
 	var currentBatchSize int
 	var gotData []response2.Nomenclature
-	var goroutinesNmsCount atomic.Int32
 
+	run := cu.startRun("brand", settings)
 	brandsContext, cancel := context.WithTimeout(ctx, time.Second*15)
 	defer cancel()
 	brandsRaw, err := cu.wsclient.FetcherChain.Fetch(brandsContext, "brands", requests2.BrandRequest{FilterRequest: requests2.FilterRequest{ProductIDs: []int{}}})
 	if err != nil {
-		return 0, fmt.Errorf("error fetching descriptions: %w", err)
+		err = fmt.Errorf("error fetching brands: %w", err)
+		run.Finish(err)
+		return 0, err
 	}
 
 	brandsMap, ok := brandsRaw.(map[int]interface{})
 	if !ok {
-		return 0, fmt.Errorf("error fetching descriptions: %w", err)
+		err = fmt.Errorf("unexpected type for brands: expected map[int]interface{}, got %T", brandsRaw)
+		run.Finish(err)
+		return 0, err
 	}
 
 	var processWg sync.WaitGroup
@@ -595,11 +780,12 @@ func (cu *CardUpdateService) UpdateCardBrand(ctx context.Context, settings reque
 
 	uploadToServerLimiter := rate.NewLimiter(rate.Every(time.Minute/UPLOAD_RATE_LIMIT), UPLOAD_RATE_LIMIT)
 
-	ctx, cancel = context.WithCancel(context.Background())
+	ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
 	go func() {
 		if err := cu.nomenclatureService.GetNomenclaturesWithLimitConcurrentlyPutIntoChannel(ctx, settings, "", nomenclatureChan); err != nil {
 			log.Printf("Error fetching nomenclatures concurrently: %s", err)
+			run.Fail(fmt.Errorf("error fetching nomenclatures: %w", err))
 		}
 	}()
 
@@ -610,7 +796,8 @@ func (cu *CardUpdateService) UpdateCardBrand(ctx context.Context, settings reque
 		go func(i int) {
 			defer processWg.Done()
 			for nomenclature := range nomenclatureChan {
-				goroutinesNmsCount.Add(1)
+				run.Metrics().GoroutinesNmsCount.Add(1)
+				cu.preview.Remember(run.ID, nomenclature)
 
 				var wbCard models.WildberriesCard
 				wbCard = *wbCard.FromNomenclature(nomenclature)
@@ -619,22 +806,33 @@ func (cu *CardUpdateService) UpdateCardBrand(ctx context.Context, settings reque
 					continue // Если запись уже была обработана, пропускаем её
 				}
 
-				globalId, err := nomenclature.GlobalID()
+				globalId, err := cu.articular.SupplierArticul(nomenclature.VendorCode)
 				if err != nil || globalId == 0 {
-					numberOfErroredNomenclatures.Add(1)
+					run.Metrics().ErroredNomenclatures.Add(1)
+					run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
 					continue
 				}
 
 				switch brand := brandsMap[globalId].(type) {
 				case string:
 					if cu.brandService.IsBanned(brand) || brand == "" {
-						numberOfErroredNomenclatures.Add(1)
+						run.Metrics().ErroredNomenclatures.Add(1)
+						run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
 						continue
 					}
 					wbCard.Brand = brand
 				default:
-					numberOfErroredNomenclatures.Add(1)
+					run.Metrics().ErroredNomenclatures.Add(1)
+					run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeSkippedInvalid, "")
+					continue
 				}
+				if !cu.changes.Track(run.ID, nomenclature, &wbCard, nil) {
+					run.Metrics().UnchangedCount.Add(1)
+					run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeUnchanged, "")
+					continue
+				}
+				run.Metrics().ProcessedCount.Add(1)
+				run.Record(nomenclature.NmID, nomenclature.VendorCode, models.OutcomeProcessed, "")
 
 				mu.Lock()
 				gotData = append(gotData, nomenclature)
@@ -657,18 +855,17 @@ func (cu *CardUpdateService) UpdateCardBrand(ctx context.Context, settings reque
 		defer uploadWg.Done()
 		for batch := range uploadChan {
 			log.Println("Uploading batch of cards...")
-			// Лимитирование запросов на загрузку
-			if err := uploadToServerLimiter.Wait(context.Background()); err != nil {
-				log.Printf("Error waiting for rate limiter: %s", err)
-				return
+			// Лимитирование запросов на загрузку; после отмены ctx канал только дочитывается
+			if err := uploadToServerLimiter.Wait(ctx); err != nil {
+				continue
 			}
 
-			cards, err := cu.processAndUpload(updateCardsUrl, batch)
+			cards, err := cu.processAndUpload(run, updateCardsUrl, batch)
 			if err != nil {
 				log.Printf("Error during uploading %s", err)
-				return
+				continue
 			}
-			updatedCount.Add(int32(cards))
+			run.Metrics().UpdatedCount.Add(int32(cards))
 		}
 	}()
 
@@ -684,58 +881,73 @@ func (cu *CardUpdateService) UpdateCardBrand(ctx context.Context, settings reque
 	close(uploadChan)
 	uploadWg.Wait()
 
-	log.Printf("Goroutines fetchers got (%d) nomenclautres", goroutinesNmsCount.Load())
-	log.Printf("Update completed, total updated count: %d. Unfetched count : %d", updatedCount.Load(), numberOfErroredNomenclatures.Load())
-	return int(updatedCount.Load()), nil
+	log.Printf("Goroutines fetchers got (%d) nomenclautres", run.Metrics().GoroutinesNmsCount.Load())
+	log.Printf("Update completed, total updated count: %d. Unfetched count : %d", run.Metrics().UpdatedCount.Load(), run.Metrics().ErroredNomenclatures.Load())
+	err = ctx.Err()
+	run.Finish(err)
+	return int(run.Metrics().UpdatedCount.Load()), err
 }
 
-func (cu *CardUpdateService) UpdateDBNomenclatures(settings request2.Settings, locale string) (int, error) {
-	return cu.nomenclatureService.UploadToDb(settings, locale)
+func (cu *CardUpdateService) UpdateDBNomenclatures(ctx context.Context, settings request2.Settings, locale string) (int, error) {
+	return cu.nomenclatureService.UploadToDb(ctx, settings, locale)
 }
 
 func (cu *CardUpdateService) CheckSearchEngine(settings request2.Settings, locale string) (int, error) {
 	return cu.nomenclatureService.CheckTotalNmCount(settings, locale)
 }
 
-func (cu *CardUpdateService) processAndUpload(url string, data interface{}) (int, error) {
+// processAndUpload отправляет data в WB и записывает в run исход карточек: uploaded, banned или rejected.
+// Если WB отклонил запрос из-за забаненных артикулов, остальные карточки отправляются повторно.
+func (cu *CardUpdateService) processAndUpload(run *UpdateRun, url string, data interface{}) (int, error) {
+	if cu.preview != nil {
+		return cu.preview.Write(run.ID, url, data)
+	}
 	bodyBytes, statusCode, err := cu.uploadModels(url, data)
-	if err != nil {
-		if statusCode != http.StatusOK && bodyBytes != nil {
-			var errorResponse map[string]interface{}
-			log.Printf("Trying to fix (Status=%d)...", statusCode)
-			if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil {
-				if additionalErrors, ok := errorResponse["additionalErrors"].(map[string]interface{}); ok {
-					if bannedArticles, ok := additionalErrors["Забаненные артикулы WB"].(string); ok {
-						bannedArticlesSlice := strings.Split(bannedArticles, ", ")
-						filteredModels := cu.filterOutBannedModels(data, bannedArticlesSlice)
-						if len(filteredModels) > 0 {
-							dataLen, err := cu.processAndUpload(url, filteredModels)
-							if err != nil {
-								return 0, err
-							}
-							return dataLen, nil
-						}
+	if err == nil {
+		cu.commitChanges(run, data)
+		run.RecordModels(data, models.OutcomeUploaded, "")
+		// Используем универсальную функцию для получения длины данных
+		return cu.getDataLength(data), nil
+	}
+
+	if statusCode != http.StatusOK && bodyBytes != nil {
+		var errorResponse map[string]interface{}
+		log.Printf("Trying to fix (Status=%d)...", statusCode)
+		if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil {
+			if additionalErrors, ok := errorResponse["additionalErrors"].(map[string]interface{}); ok {
+				if bannedArticles, ok := additionalErrors["Забаненные артикулы WB"].(string); ok {
+					bannedArticlesSlice := strings.Split(bannedArticles, ", ")
+					filteredModels := cu.filterOutBannedModels(run, data, bannedArticlesSlice)
+					if len(filteredModels) == 0 {
+						return 0, nil
 					}
+					return cu.processAndUpload(run, url, filteredModels)
 				}
 			}
 		}
 	}
 
-	// Используем универсальную функцию для получения длины данных
-	return cu.getDataLength(data), nil
+	run.RecordModels(data, models.OutcomeRejected, wbErrorText(err, bodyBytes))
+	run.Fail(err)
+	return 0, err
+}
+
+// commitChanges сохраняет изменения карточек успешно отправленного запроса.
+func (cu *CardUpdateService) commitChanges(run *UpdateRun, data interface{}) {
+	if err := cu.changes.Commit(context.Background(), run.ID, data); err != nil {
+		log.Printf("Error saving card changes: %s", err)
+	}
 }
 
 func (cu *CardUpdateService) getDataLength(data interface{}) int {
-	// Проверяем, является ли data срезом
-	switch v := data.(type) {
-	case []interface{}:
-		return len(v)
-	case interface{}:
-		return 1
-	default:
-		log.Println("Unknown data type, returning length as 0")
+	// Срез любого типа ([]request2.Model, []interface{}) - по числу элементов, одна модель - 1
+	if data == nil {
 		return 0
 	}
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Slice {
+		return v.Len()
+	}
+	return 1
 }
 
 func (cu *CardUpdateService) uploadModels(url string, models interface{}) ([]byte, int, error) {
@@ -788,11 +1000,11 @@ func (cu *CardUpdateService) uploadModels(url string, models interface{}) ([]byt
 	return bodyBytes, resp.StatusCode, nil
 }
 
-func (cu *CardUpdateService) filterOutBannedModels(data interface{}, bannedArticles []string) []interface{} {
+// filterOutBannedModels убирает из data модели забаненных артикулов и записывает им в run исход banned.
+func (cu *CardUpdateService) filterOutBannedModels(run *UpdateRun, data interface{}, bannedArticles []string) []interface{} {
 	bannedSet := make(map[string]struct{}, len(bannedArticles))
 	for _, article := range bannedArticles {
 		bannedSet[article] = struct{}{}
-		numberOfErroredNomenclatures.Add(1)
 	}
 
 	var filteredModels []interface{}
@@ -813,9 +1025,12 @@ func (cu *CardUpdateService) filterOutBannedModels(data interface{}, bannedArtic
 		case *request2.MediaRequest:
 			id = strconv.Itoa(v.NmId)
 		}
-		if _, isBanned := bannedSet[id]; !isBanned {
-			filteredModels = append(filteredModels, model)
+		if _, isBanned := bannedSet[id]; isBanned {
+			run.Metrics().ErroredNomenclatures.Add(1)
+			run.RecordModels(model, models.OutcomeBanned, "")
+			continue
 		}
+		filteredModels = append(filteredModels, model)
 	}
 
 	return filteredModels
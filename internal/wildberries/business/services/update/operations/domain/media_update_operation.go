@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	registry "gomarketplace_api/internal/registry/business"
 	clients2 "gomarketplace_api/internal/suppliers/wholesaler/pkg/clients"
 	"gomarketplace_api/internal/wildberries/business/models/dto/request"
 	"gomarketplace_api/internal/wildberries/business/models/dto/response"
@@ -20,14 +21,16 @@ var (
 )
 
 type MediaUpdateOperation struct {
-	mediaMap map[int][]string
-	client   *clients.WServiceClient
+	mediaMap  map[int][]string
+	client    *clients.WServiceClient
+	articular *registry.ArticularService
 }
 
-func NewMediaUpdateOperation(client *clients.WServiceClient) *MediaUpdateOperation {
+func NewMediaUpdateOperation(client *clients.WServiceClient, articular *registry.ArticularService) *MediaUpdateOperation {
 	return &MediaUpdateOperation{
-		mediaMap: make(map[int][]string),
-		client:   client,
+		mediaMap:  make(map[int][]string),
+		client:    client,
+		articular: articular,
 	}
 }
 
@@ -39,7 +42,7 @@ func (op *MediaUpdateOperation) Validate(nom response.Nomenclature) bool {
 	if len(nom.Photos) <= 1 {
 		return false
 	}
-	globalID, err := nom.GlobalID()
+	globalID, err := op.articular.SupplierArticul(nom.VendorCode)
 	if err != nil || globalID == 0 {
 		return false
 	}
@@ -53,7 +56,7 @@ func (op *MediaUpdateOperation) Validate(nom response.Nomenclature) bool {
 // Process создаёт модель запроса на основе номенклатуры и mediaMap.
 // Если в mediaMap для globalID только 1 URL – дублирует его для улучшения качества.
 func (op *MediaUpdateOperation) Process(ctx context.Context, nom response.Nomenclature) (request.Model, error) {
-	globalID, err := nom.GlobalID()
+	globalID, err := op.articular.SupplierArticul(nom.VendorCode)
 	if err != nil {
 		return nil, fmt.Errorf("invalid globalID: %w", err)
 	}
@@ -0,0 +1,226 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	request2 "gomarketplace_api/internal/wildberries/business/models/dto/request"
+	response2 "gomarketplace_api/internal/wildberries/business/models/dto/response"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+	"gomarketplace_api/internal/wildberries/business/services/update/operations"
+	domainmodels "gomarketplace_api/internal/wildberries/business/services/update/operations/domain/models"
+	"log"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// maxMediaFiles - максимум фото и видео в карточке WB.
+const maxMediaFiles = 30
+
+// PreviewRecord - одна карточка из запроса к WB, записанного в режиме dry-run вместо отправки.
+// Карточки одного запроса имеют общий Batch.
+type PreviewRecord struct {
+	Batch      int64           `json:"batch"`
+	URL        string          `json:"url"`
+	NmID       int             `json:"nm_id,omitempty"`
+	VendorCode string          `json:"vendor_code,omitempty"`
+	Payload    json.RawMessage `json:"payload"`
+	Diff       []FieldChange   `json:"diff,omitempty"`
+	Errors     []string        `json:"errors,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// PreviewSink принимает записи dry-run: JSON-lines файл или память для HTTP-просмотра.
+type PreviewSink interface {
+	WritePreview(records []PreviewRecord) error
+}
+
+// Preview - режим dry-run сервисов обновления: готовые запросы к WB проверяются, сравниваются
+// с текущими карточками и записываются в PreviewSink, а в WB ничего не отправляется.
+type Preview struct {
+	sink PreviewSink
+	// originals - текущие карточки запусков: runID -> *sync.Map (nmID -> response2.Nomenclature).
+	// Карточки запуска удаляются, когда он завершается (Forget).
+	originals sync.Map
+	batches   atomic.Int64
+	cards     atomic.Int64
+	invalid   atomic.Int64
+}
+
+func NewPreview(sink PreviewSink) *Preview {
+	return &Preview{sink: sink}
+}
+
+// Remember запоминает текущую карточку запуска runID для сравнения с запросом. Без dry-run (nil) ничего не делает.
+func (p *Preview) Remember(runID string, nom response2.Nomenclature) {
+	if p == nil {
+		return
+	}
+	originals, _ := p.originals.LoadOrStore(runID, &sync.Map{})
+	originals.(*sync.Map).Store(nom.NmID, nom)
+}
+
+// Forget удаляет карточки, запомненные запуском runID. Без dry-run (nil) ничего не делает.
+func (p *Preview) Forget(runID string) {
+	if p == nil {
+		return
+	}
+	p.originals.Delete(runID)
+}
+
+// Write записывает запрос url запуска runID с телом data (модель или срез моделей) и возвращает число
+// карточек в нём. Запросы сравниваются с карточками, запомненными запуском; пустой runID - без сравнения.
+func (p *Preview) Write(runID, url string, data interface{}) (int, error) {
+	batch := p.batches.Add(1)
+	now := time.Now()
+
+	originals := &sync.Map{}
+	if runOriginals, ok := p.originals.Load(runID); ok {
+		originals = runOriginals.(*sync.Map)
+	}
+
+	var records []PreviewRecord
+	for _, item := range previewItems(data) {
+		payload, err := json.Marshal(item)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal preview payload: %w", err)
+		}
+		record := PreviewRecord{Batch: batch, URL: url, Payload: payload, Errors: ValidateModel(item), CreatedAt: now}
+		record.NmID, record.VendorCode = modelIdentity(item)
+		if nom, ok := originals.Load(record.NmID); ok && record.NmID != 0 {
+			record.Diff = CardDiff(nom.(response2.Nomenclature), item)
+		}
+		if len(record.Errors) > 0 {
+			p.invalid.Add(1)
+		}
+		records = append(records, record)
+	}
+
+	if err := p.sink.WritePreview(records); err != nil {
+		return 0, fmt.Errorf("failed to write preview: %w", err)
+	}
+	p.cards.Add(int64(len(records)))
+	log.Printf("Dry run: %d cards for %s written to preview (batch %d)", len(records), url, batch)
+	return len(records), nil
+}
+
+// Stats возвращает число записанных запросов, карточек и карточек с ошибками проверки.
+func (p *Preview) Stats() (batches, cards, invalid int64) {
+	return p.batches.Load(), p.cards.Load(), p.invalid.Load()
+}
+
+// previewItems раскладывает тело запроса на карточки. CreateCardRequestWrapper остаётся целым:
+// это одна карточка с вариантами.
+func previewItems(data interface{}) []interface{} {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return []interface{}{data}
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items
+}
+
+func modelIdentity(model interface{}) (int, string) {
+	switch m := model.(type) {
+	case *models.WildberriesCard:
+		return m.NmID, m.VendorCode
+	case *request2.MediaRequest:
+		return m.NmId, ""
+	case domainmodels.MediaModel:
+		return m.NmID, ""
+	case domainmodels.BrandModel:
+		return m.NmID, ""
+	case domainmodels.AppellationModel:
+		return m.NmID, ""
+	case domainmodels.CompositeModel:
+		return m.NmID, ""
+	case *domainmodels.MediaModel:
+		return m.NmID, ""
+	case *domainmodels.BrandModel:
+		return m.NmID, ""
+	case *domainmodels.AppellationModel:
+		return m.NmID, ""
+	case *domainmodels.CompositeModel:
+		return m.NmID, ""
+	case *operations.SequentialModel:
+		for _, sub := range m.Models {
+			if nmID, vendorCode := modelIdentity(sub); nmID != 0 {
+				return nmID, vendorCode
+			}
+		}
+	case request2.CreateCardRequestWrapper:
+		if len(m.Variants) > 0 {
+			return 0, m.Variants[0].VendorCode
+		}
+	}
+	return 0, ""
+}
+
+// ValidateModel проверяет запрос к WB по ограничениям content-api и возвращает найденные ошибки.
+func ValidateModel(model interface{}) []string {
+	var errs []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+	checkTitle := func(title string) {
+		check(title != "", "title is empty")
+		check(utf8.RuneCountInString(title) <= maxTitleLength, "title is longer than %d characters", maxTitleLength)
+	}
+	checkMedia := func(urls []string) {
+		check(len(urls) > 0, "media is empty")
+		check(len(urls) <= maxMediaFiles, "more than %d media files", maxMediaFiles)
+		for _, u := range urls {
+			parsed, err := url.Parse(u)
+			check(err == nil && strings.HasPrefix(parsed.Scheme, "http") && parsed.Host != "", "invalid media url %q", u)
+		}
+	}
+
+	switch m := model.(type) {
+	case *models.WildberriesCard:
+		check(m.NmID > 0, "nmID is required")
+		check(m.VendorCode != "", "vendorCode is required")
+		check(m.Brand != "", "brand is empty")
+		checkTitle(m.Title)
+		check(utf8.RuneCountInString(m.Description) <= maxDescLength, "description is longer than %d characters", maxDescLength)
+		check(m.Dimensions.Length > 0 && m.Dimensions.Width > 0 && m.Dimensions.Height > 0, "dimensions must be positive")
+	case *request2.MediaRequest:
+		check(m.NmId > 0, "nmId is required")
+		checkMedia(m.Data)
+	case domainmodels.MediaModel:
+		check(m.NmID > 0, "nmId is required")
+		checkMedia(m.URLs)
+	case domainmodels.BrandModel:
+		check(m.NmID > 0, "nmId is required")
+		check(m.Brand != "", "brand is empty")
+	case domainmodels.AppellationModel:
+		check(m.NmID > 0, "nmId is required")
+		checkTitle(m.Title)
+	case domainmodels.CompositeModel:
+		check(m.NmID > 0, "nmId is required")
+		if m.Title != "" {
+			checkTitle(m.Title)
+		}
+		if len(m.Media) > 0 {
+			checkMedia(m.Media)
+		}
+	case request2.CreateCardRequestWrapper:
+		check(m.SubjectID > 0, "subjectID is required")
+		check(len(m.Variants) > 0, "variants are empty")
+		for _, variant := range m.Variants {
+			if err := variant.Validate(); err != nil {
+				errs = append(errs, fmt.Sprintf("variant %s: %s", variant.VendorCode, err))
+			}
+			check(utf8.RuneCountInString(variant.Title) <= maxTitleLength, "variant %s: title is longer than %d characters", variant.VendorCode, maxTitleLength)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,108 @@
+package update
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// defaultPreviewKeep - сколько последних записей dry-run хранит MemoryPreviewSink.
+const defaultPreviewKeep = 1000
+
+// JSONLinesPreviewSink дописывает записи dry-run в файл, по одной JSON-записи на строку.
+type JSONLinesPreviewSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewJSONLinesPreviewSink(path string) (*JSONLinesPreviewSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open preview file: %w", err)
+	}
+	return &JSONLinesPreviewSink{file: file}, nil
+}
+
+func (s *JSONLinesPreviewSink) WritePreview(records []PreviewRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := bufio.NewWriter(s.file)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (s *JSONLinesPreviewSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// MemoryPreviewSink хранит последние записи dry-run и отдаёт их по HTTP:
+// GET ?nm_id=123 - записи карточки, ?invalid=true - только не прошедшие проверку.
+type MemoryPreviewSink struct {
+	mu      sync.Mutex
+	keep    int
+	records []PreviewRecord
+}
+
+// NewMemoryPreviewSink создаёт хранилище последних keep записей (0 - 1000).
+func NewMemoryPreviewSink(keep int) *MemoryPreviewSink {
+	if keep <= 0 {
+		keep = defaultPreviewKeep
+	}
+	return &MemoryPreviewSink{keep: keep}
+}
+
+func (s *MemoryPreviewSink) WritePreview(records []PreviewRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	if extra := len(s.records) - s.keep; extra > 0 {
+		s.records = append([]PreviewRecord(nil), s.records[extra:]...)
+	}
+	return nil
+}
+
+// Records возвращает сохранённые записи; nmID 0 - всех карточек.
+func (s *MemoryPreviewSink) Records(nmID int, invalidOnly bool) []PreviewRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]PreviewRecord, 0, len(s.records))
+	for _, record := range s.records {
+		if nmID != 0 && record.NmID != nmID {
+			continue
+		}
+		if invalidOnly && len(record.Errors) == 0 {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func (s *MemoryPreviewSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var nmID int
+	if value := r.URL.Query().Get("nm_id"); value != "" {
+		var err error
+		if nmID, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid nm_id", http.StatusBadRequest)
+			return
+		}
+	}
+	invalidOnly := r.URL.Query().Get("invalid") == "true"
+
+	if err := json.NewEncoder(w).Encode(s.Records(nmID, invalidOnly)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,150 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	coremodels "gomarketplace_api/internal/core/models"
+	coreservices "gomarketplace_api/internal/core/services"
+	pkg2 "gomarketplace_api/internal/suppliers/wholesaler/pkg"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+	clients2 "gomarketplace_api/internal/wildberries/pkg/clients"
+	"gomarketplace_api/internal/wildberries/storage"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"strconv"
+)
+
+// PriceSyncService выгружает в WB цены PriceEngine: Y - цена до скидки, Z - цена продажи.
+// Карточки, у которых цена и скидка совпадают с последними выгруженными, пропускаются.
+// Перед выгрузкой цены проверяет MarginGuard; nil guard пропускает все цены.
+type PriceSyncService struct {
+	channel  coreservices.Channel
+	wsclient *clients2.WServiceClient
+	repo     *storage.PriceRepository
+	guard    *coreservices.MarginGuard
+	dryRun   bool
+	log      logger.Logger
+}
+
+func NewPriceSyncService(
+	channel coreservices.Channel,
+	wsclient *clients2.WServiceClient,
+	repo *storage.PriceRepository,
+	guard *coreservices.MarginGuard,
+	dryRun bool,
+	writer io.Writer) *PriceSyncService {
+	return &PriceSyncService{
+		channel:  channel,
+		wsclient: wsclient,
+		repo:     repo,
+		guard:    guard,
+		dryRun:   dryRun,
+		log:      logger.NewLogger(writer, "[WB PriceSync]"),
+	}
+}
+
+// Sync сравнивает цены PriceEngine с последними выгруженными и отправляет изменившиеся.
+// В режиме dry-run изменения только логируются: в WB ничего не отправляется и в БД не сохраняется.
+func (s *PriceSyncService) Sync(ctx context.Context) (*coremodels.PushResult, error) {
+	targets, err := s.repo.GetPriceTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(targets))
+	for _, target := range targets {
+		ids = append(ids, target.GlobalID)
+	}
+
+	prices, err := pkg2.FetchPrices(ctx, s.wsclient.FetcherChain, s.channel.Name(), ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching prices: %w", err)
+	}
+
+	result := coremodels.NewPushResult()
+	updates := make([]coremodels.PriceUpdate, 0, len(targets))
+	pushed := make(map[string]models.PricePushed, len(targets))
+	for _, target := range targets {
+		nmID := strconv.Itoa(target.NmID)
+		price, ok := prices[target.GlobalID]
+		if !ok || price.Z <= 0 {
+			result.Failed[nmID] = "нет рассчитанной цены"
+			continue
+		}
+
+		wbPrice, discount := models.PriceAndDiscount(price.Y, price.Z)
+		if target.LastPrice != nil && *target.LastPrice == wbPrice && *target.LastDiscount == discount {
+			result.Unchanged++
+			continue
+		}
+
+		updates = append(updates, coremodels.PriceUpdate{
+			GlobalID:        target.GlobalID,
+			ExternalID:      nmID,
+			Price:           price.Y,
+			DiscountedPrice: price.Z,
+		})
+		pushed[nmID] = models.PricePushed{GlobalID: target.GlobalID, NmID: target.NmID, Price: wbPrice, Discount: discount}
+	}
+
+	if s.dryRun {
+		allowed, quarantined, err := s.guard.Evaluate(ctx, s.channel.Name(), updates)
+		if err != nil {
+			return result, fmt.Errorf("margin guard failed: %w", err)
+		}
+		for _, update := range allowed {
+			p := pushed[update.ExternalID]
+			s.log.Log("[dry-run] nmID %s: price %d, discount %d%%", update.ExternalID, p.Price, p.Discount)
+		}
+		for _, item := range quarantined {
+			s.log.Log("[dry-run] nmID %s quarantined: %s", item.ExternalID, item.Reason)
+			result.Failed[item.ExternalID] = item.Reason
+		}
+		result.Changed = len(allowed)
+		s.log.Log("[dry-run] would change %d, unchanged %d, failed %d", result.Changed, result.Unchanged, len(result.Failed))
+		return result, nil
+	}
+
+	allowed, err := s.guard.Check(ctx, s.channel.Name(), updates)
+	if err != nil {
+		return result, fmt.Errorf("margin guard failed: %w", err)
+	}
+	allowedIDs := make(map[string]bool, len(allowed))
+	for _, update := range allowed {
+		allowedIDs[update.ExternalID] = true
+	}
+	for _, update := range updates {
+		if !allowedIDs[update.ExternalID] {
+			result.Failed[update.ExternalID] = "цена задержана проверкой маржи"
+		}
+	}
+	updates = allowed
+
+	if len(updates) > 0 {
+		pushResult, err := s.channel.PushPrices(ctx, updates)
+		if err != nil {
+			return result, fmt.Errorf("failed to push prices: %w", err)
+		}
+		result.Changed = pushResult.Changed
+		for nmID, reason := range pushResult.Failed {
+			result.Failed[nmID] = reason
+		}
+	}
+
+	saved := make([]models.PricePushed, 0, len(pushed))
+	confirmed := make([]coremodels.PriceUpdate, 0, len(updates))
+	for _, update := range updates {
+		if _, failed := result.Failed[update.ExternalID]; !failed {
+			saved = append(saved, pushed[update.ExternalID])
+			confirmed = append(confirmed, update)
+		}
+	}
+	if err := s.repo.SavePushed(ctx, saved); err != nil {
+		return result, err
+	}
+	if err := s.guard.Confirm(ctx, s.channel.Name(), confirmed); err != nil {
+		return result, err
+	}
+
+	s.log.Log("Changed %d, unchanged %d, failed %d", result.Changed, result.Unchanged, len(result.Failed))
+	return result, nil
+}
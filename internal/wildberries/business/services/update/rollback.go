@@ -0,0 +1,177 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/time/rate"
+	request2 "gomarketplace_api/internal/wildberries/business/models/dto/request"
+	response2 "gomarketplace_api/internal/wildberries/business/models/dto/response"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+	"gomarketplace_api/internal/wildberries/business/services/get"
+	"gomarketplace_api/internal/wildberries/storage"
+	"log"
+	"strconv"
+	"time"
+)
+
+// SetHistoryRepository включает откат карточек к версиям wildberries.nomenclatures_history.
+func (cu *CardUpdateService) SetHistoryRepository(history *storage.NomenclatureHistoryRepository) {
+	cu.history = history
+}
+
+// RollbackCard повторно загружает в WB версию version карточки nmID.
+func (cu *CardUpdateService) RollbackCard(ctx context.Context, nmID, version int) (int, error) {
+	settings := map[string]int{"nm_id": nmID, "version": version}
+	return cu.rollback(ctx, settings, []models.CardVersion{{NmID: nmID, Version: version}})
+}
+
+// RollbackRun возвращает карточки, обновлённые в запуске runID, к версиям до запуска.
+// Запуски, менявшие медиа (например, wb_media), не откатываются: WB хранит загруженные фото
+// по своим адресам, и прежние фото из версии карточки повторно загрузить нельзя.
+func (cu *CardUpdateService) RollbackRun(ctx context.Context, runID string) (int, error) {
+	if cu.changes.repo == nil {
+		return 0, fmt.Errorf("card changes are not saved, nothing to roll back")
+	}
+	payloads, err := cu.changes.repo.GetRunPayloads(ctx, runID)
+	if err != nil {
+		return 0, err
+	}
+	for _, payload := range payloads {
+		if _, ok := payloadMedia(payload); ok {
+			return 0, fmt.Errorf("run %s changed card media: media rollback is not supported", runID)
+		}
+	}
+	versions, err := cu.changes.repo.GetRunVersions(ctx, runID)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, fmt.Errorf("run %s has no saved card versions", runID)
+	}
+	return cu.rollback(ctx, map[string]string{"run_id": runID}, versions)
+}
+
+// rollback отправляет сохранённые версии карточек в /content/v2/cards/update: наименование, описание,
+// бренд, габариты, характеристики и размеры. Фото не откатываются. Карточки, которые в WB уже совпадают
+// с версией, пропускаются. Карточки без версии или не найденные в WB отмечаются в запуске rejected,
+// откат остальных продолжается. Откат сохраняется как запуск rollback с выборкой settings.
+// Возвращает число отправленных карточек.
+func (cu *CardUpdateService) rollback(ctx context.Context, settings interface{}, versions []models.CardVersion) (int, error) {
+	if cu.history == nil {
+		return 0, fmt.Errorf("card history is not configured")
+	}
+	run := cu.startRun("rollback", settings)
+	uploaded, err := cu.uploadVersions(ctx, run, versions)
+	run.Finish(err)
+	return uploaded, err
+}
+
+// cardFetchRateLimit - запросов карточек по одной в минуту при откате и поиске размеров (лимит WB - 100).
+const cardFetchRateLimit = 70
+
+// currentCard получает из WB текущее состояние карточки nmID. Локальные версии для сравнения
+// не подходят: отправленные обновления попадают в них только при следующей синхронизации карточек.
+func (cu *CardUpdateService) currentCard(ctx context.Context, nmID int) (*response2.Nomenclature, error) {
+	return findCard(ctx, &cu.nomenclatureService, nmID)
+}
+
+// findCard запрашивает из WB карточку nmID.
+func findCard(ctx context.Context, search *get.SearchEngine, nmID int) (*response2.Nomenclature, error) {
+	settings := request2.Settings{
+		Filter: request2.Filter{WithPhoto: -1, TextSearch: strconv.Itoa(nmID), TagIDs: []int{}, ObjectIDs: []int{}, Brands: []string{}},
+		Cursor: request2.Cursor{Limit: 100},
+	}
+	nomenclatures, err := search.GetNomenclatures(ctx, settings, "")
+	if err != nil {
+		return nil, fmt.Errorf("fetch card %d from WB: %w", nmID, err)
+	}
+	for _, nomenclature := range nomenclatures.Data {
+		if nomenclature.NmID == nmID {
+			return &nomenclature, nil
+		}
+	}
+	return nil, fmt.Errorf("card %d not found in WB", nmID)
+}
+
+// uploadVersions отправляет версии карточек versions в рамках запуска run.
+func (cu *CardUpdateService) uploadVersions(ctx context.Context, run *UpdateRun, versions []models.CardVersion) (int, error) {
+	limiter := rate.NewLimiter(rate.Every(time.Minute/uploadRateLimit), uploadRateLimit)
+	fetchLimiter := rate.NewLimiter(rate.Every(time.Minute/cardFetchRateLimit), cardFetchRateLimit)
+
+	var batch []request2.Model
+	uploaded, unchanged, rejected := 0, 0, 0
+	reject := func(nmID int, err error) {
+		rejected++
+		run.Metrics().ErroredNomenclatures.Add(1)
+		run.Record(nmID, "", models.OutcomeRejected, err.Error())
+		run.Fail(err)
+		log.Printf("Rollback %s: %s", run.ID, err)
+	}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		count, err := cu.processAndUpload(run, updateCardsUrl, batch)
+		if err != nil {
+			return err
+		}
+		uploaded += count
+		run.Metrics().UpdatedCount.Add(int32(count))
+		batch = nil
+		return nil
+	}
+
+	for _, version := range versions {
+		if version.Version == 0 {
+			reject(version.NmID, fmt.Errorf("card %d has no saved version before the run", version.NmID))
+			continue
+		}
+		snapshot, err := cu.history.GetVersion(ctx, version.NmID, version.Version)
+		if errors.Is(err, storage.ErrVersionNotFound) {
+			reject(version.NmID, fmt.Errorf("card %d has no version %d", version.NmID, version.Version))
+			continue
+		}
+		if err != nil {
+			return uploaded, err
+		}
+		if err := fetchLimiter.Wait(ctx); err != nil {
+			return uploaded, err
+		}
+		current, err := cu.currentCard(ctx, version.NmID)
+		if err != nil {
+			reject(version.NmID, err)
+			continue
+		}
+
+		cu.preview.Remember(run.ID, *current)
+		card := (&models.WildberriesCard{}).FromNomenclature(*snapshot)
+		if !cu.changes.Track(run.ID, *current, card, nil, fmt.Sprintf("Откат к версии %d", version.Version)) {
+			unchanged++
+			run.Metrics().UnchangedCount.Add(1)
+			run.Record(card.NmID, card.VendorCode, models.OutcomeUnchanged, "")
+			continue
+		}
+		run.Metrics().ProcessedCount.Add(1)
+		run.Record(card.NmID, card.VendorCode, models.OutcomeProcessed, "")
+		batch = append(batch, card)
+		if len(batch) >= uploadBatchSize {
+			if err := flush(); err != nil {
+				return uploaded, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return uploaded, err
+	}
+
+	log.Printf("Rollback %s completed: %d cards uploaded, %d already match the version, %d rejected",
+		run.ID, uploaded, unchanged, rejected)
+	if rejected > 0 {
+		return uploaded, fmt.Errorf("%d of %d cards not rolled back, see run %s", rejected, len(versions), run.ID)
+	}
+	return uploaded, nil
+}
@@ -11,6 +11,7 @@ import (
 	models "gomarketplace_api/internal/wildberries/business/models/get"
 	"gomarketplace_api/internal/wildberries/business/services"
 	"gomarketplace_api/internal/wildberries/business/services/update/operations"
+	"gomarketplace_api/internal/wildberries/storage"
 	"gomarketplace_api/metrics"
 	"io"
 	"log"
@@ -23,13 +24,28 @@ import (
 	"time"
 )
 
+// NomenclatureSource отправляет номенклатуры в nomenclatureChan и возвращает ошибку их получения.
+// Канал может остаться незакрытым: обработка заканчивается, когда источник вернулся.
+type NomenclatureSource func(ctx context.Context, nomenclatureChan chan response.Nomenclature) error
+
 type Service struct {
 	operation   operations.UpdateOperation
 	uploadURL   string
 	rateLimiter *rate.Limiter
 	workerCount int
-	metrics     *metrics.UpdateMetrics
 	auth        services.AuthEngine
+	// preview - режим dry-run: запросы записываются в preview, а не отправляются в WB
+	preview *Preview
+	// changes - отсечение обновлений без изменений и журнал wildberries.changes
+	changes *CardChanges
+	// runs - запуски обновления и исходы карточек; nil - не сохранять
+	runs *storage.UpdateRunRepository
+	// settings - выборка номенклатур, сохраняется в запуске
+	settings interface{}
+
+	mu sync.Mutex
+	// lastRun - последний начатый запуск, для LastRunID и Metrics
+	lastRun *UpdateRun
 }
 
 // NewUpdateService создает новый сервис обновления с указанными параметрами
@@ -45,42 +61,101 @@ func NewUpdateService(
 		uploadURL:   uploadURL,
 		rateLimiter: rateLimiter,
 		workerCount: workerCount,
-		metrics:     &metrics.UpdateMetrics{},
 		auth:        auth,
+		changes:     NewCardChanges(nil),
 	}
 }
 
-// Update запускает процесс обновления для номенклатур.
-// nomenclatureChan – канал, в который поступают номенклатуры для обработки.
-func (s *Service) Update(ctx context.Context, nomenclatureChan <-chan response.Nomenclature) (int, error) {
+// SetPreview включает режим dry-run: запросы обновления записываются в preview и не отправляются в WB.
+func (s *Service) SetPreview(preview *Preview) {
+	s.preview = preview
+}
+
+// SetChangeRepository включает запись изменений отправленных карточек в wildberries.changes.
+func (s *Service) SetChangeRepository(repo *storage.ChangeRepository) {
+	s.changes = NewCardChanges(repo)
+}
+
+// SetRunRepository включает сохранение запусков обновления и исходов карточек в wildberries.update_runs.
+func (s *Service) SetRunRepository(repo *storage.UpdateRunRepository) {
+	s.runs = repo
+}
+
+// SetSettings задаёт выборку номенклатур, которая сохраняется в запуске обновления.
+func (s *Service) SetSettings(settings interface{}) {
+	s.settings = settings
+}
+
+// Update запускает процесс обновления для номенклатур из source. Ошибка получения номенклатур
+// завершает запуск с ошибкой и возвращается.
+func (s *Service) Update(ctx context.Context, source NomenclatureSource) (int, error) {
+	run := startUpdateRun(s.runs, s.preview, s.changes, operationName(s.operation), s.settings)
+	s.mu.Lock()
+	s.lastRun = run
+	s.mu.Unlock()
 	processedItems := &sync.Map{}
 	uploadChan := make(chan request.Model)
 
+	nomenclatureChan := make(chan response.Nomenclature)
+	fetched := make(chan struct{})
+	var fetchErr error
+	go func() {
+		defer close(fetched)
+		if err := source(ctx, nomenclatureChan); err != nil {
+			log.Printf("Error fetching nomenclatures: %s", err)
+			fetchErr = fmt.Errorf("error fetching nomenclatures: %w", err)
+			run.Fail(fetchErr)
+		}
+	}()
+
 	var processWg sync.WaitGroup
 	for i := 0; i < s.workerCount; i++ {
 		processWg.Add(1)
 		go func(workerID int) {
 			defer processWg.Done()
-			for nom := range nomenclatureChan {
-				_, loaded := processedItems.LoadOrStore(nom.VendorCode, true)
-				if loaded {
-					continue
+			for {
+				// карточки читаются страницами: отправленные ранее медиа страницы загружаются одним запросом
+				page, ok := readPage(nomenclatureChan, fetched, mediaPageSize)
+				if !ok {
+					return
 				}
 
-				if !s.operation.Validate(nom) {
-					log.Printf("Worker %d: номенклатура %s не прошла валидацию", workerID, nom.VendorCode)
-					s.metrics.ErroredNomenclatures.Add(1)
-					continue
+				var requests []trackedModel
+				for _, nom := range page {
+					_, loaded := processedItems.LoadOrStore(nom.VendorCode, true)
+					if loaded {
+						continue
+					}
+
+					s.preview.Remember(run.ID, nom)
+					if !s.operation.Validate(nom) {
+						log.Printf("Worker %d: номенклатура %s не прошла валидацию", workerID, nom.VendorCode)
+						run.Metrics().ErroredNomenclatures.Add(1)
+						run.Record(nom.NmID, nom.VendorCode, models.OutcomeSkippedInvalid, "")
+						continue
+					}
+
+					model, err := s.operation.Process(ctx, nom)
+					if err != nil {
+						log.Printf("Worker %d: ошибка обработки %s: %s", workerID, nom.VendorCode, err)
+						run.Metrics().ErroredNomenclatures.Add(1)
+						run.Record(nom.NmID, nom.VendorCode, models.OutcomeSkippedInvalid, err.Error())
+						continue
+					}
+					requests = append(requests, trackedModel{nom: nom, model: model})
 				}
 
-				model, err := s.operation.Process(ctx, nom)
-				if err != nil {
-					log.Printf("Worker %d: ошибка обработки %s: %s", workerID, nom.VendorCode, err)
-					s.metrics.ErroredNomenclatures.Add(1)
-					continue
+				pushed := s.changes.PushedMedia(ctx, requests)
+				for _, req := range requests {
+					if !s.changes.Track(run.ID, req.nom, req.model, pushed[req.nom.NmID]) {
+						run.Metrics().UnchangedCount.Add(1)
+						run.Record(req.nom.NmID, req.nom.VendorCode, models.OutcomeUnchanged, "")
+						continue
+					}
+					run.Record(req.nom.NmID, req.nom.VendorCode, models.OutcomeProcessed, "")
+					uploadChan <- req.model
+					run.Metrics().ProcessedCount.Add(1)
 				}
-				uploadChan <- model
-				s.metrics.ProcessedCount.Add(1)
 			}
 		}(i)
 	}
@@ -89,26 +164,37 @@ func (s *Service) Update(ctx context.Context, nomenclatureChan <-chan response.N
 	uploadWg.Add(1)
 	go func() {
 		defer uploadWg.Done()
-		s.uploadWorker(ctx, uploadChan)
+		s.uploadWorker(ctx, run, uploadChan)
 	}()
 
 	processWg.Wait()
 	close(uploadChan)
 	uploadWg.Wait()
 
-	return int(s.metrics.UpdatedCount.Load()), nil
+	<-fetched
+	err := ctx.Err()
+	if err == nil {
+		err = fetchErr
+	}
+	run.Finish(err)
+	return int(run.Metrics().UpdatedCount.Load()), err
 }
 
 // uploadWorker обрабатывает загрузку данных
 func (s *Service) uploadWorker(
 	ctx context.Context,
+	run *UpdateRun,
 	uploadChan <-chan request.Model) {
 	s.UploadWorker(
 		ctx,
 		uploadChan,
 		s.uploadURL,
-		s.processAndUpload,
-		&s.metrics.UpdatedCount,
+		func(url string, data interface{}) (int, error) {
+			count, err := s.processAndUpload(run, url, data)
+			run.Fail(err)
+			return count, err
+		},
+		&run.Metrics().UpdatedCount,
 	)
 }
 
@@ -134,13 +220,22 @@ func (s *Service) UploadWorker(
 	}
 }
 
-func (s *Service) processAndUpload(url string, data interface{}) (int, error) {
+// processAndUpload отправляет data в WB и записывает в run исход карточек: uploaded, banned или rejected.
+func (s *Service) processAndUpload(run *UpdateRun, url string, data interface{}) (int, error) {
+	if s.preview != nil {
+		return s.preview.Write(run.ID, url, data)
+	}
 	bodyBytes, statusCode, err := s.uploadModels(url, data)
 	if err == nil {
+		if err := s.changes.Commit(context.Background(), run.ID, data); err != nil {
+			log.Printf("Error saving card changes: %s", err)
+		}
+		run.RecordModels(data, models.OutcomeUploaded, "")
 		return s.getDataLength(data), nil
 	}
 
 	if statusCode == http.StatusOK || bodyBytes == nil {
+		run.RecordModels(data, models.OutcomeRejected, wbErrorText(err, bodyBytes))
 		return 0, err
 	}
 
@@ -148,15 +243,20 @@ func (s *Service) processAndUpload(url string, data interface{}) (int, error) {
 
 	bannedArticles, parseErr := s.extractBannedArticles(bodyBytes)
 	if parseErr != nil {
+		run.RecordModels(data, models.OutcomeRejected, wbErrorText(err, bodyBytes))
 		return 0, err
 	}
 
-	filteredModels := s.filterOutBannedModels(data, bannedArticles)
+	filteredModels := s.filterOutBannedModels(run, data, bannedArticles)
 	if s.getDataLength(filteredModels) == 0 {
+		if reflect.ValueOf(data).Kind() != reflect.Slice {
+			// одиночная модель не фильтруется - запрос отклонён целиком
+			run.RecordModels(data, models.OutcomeRejected, wbErrorText(err, bodyBytes))
+		}
 		return 0, err
 	}
 
-	return s.processAndUpload(url, filteredModels)
+	return s.processAndUpload(run, url, filteredModels)
 }
 
 // extractBannedArticles извлекает список забаненных артикулов из ответа
@@ -179,11 +279,11 @@ func (s *Service) extractBannedArticles(bodyBytes []byte) ([]string, error) {
 	return strings.Split(bannedArticlesStr, ", "), nil
 }
 
-func (s *Service) filterOutBannedModels(data interface{}, bannedArticles []string) []interface{} {
+// filterOutBannedModels убирает из data модели забаненных артикулов и записывает им в run исход banned.
+func (s *Service) filterOutBannedModels(run *UpdateRun, data interface{}, bannedArticles []string) []interface{} {
 	bannedSet := make(map[string]struct{}, len(bannedArticles))
 	for _, article := range bannedArticles {
 		bannedSet[article] = struct{}{}
-		numberOfErroredNomenclatures.Add(1)
 	}
 
 	var filteredModels []interface{}
@@ -203,9 +303,12 @@ func (s *Service) filterOutBannedModels(data interface{}, bannedArticles []strin
 		case *request.MediaRequest:
 			id = strconv.Itoa(v.NmId)
 		}
-		if _, isBanned := bannedSet[id]; !isBanned {
-			filteredModels = append(filteredModels, model)
+		if _, isBanned := bannedSet[id]; isBanned {
+			run.Metrics().ErroredNomenclatures.Add(1)
+			run.RecordModels(model, models.OutcomeBanned, "")
+			continue
 		}
+		filteredModels = append(filteredModels, model)
 	}
 
 	return filteredModels
@@ -273,6 +376,30 @@ func (s *Service) getDataLength(data interface{}) int {
 	}
 }
 
+// Metrics возвращает счётчики последнего запуска Update.
 func (s *Service) Metrics() *metrics.UpdateMetrics {
-	return s.metrics
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastRun == nil {
+		return &metrics.UpdateMetrics{}
+	}
+	return s.lastRun.Metrics()
+}
+
+// LastRunID возвращает ID последнего запуска Update.
+func (s *Service) LastRunID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastRun == nil {
+		return ""
+	}
+	return s.lastRun.ID
+}
+
+// operationName - имя операции для ID запуска: MediaUpdateOperation -> media.
+func operationName(operation operations.UpdateOperation) string {
+	name := reflect.TypeOf(operation).String()
+	name = name[strings.LastIndex(name, ".")+1:]
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "Operation"), "Update")
+	return strings.ToLower(name)
 }
@@ -0,0 +1,74 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang.org/x/time/rate"
+	"gomarketplace_api/internal/wildberries/business/models/dto/request"
+	"gomarketplace_api/internal/wildberries/business/models/dto/response"
+	"testing"
+	"time"
+)
+
+type fakeMediaOperation struct{}
+
+func (fakeMediaOperation) Validate(response.Nomenclature) bool { return true }
+
+func (fakeMediaOperation) Process(_ context.Context, nom response.Nomenclature) (request.Model, error) {
+	return request.NewMediaRequest(nom.NmID, []string{"https://img/1.jpg"}), nil
+}
+
+func newPreviewService(sink PreviewSink) *Service {
+	service := NewUpdateService(fakeMediaOperation{}, "https://wb/media", rate.NewLimiter(rate.Inf, 1), 2, nil)
+	service.SetPreview(NewPreview(sink))
+	return service
+}
+
+func TestUpdateFetchError(t *testing.T) {
+	service := newPreviewService(NewMemoryPreviewSink(10))
+	fetchErr := errors.New("wb is down")
+
+	done := make(chan struct{})
+	var updated int
+	var err error
+	go func() {
+		defer close(done)
+		// источник падает, не закрыв канал, как GetNomenclaturesWithLimitConcurrentlyPutIntoChannel
+		updated, err = service.Update(context.Background(), func(ctx context.Context, ch chan response.Nomenclature) error {
+			ch <- response.Nomenclature{NmID: 1, VendorCode: "id-1-1366"}
+			return fetchErr
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Update did not return after the source failed")
+	}
+	if !errors.Is(err, fetchErr) {
+		t.Fatalf("err = %v, want fetch error", err)
+	}
+	if updated != 1 {
+		t.Errorf("updated = %d, want 1 (cards received before the error are processed)", updated)
+	}
+}
+
+func TestUpdateSourceClosesChannel(t *testing.T) {
+	sink := NewMemoryPreviewSink(10)
+	service := newPreviewService(sink)
+
+	updated, err := service.Update(context.Background(), func(ctx context.Context, ch chan response.Nomenclature) error {
+		defer close(ch)
+		for nmID := 1; nmID <= 3; nmID++ {
+			ch <- response.Nomenclature{NmID: nmID, VendorCode: fmt.Sprintf("id-%d-1366", nmID)}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated != 3 || len(sink.Records(0, false)) != 3 {
+		t.Errorf("updated = %d, preview = %d, want 3", updated, len(sink.Records(0, false)))
+	}
+}
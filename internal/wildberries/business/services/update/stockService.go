@@ -0,0 +1,166 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/time/rate"
+	coremodels "gomarketplace_api/internal/core/models"
+	coreservices "gomarketplace_api/internal/core/services"
+	"gomarketplace_api/internal/wildberries/business/models/dto/response"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+	"gomarketplace_api/internal/wildberries/business/services/get"
+	"gomarketplace_api/internal/wildberries/storage"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"time"
+)
+
+// sizeLookupLimit - сколько карточек без размеров запрашивается из WB по одной. Если их больше
+// (первый запуск, карточки ещё не синхронизированы), перечитываются все карточки продавца.
+const sizeLookupLimit = 100
+
+// StockSyncService выгружает остатки wholesaler.stocks на склад продавца WB.
+// Выгружаются только баркоды, остаток которых отличается от последнего выгруженного.
+type StockSyncService struct {
+	channel     coreservices.Channel
+	search      *get.SearchEngine
+	repo        *storage.StockRepository
+	warehouseID int64
+	// limiter - запросы карточек без размеров к Content API
+	limiter *rate.Limiter
+	log     logger.Logger
+}
+
+func NewStockSyncService(channel coreservices.Channel, search *get.SearchEngine, repo *storage.StockRepository, warehouseID int64, writer io.Writer) *StockSyncService {
+	return &StockSyncService{
+		channel:     channel,
+		search:      search,
+		repo:        repo,
+		warehouseID: warehouseID,
+		limiter:     rate.NewLimiter(rate.Every(time.Minute/cardFetchRateLimit), 5),
+		log:         logger.NewLogger(writer, "[WB StockSync]"),
+	}
+}
+
+// Sync обновляет размеры карточек, сравнивает остатки с последними выгруженными и отправляет изменившиеся.
+func (s *StockSyncService) Sync(ctx context.Context) (*coremodels.PushResult, error) {
+	if err := s.refreshSizes(ctx); err != nil {
+		return nil, err
+	}
+
+	targets, err := s.repo.GetStockTargets(ctx, s.warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]coremodels.StockUpdate, 0, len(targets))
+	unchanged := 0
+	for _, target := range targets {
+		amount := max(target.Stocks, 0)
+		if target.LastPushed != nil && *target.LastPushed == amount {
+			unchanged++
+			continue
+		}
+		updates = append(updates, coremodels.StockUpdate{GlobalID: target.GlobalID, SKU: target.Sku, Quantity: amount})
+	}
+
+	result := coremodels.NewPushResult()
+	if len(updates) > 0 {
+		result, err = s.channel.PushStocks(ctx, updates)
+		if err != nil {
+			return result, fmt.Errorf("failed to push stocks: %w", err)
+		}
+	}
+	result.Unchanged = unchanged
+
+	pushed := make(map[string]int, len(updates))
+	for _, update := range updates {
+		if _, failed := result.Failed[update.SKU]; !failed {
+			pushed[update.SKU] = update.Quantity
+		}
+	}
+	if err := s.repo.SavePushed(ctx, s.warehouseID, pushed); err != nil {
+		return result, err
+	}
+
+	s.log.Log("Warehouse %d: changed %d, unchanged %d, failed %d SKUs",
+		s.warehouseID, result.Changed, result.Unchanged, len(result.Failed))
+	for sku, reason := range result.Failed {
+		s.log.Log("SKU %s failed: %s", sku, reason)
+	}
+	return result, nil
+}
+
+// refreshSizes сохраняет chrtID и баркоды размеров карточек из wildberries.cards_actual.
+// Из WB запрашиваются только карточки, размеров которых там нет.
+func (s *StockSyncService) refreshSizes(ctx context.Context) error {
+	if err := s.repo.SyncSizesFromCards(ctx); err != nil {
+		return err
+	}
+	unknown, err := s.repo.GetCardsWithoutSizes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	if len(unknown) > sizeLookupLimit {
+		s.log.Log("%d cards have no sizes, listing all cards", len(unknown))
+		return s.listSizes(ctx)
+	}
+
+	var sizes []models.NomenclatureSize
+	for _, nmID := range unknown {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return err
+		}
+		nomenclature, err := findCard(ctx, s.search, nmID)
+		if err != nil {
+			s.log.Log("Sizes of card %d not loaded: %v", nmID, err)
+			continue
+		}
+		sizes = append(sizes, nomenclatureSizes(*nomenclature)...)
+	}
+	return s.repo.UpsertSizes(ctx, sizes)
+}
+
+// listSizes перечитывает все карточки продавца и сохраняет chrtID и баркоды их размеров.
+func (s *StockSyncService) listSizes(ctx context.Context) error {
+	cardCh := make(chan coremodels.ChannelCard)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.channel.ListCards(ctx, cardCh)
+	}()
+
+	var sizes []models.NomenclatureSize
+	for card := range cardCh {
+		if card.GlobalID == 0 || len(card.Raw) == 0 {
+			continue
+		}
+		var nomenclature response.Nomenclature
+		if err := json.Unmarshal(card.Raw, &nomenclature); err != nil {
+			s.log.Log("Skipping card %s: %v", card.VendorCode, err)
+			continue
+		}
+		sizes = append(sizes, nomenclatureSizes(nomenclature)...)
+	}
+	if err := <-errCh; err != nil {
+		return fmt.Errorf("failed to list cards: %w", err)
+	}
+
+	return s.repo.UpsertSizes(ctx, sizes)
+}
+
+func nomenclatureSizes(nomenclature response.Nomenclature) []models.NomenclatureSize {
+	sizes := make([]models.NomenclatureSize, 0, len(nomenclature.Sizes))
+	for _, size := range nomenclature.Sizes {
+		sizes = append(sizes, models.NomenclatureSize{
+			ChrtID:   size.ChrtID,
+			NmID:     nomenclature.NmID,
+			TechSize: size.TechSize,
+			Skus:     size.Skus,
+		})
+	}
+	return sizes
+}
@@ -0,0 +1,180 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+	"gomarketplace_api/internal/wildberries/storage"
+	"gomarketplace_api/metrics"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runFlushSize - сколько исходов карточек копится в памяти перед записью в БД.
+const runFlushSize = 500
+
+// UpdateRun - запуск обновления карточек: операция, выборка, исход каждой карточки и итог.
+// Исходы сохраняются в wildberries.update_run_cards; без репозитория (dry-run) только считаются в Metrics.
+type UpdateRun struct {
+	ID        string
+	Operation string
+	metrics   *metrics.UpdateMetrics
+	repo      *storage.UpdateRunRepository
+	// preview - dry-run, в котором запуск запоминает карточки; они удаляются в Finish
+	preview *Preview
+	// changes - изменения карточек запуска; неотправленные удаляются в Finish
+	changes *CardChanges
+
+	mu      sync.Mutex
+	pending []models.UpdateRunCard
+	err     error
+}
+
+// startUpdateRun начинает запуск operation с выборкой settings (сохраняется как JSON).
+// preview - dry-run сервиса, nil без него; changes - изменения карточек сервиса.
+func startUpdateRun(repo *storage.UpdateRunRepository, preview *Preview, changes *CardChanges, operation string, settings interface{}) *UpdateRun {
+	startedAt := time.Now()
+	run := &UpdateRun{
+		ID:        fmt.Sprintf("%s-%s", operation, startedAt.Format("20060102-150405.000")),
+		Operation: operation,
+		metrics:   &metrics.UpdateMetrics{},
+		repo:      repo,
+		preview:   preview,
+		changes:   changes,
+	}
+	log.Printf("Update run %s started", run.ID)
+	if repo == nil {
+		return run
+	}
+
+	var settingsJSON json.RawMessage
+	if settings != nil {
+		data, err := json.Marshal(settings)
+		if err != nil {
+			log.Printf("Update run %s: failed to marshal settings: %s", run.ID, err)
+		}
+		settingsJSON = data
+	}
+	err := repo.StartRun(context.Background(), models.UpdateRun{
+		RunID:     run.ID,
+		Operation: operation,
+		Settings:  settingsJSON,
+		Status:    models.RunStatusRunning,
+		StartedAt: startedAt,
+	})
+	if err != nil {
+		log.Printf("Update run %s will not be saved: %s", run.ID, err)
+		run.repo = nil
+	}
+	return run
+}
+
+// Metrics возвращает счётчики запуска.
+func (r *UpdateRun) Metrics() *metrics.UpdateMetrics {
+	return r.metrics
+}
+
+// Record запоминает исход карточки nmID; errText - текст ошибки для OutcomeRejected.
+// Последующий исход той же карточки заменяет предыдущий.
+func (r *UpdateRun) Record(nmID int, vendorCode, outcome, errText string) {
+	if nmID == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.repo == nil {
+		return
+	}
+	r.pending = append(r.pending, models.UpdateRunCard{
+		RunID:      r.ID,
+		NmID:       nmID,
+		VendorCode: vendorCode,
+		Outcome:    outcome,
+		Error:      errText,
+		UpdatedAt:  time.Now(),
+	})
+	if len(r.pending) >= runFlushSize {
+		r.flush()
+	}
+}
+
+// RecordModels запоминает исход всех карточек запроса data (модель или срез моделей).
+func (r *UpdateRun) RecordModels(data interface{}, outcome, errText string) {
+	for _, item := range previewItems(data) {
+		nmID, vendorCode := modelIdentity(item)
+		r.Record(nmID, vendorCode, outcome, errText)
+	}
+}
+
+// Fail запоминает ошибку, не остановившую запуск (например, отклонённую WB пачку).
+// Finish без своей ошибки завершает запуск первой из них.
+func (r *UpdateRun) Fail(err error) {
+	if err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Finish сохраняет оставшиеся исходы и итог запуска: failed, если err или ошибка из Fail не nil.
+// Карточки, запомненные запуском в preview, и его неотправленные изменения удаляются.
+func (r *UpdateRun) Finish(err error) {
+	r.preview.Forget(r.ID)
+	r.changes.Discard(r.ID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		err = r.err
+	}
+	status, errText := models.RunStatusSuccess, ""
+	if err != nil {
+		status, errText = models.RunStatusFailed, err.Error()
+	}
+	log.Printf("Update run %s finished (%s): uploaded %d, processed %d, errored %d, unchanged %d",
+		r.ID, status, r.metrics.UpdatedCount.Load(), r.metrics.ProcessedCount.Load(),
+		r.metrics.ErroredNomenclatures.Load(), r.metrics.UnchangedCount.Load())
+
+	if r.repo == nil {
+		return
+	}
+	r.flush()
+	if err := r.repo.FinishRun(context.Background(), r.ID, status, errText); err != nil {
+		log.Printf("Update run %s: %s", r.ID, err)
+	}
+}
+
+// flush записывает накопленные исходы. Вызывается под r.mu, чтобы исходы карточки сохранялись по порядку.
+func (r *UpdateRun) flush() {
+	if len(r.pending) == 0 {
+		return
+	}
+	if err := r.repo.SaveCards(context.Background(), r.pending); err != nil {
+		log.Printf("Update run %s: failed to save card outcomes: %s", r.ID, err)
+	}
+	r.pending = nil
+}
+
+// wbErrorText возвращает текст ошибки WB из ответа body, если он есть, иначе err.
+func wbErrorText(err error, body []byte) string {
+	var errorResponse struct {
+		ErrorText        string      `json:"errorText"`
+		AdditionalErrors interface{} `json:"additionalErrors"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &errorResponse) != nil || errorResponse.ErrorText == "" {
+		return err.Error()
+	}
+	text := []string{errorResponse.ErrorText}
+	if errorResponse.AdditionalErrors != nil {
+		if additional, err := json.Marshal(errorResponse.AdditionalErrors); err == nil {
+			text = append(text, string(additional))
+		}
+	}
+	return strings.Join(text, ": ")
+}
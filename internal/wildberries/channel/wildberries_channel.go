@@ -0,0 +1,406 @@
+package channel
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/time/rate"
+	"gomarketplace_api/config"
+	coremodels "gomarketplace_api/internal/core/models"
+	coreservices "gomarketplace_api/internal/core/services"
+	corestorage "gomarketplace_api/internal/core/storage"
+	registry "gomarketplace_api/internal/registry/business"
+	registrystorage "gomarketplace_api/internal/registry/storage"
+	"gomarketplace_api/internal/wildberries/business/models/dto/request"
+	"gomarketplace_api/internal/wildberries/business/models/dto/response"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+	"gomarketplace_api/internal/wildberries/business/services"
+	"gomarketplace_api/internal/wildberries/business/services/get"
+	"gomarketplace_api/internal/wildberries/business/services/parse"
+	"gomarketplace_api/internal/wildberries/business/services/update"
+	"gomarketplace_api/internal/wildberries/storage"
+	"gomarketplace_api/pkg/business/service"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ChannelName - имя площадки, под которым канал WB фигурирует в core.
+const ChannelName = "wildberries"
+
+const (
+	stocksUrl = "https://marketplace-api.wildberries.ru/api/v3/stocks/%d"
+	pricesUrl = "https://discounts-prices-api.wildberries.ru/api/v2/upload/task"
+
+	cardsPageLimit      = 100
+	categoriesPageLimit = 1000
+	cardsBatchSize      = 100
+	stocksBatchSize     = 1000
+	pricesBatchSize     = 1000
+)
+
+var _ coreservices.Channel = (*WildberriesChannel)(nil)
+
+// WildberriesChannel реализует core-канал поверх сервисов Content API WB.
+type WildberriesChannel struct {
+	auth        services.AuthEngine
+	search      *get.SearchEngine
+	categories  *get.CategoriesEngine
+	cardService *update.CardService
+	// cardUpdater - обновление контента существующих карточек
+	cardUpdater *update.CardUpdateService
+	articular   *registry.ArticularService
+	warehouseID int64
+	limiter     *rate.Limiter
+	// Marketplace API лимитируется отдельно от Content API
+	marketplaceLimiter *rate.Limiter
+	pricesLimiter      *rate.Limiter
+	client             *http.Client
+	log                logger.Logger
+}
+
+func NewWildberriesChannel(db *sql.DB, wbConfig config.WildberriesConfig, wsClientUrl string, writer io.Writer) (*WildberriesChannel, error) {
+	bearer := services.NewBearerAuth(wbConfig.ApiKey)
+	if bearer == nil {
+		return nil, errors.New("wildberries api key is not configured")
+	}
+
+	searchConfig := get.Config{
+		WorkerCount:    get.WorkerCount,
+		MaxRetries:     get.MaxRetries,
+		RetryInterval:  get.RetryInterval,
+		RequestTimeout: get.RequestTimeout,
+	}
+	articular := registry.NewArticularService(registrystorage.NewArticularRepository(db), wbConfig.WbIdentity.Code, wbConfig.WbIdentity.Supplier)
+	cardService := update.NewCardService(wsClientUrl, service.NewTextService(), writer, wbConfig, articular)
+	if cardService == nil {
+		return nil, errors.New("failed to create wildberries card service")
+	}
+	cardService.SetProductGroups(corestorage.NewProductGroupRepository(db))
+
+	search := get.NewSearchEngine(db, bearer, writer, searchConfig, articular)
+	search.SetHistoryRepository(storage.NewNomenclatureHistoryRepository(db))
+
+	cardUpdater := update.NewCardUpdateService(search, service.NewTextService(), wsClientUrl, bearer, writer,
+		parse.NewBrandServiceWildberries(wbConfig.WbBanned.BannedBrands), wbConfig.WbValues, articular)
+	cardUpdater.SetChangeRepository(storage.NewChangeRepository(db))
+	cardUpdater.SetHistoryRepository(storage.NewNomenclatureHistoryRepository(db))
+	cardUpdater.SetRunRepository(storage.NewUpdateRunRepository(db))
+
+	return &WildberriesChannel{
+		auth:        bearer,
+		search:      search,
+		categories:  get.NewCategoriesService(bearer),
+		cardService: cardService,
+		cardUpdater: cardUpdater,
+		articular:   articular,
+		warehouseID: wbConfig.WarehouseID,
+		// Content API: 100 запросов в минуту
+		limiter: rate.NewLimiter(rate.Every(time.Minute/100), 5),
+		// Marketplace API: 300 запросов в минуту
+		marketplaceLimiter: rate.NewLimiter(rate.Every(time.Minute/300), 10),
+		// Prices API: 10 запросов за 6 секунд
+		pricesLimiter: rate.NewLimiter(rate.Every(600*time.Millisecond), 10),
+		client:        &http.Client{Timeout: 60 * time.Second},
+		log:           logger.NewLogger(writer, "[WildberriesChannel]"),
+	}, nil
+}
+
+// SetPreview включает режим dry-run для CreateCards и UpdateContent: карточки записываются в preview и не
+// отправляются в WB, запуски обновления не сохраняются.
+func (c *WildberriesChannel) SetPreview(preview *update.Preview) {
+	c.cardService.SetPreview(preview)
+	c.cardUpdater.SetPreview(preview)
+	if preview != nil {
+		c.cardUpdater.SetRunRepository(nil)
+	}
+}
+
+func (c *WildberriesChannel) Name() string {
+	return ChannelName
+}
+
+// ListCards постранично обходит карточки продавца по курсору Content API.
+func (c *WildberriesChannel) ListCards(ctx context.Context, cardCh chan<- coremodels.ChannelCard) error {
+	defer close(cardCh)
+
+	settings := request.Settings{
+		Sort:   request.Sort{Ascending: false},
+		Filter: request.Filter{WithPhoto: -1, TagIDs: []int{}, ObjectIDs: []int{}, Brands: []string{}},
+		Cursor: request.Cursor{Limit: cardsPageLimit},
+	}
+	for {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("rate limiter error: %w", err)
+		}
+		page, err := c.search.GetNomenclatures(ctx, settings, "")
+		if err != nil {
+			return fmt.Errorf("failed to list wildberries cards: %w", err)
+		}
+
+		vendorCodes := make([]string, len(page.Data))
+		for i, nomenclature := range page.Data {
+			vendorCodes[i] = nomenclature.VendorCode
+		}
+		globalIDs, err := c.articular.SupplierArticuls(vendorCodes)
+		if err != nil {
+			return fmt.Errorf("failed to resolve wildberries vendor codes: %w", err)
+		}
+
+		for _, nomenclature := range page.Data {
+			card, err := toChannelCard(nomenclature, globalIDs[nomenclature.VendorCode])
+			if err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case cardCh <- card:
+			}
+		}
+
+		if len(page.Data) < cardsPageLimit {
+			return nil
+		}
+		settings.Cursor = request.Cursor{
+			Limit:     cardsPageLimit,
+			UpdatedAt: page.Cursor.UpdatedAt,
+			NmID:      page.Cursor.NmID,
+		}
+	}
+}
+
+// CreateCards собирает карточки через CardService и отправляет их в /content/v2/cards/upload.
+func (c *WildberriesChannel) CreateCards(ctx context.Context, categoryID int, globalIDs []int) (int, error) {
+	prepared, err := c.cardService.PrepareAndUpload(ctx, globalIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare wildberries cards: %w", err)
+	}
+	cards, ok := prepared.([]request.CreateCardRequestData)
+	if !ok {
+		return 0, fmt.Errorf("unexpected prepared cards type %T", prepared)
+	}
+
+	created := 0
+	for start := 0; start < len(cards); start += cardsBatchSize {
+		end := min(start+cardsBatchSize, len(cards))
+
+		batch := make([]request.CreateCardRequestWrapper, 0, end-start)
+		for _, card := range cards[start:end] {
+			batch = append(batch, request.CreateCardRequestWrapper{
+				SubjectID: categoryID,
+				Variants:  []request.CreateCardRequestData{card},
+			})
+		}
+
+		if err := c.limiter.Wait(ctx); err != nil {
+			return created, fmt.Errorf("rate limiter error: %w", err)
+		}
+		if _, _, err := c.cardService.SendToServerModels(batch); err != nil {
+			return created, fmt.Errorf("failed to upload wildberries cards: %w", err)
+		}
+		created += len(batch)
+	}
+
+	c.log.Log("Category %d: sent %d cards", categoryID, created)
+	return created, nil
+}
+
+// UpdateContent обновляет наименование, описание и бренд карточек через CardUpdateService: карточки без
+// изменений не отправляются, в режиме dry-run запросы записываются в preview. Card.Raw - карточка из ListCards.
+func (c *WildberriesChannel) UpdateContent(ctx context.Context, updates []coremodels.ContentUpdate) (int, error) {
+	changes := make([]update.ContentChange, 0, len(updates))
+	for _, upd := range updates {
+		if len(upd.Card.Raw) == 0 {
+			c.log.Log("Skipping card %s: no source card data", upd.Card.VendorCode)
+			continue
+		}
+		var nomenclature response.Nomenclature
+		if err := json.Unmarshal(upd.Card.Raw, &nomenclature); err != nil {
+			return 0, fmt.Errorf("failed to decode card %s: %w", upd.Card.VendorCode, err)
+		}
+		changes = append(changes, update.ContentChange{
+			Nomenclature: nomenclature,
+			Title:        upd.Title,
+			Description:  upd.Description,
+			Brand:        upd.Brand,
+		})
+	}
+
+	updated, err := c.cardUpdater.UpdateCardContent(ctx, changes)
+	if err != nil {
+		return updated, fmt.Errorf("failed to update wildberries cards: %w", err)
+	}
+	return updated, nil
+}
+
+// PushPrices загружает цены и скидки. ExternalID - nmID карточки, Price - цена до скидки,
+// DiscountedPrice - цена продажи, из которой считается скидка в процентах.
+func (c *WildberriesChannel) PushPrices(ctx context.Context, prices []coremodels.PriceUpdate) (*coremodels.PushResult, error) {
+	result := coremodels.NewPushResult()
+	for start := 0; start < len(prices); start += pricesBatchSize {
+		end := min(start+pricesBatchSize, len(prices))
+
+		items := make([]request.PriceItem, 0, end-start)
+		for _, price := range prices[start:end] {
+			nmID, err := strconv.Atoi(price.ExternalID)
+			if err != nil {
+				result.Failed[price.ExternalID] = "invalid nmID"
+				continue
+			}
+			wbPrice, discount := models.PriceAndDiscount(price.Price, price.DiscountedPrice)
+			items = append(items, request.PriceItem{NmID: nmID, Price: wbPrice, Discount: discount})
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		if err := c.pricesLimiter.Wait(ctx); err != nil {
+			return result, fmt.Errorf("rate limiter error: %w", err)
+		}
+		body, _, err := c.send(ctx, http.MethodPost, pricesUrl, request.PricesUploadRequest{Data: items})
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+			var uploadResponse response.PricesUploadResponse
+			if json.Unmarshal(body, &uploadResponse) == nil && uploadResponse.ErrorText != "" {
+				reason = uploadResponse.ErrorText
+			}
+		}
+		for _, item := range items {
+			if reason != "" {
+				result.Failed[strconv.Itoa(item.NmID)] = reason
+			} else {
+				result.Changed++
+			}
+		}
+	}
+	return result, nil
+}
+
+// PushStocks выгружает остатки на склад продавца из конфигурации. SKU - баркод размера.
+// Баркоды, отклонённые WB (ответ 409), попадают в Failed, остальные отправляются повторно.
+func (c *WildberriesChannel) PushStocks(ctx context.Context, stocks []coremodels.StockUpdate) (*coremodels.PushResult, error) {
+	if c.warehouseID == 0 {
+		return nil, errors.New("wildberries warehouse_id is not configured")
+	}
+
+	result := coremodels.NewPushResult()
+	url := fmt.Sprintf(stocksUrl, c.warehouseID)
+	for start := 0; start < len(stocks); start += stocksBatchSize {
+		end := min(start+stocksBatchSize, len(stocks))
+
+		items := make([]request.StockItem, 0, end-start)
+		for _, stock := range stocks[start:end] {
+			items = append(items, request.StockItem{Sku: stock.SKU, Amount: max(stock.Quantity, 0)})
+		}
+
+		if err := c.pushStocksBatch(ctx, url, items, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (c *WildberriesChannel) pushStocksBatch(ctx context.Context, url string, items []request.StockItem, result *coremodels.PushResult) error {
+	if err := c.marketplaceLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limiter error: %w", err)
+	}
+
+	body, status, err := c.send(ctx, http.MethodPut, url, request.StocksRequest{Stocks: items})
+	if err == nil {
+		result.Changed += len(items)
+		return nil
+	}
+	if status != http.StatusConflict {
+		for _, item := range items {
+			result.Failed[item.Sku] = err.Error()
+		}
+		return nil
+	}
+
+	var stockErrors []response.StockError
+	if jsonErr := json.Unmarshal(body, &stockErrors); jsonErr != nil {
+		for _, item := range items {
+			result.Failed[item.Sku] = err.Error()
+		}
+		return nil
+	}
+
+	rejected := make(map[string]struct{})
+	for _, stockErr := range stockErrors {
+		for _, data := range stockErr.Data {
+			rejected[data.Sku] = struct{}{}
+			result.Failed[data.Sku] = fmt.Sprintf("%s: %s", stockErr.Code, stockErr.Message)
+		}
+	}
+	retry := make([]request.StockItem, 0, len(items))
+	for _, item := range items {
+		if _, ok := rejected[item.Sku]; !ok {
+			retry = append(retry, item)
+		}
+	}
+	// если WB не указал конкретные баркоды, повторять бессмысленно
+	if len(rejected) == 0 || len(retry) == 0 {
+		for _, item := range retry {
+			result.Failed[item.Sku] = err.Error()
+		}
+		return nil
+	}
+	return c.pushStocksBatch(ctx, url, retry, result)
+}
+
+// Categories загружает все предметы WB постранично.
+func (c *WildberriesChannel) Categories(ctx context.Context) ([]coremodels.ChannelCategory, error) {
+	var result []coremodels.ChannelCategory
+	for offset := 0; ; offset += categoriesPageLimit {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("rate limiter error: %w", err)
+		}
+		page, err := c.categories.GetCategoriesRequestWildberries("", "ru", categoriesPageLimit, offset, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wildberries categories: %w", err)
+		}
+		for _, category := range page.Data {
+			result = append(result, coremodels.ChannelCategory{
+				ID:         category.SubjectID,
+				ParentID:   category.ParentID,
+				Name:       category.SubjectName,
+				ParentName: category.ParentName,
+			})
+		}
+		if len(page.Data) < categoriesPageLimit {
+			return result, nil
+		}
+	}
+}
+
+// toChannelCard переводит карточку WB в карточку канала; globalID - артикул wholesaler, 0 у карточек
+// других поставщиков и чужих карточек.
+func toChannelCard(nomenclature response.Nomenclature, globalID int) (coremodels.ChannelCard, error) {
+	raw, err := json.Marshal(nomenclature)
+	if err != nil {
+		return coremodels.ChannelCard{}, fmt.Errorf("failed to encode card %d: %w", nomenclature.NmID, err)
+	}
+
+	var barcodes []string
+	for _, size := range nomenclature.Sizes {
+		barcodes = append(barcodes, size.Skus...)
+	}
+	return coremodels.ChannelCard{
+		Channel:    ChannelName,
+		ExternalID: strconv.Itoa(nomenclature.NmID),
+		VendorCode: nomenclature.VendorCode,
+		GlobalID:   globalID,
+		CategoryID: nomenclature.SubjectID,
+		Title:      nomenclature.Title,
+		Brand:      nomenclature.Brand,
+		Barcodes:   barcodes,
+		UpdatedAt:  nomenclature.UpdatedAt,
+		Raw:        raw,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"gomarketplace_api/config"
+	coremodels "gomarketplace_api/internal/core/models"
+	coreservices "gomarketplace_api/internal/core/services"
+	"gomarketplace_api/internal/wildberries/business/models/dto/response"
+	"gomarketplace_api/internal/wildberries/business/services/update"
+	"io"
+	"testing"
+)
+
+func channelCard(t *testing.T, nomenclature response.Nomenclature) coremodels.ChannelCard {
+	t.Helper()
+	card, err := toChannelCard(nomenclature, 0)
+	if err != nil {
+		t.Fatalf("toChannelCard: %v", err)
+	}
+	return card
+}
+
+func TestUpdateContentPreview(t *testing.T) {
+	wbChannel, err := NewWildberriesChannel(nil, config.WildberriesConfig{ApiKey: "key"}, "http://localhost:0", io.Discard)
+	if err != nil {
+		t.Fatalf("NewWildberriesChannel: %v", err)
+	}
+	sink := update.NewMemoryPreviewSink(10)
+	wbChannel.SetPreview(update.NewPreview(sink))
+
+	changed := response.Nomenclature{NmID: 1, VendorCode: "id-1-1366", Title: "Старое", Brand: "Brand"}
+	unchanged := response.Nomenclature{NmID: 2, VendorCode: "id-2-1366", Title: "Товар", Brand: "Brand"}
+
+	var ch coreservices.Channel = wbChannel
+	updated, err := ch.UpdateContent(context.Background(), []coremodels.ContentUpdate{
+		{Card: channelCard(t, changed), Title: "Новое"},
+		{Card: channelCard(t, unchanged), Title: "Товар", Brand: "Brand"},
+		{Card: coremodels.ChannelCard{VendorCode: "id-3-1366"}, Title: "Без карточки"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateContent: %v", err)
+	}
+	if updated != 1 {
+		t.Fatalf("updated = %d, want 1 (unchanged cards and cards without data are skipped)", updated)
+	}
+
+	records := sink.Records(0, false)
+	if len(records) != 1 || records[0].NmID != 1 {
+		t.Fatalf("preview records = %+v, want card 1 only", records)
+	}
+	if len(records[0].Diff) != 1 || records[0].Diff[0].Field != "title" || records[0].Diff[0].New != "Новое" {
+		t.Errorf("diff = %+v, want title change", records[0].Diff)
+	}
+	var payload struct {
+		Title string `json:"title"`
+		Brand string `json:"brand"`
+	}
+	if err := json.Unmarshal(records[0].Payload, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.Title != "Новое" || payload.Brand != "Brand" {
+		t.Errorf("payload = %+v, want new title with the card's brand", payload)
+	}
+}
@@ -40,6 +40,8 @@ func registerClients(fetcherChain *pkg2.FetcherChain, host string, writer io.Wri
 		{"brands", clients2.NewBrandsClient(host, writer)},
 		{"barcodes", clients2.NewBarcodesClient(host, writer)},
 		{"media", clients2.NewImageClient(host, writer)},
+		{"stocks", clients2.NewStocksClient(host, writer)},
+		{"quality", clients2.NewQualityClient(host, writer)},
 	}
 
 	for _, client := range clientsToRegister {
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+)
+
+type ChangeRepository struct {
+	db *sql.DB
+}
+
+func NewChangeRepository(db *sql.DB) *ChangeRepository {
+	return &ChangeRepository{db: db}
+}
+
+// SaveChanges сохраняет отправленные в WB обновления карточек: запрос - новой версией
+// в wildberries.cards_history вместе с последней версией nomenclatures_history, к которой он применён,
+// описания изменений - в wildberries.changes с той же версией. Карточки, которых нет
+// в wildberries.nomenclatures, не сохраняются.
+func (r *ChangeRepository) SaveChanges(ctx context.Context, changes []models.CardChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	historyStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.cards_history (global_id, nm_id, vendor_code, version, version_data, run_id, base_version)
+		SELECT
+			(SELECT global_id FROM wildberries.nomenclatures WHERE nm_id = $1::int),
+			$1::int, $2::text,
+			COALESCE((SELECT MAX(version) FROM wildberries.cards_history WHERE nm_id = $1::int), 0) + 1,
+			$3::jsonb, $4::text,
+			(SELECT MAX(version) FROM wildberries.nomenclatures_history WHERE nm_id = $1::int)
+		WHERE EXISTS (SELECT 1 FROM wildberries.nomenclatures WHERE nm_id = $1::int AND vendor_code = $2::text)
+		RETURNING version`)
+	if err != nil {
+		return fmt.Errorf("prepare cards history insert error: %w", err)
+	}
+	defer historyStmt.Close()
+
+	changeStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.changes (nm_id, vendor_code, version, description, changed_at)
+		VALUES ($1, $2, $3, $4, NOW())`)
+	if err != nil {
+		return fmt.Errorf("prepare changes insert error: %w", err)
+	}
+	defer changeStmt.Close()
+
+	for _, change := range changes {
+		var version int
+		err := historyStmt.QueryRowContext(ctx, change.NmID, change.VendorCode, string(change.Payload), change.RunID).Scan(&version)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // карточки ещё нет в wildberries.nomenclatures
+		}
+		if err != nil {
+			return fmt.Errorf("save history of card %d error: %w", change.NmID, err)
+		}
+		for _, description := range change.Description {
+			if _, err := changeStmt.ExecContext(ctx, change.NmID, change.VendorCode, version, description); err != nil {
+				return fmt.Errorf("save change of card %d error: %w", change.NmID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// GetRecentPayloadsByCards возвращает до limit последних отправленных в WB запросов по каждой карточке nmIDs,
+// начиная с последнего. Карточек без истории в ответе нет.
+func (r *ChangeRepository) GetRecentPayloadsByCards(ctx context.Context, nmIDs []int, limit int) (map[int][]json.RawMessage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT nm_id, version_data
+		FROM (
+			SELECT nm_id, version_data, ROW_NUMBER() OVER (PARTITION BY nm_id ORDER BY version DESC) AS rn
+			FROM wildberries.cards_history
+			WHERE nm_id = ANY($1)
+		) recent
+		WHERE rn <= $2
+		ORDER BY nm_id, rn`, pq.Array(nmIDs), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса истории карточек: %w", err)
+	}
+	defer rows.Close()
+
+	payloads := make(map[int][]json.RawMessage)
+	for rows.Next() {
+		var nmID int
+		var payload []byte
+		if err := rows.Scan(&nmID, &payload); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования версии карточки: %w", err)
+		}
+		payloads[nmID] = append(payloads[nmID], payload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return payloads, nil
+}
+
+// GetRunVersions возвращает для карточек запуска runID версии nomenclatures_history,
+// к которым были применены обновления запуска, - состояние карточек до запуска.
+// У карточек без сохранённой версии Version = 0.
+func (r *ChangeRepository) GetRunVersions(ctx context.Context, runID string) ([]models.CardVersion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT nm_id, MIN(base_version)
+		FROM wildberries.cards_history
+		WHERE run_id = $1
+		GROUP BY nm_id
+		ORDER BY nm_id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса версий запуска %s: %w", runID, err)
+	}
+	defer rows.Close()
+
+	var versions []models.CardVersion
+	for rows.Next() {
+		var version models.CardVersion
+		var baseVersion sql.NullInt64
+		if err := rows.Scan(&version.NmID, &baseVersion); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования версии карточки: %w", err)
+		}
+		version.Version = int(baseVersion.Int64)
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return versions, nil
+}
+
+// GetRunPayloads возвращает запросы, отправленные в WB в запуске runID.
+func (r *ChangeRepository) GetRunPayloads(ctx context.Context, runID string) ([]json.RawMessage, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT version_data
+		FROM wildberries.cards_history
+		WHERE run_id = $1
+		ORDER BY nm_id, version`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса истории запуска %s: %w", runID, err)
+	}
+	defer rows.Close()
+
+	var payloads []json.RawMessage
+	for rows.Next() {
+		var payload []byte
+		if err := rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования версии карточки: %w", err)
+		}
+		payloads = append(payloads, payload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return payloads, nil
+}
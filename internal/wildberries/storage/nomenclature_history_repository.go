@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gomarketplace_api/internal/wildberries/business/models/dto/response"
+)
+
+// ErrVersionNotFound - запрошенной версии карточки нет в wildberries.nomenclatures_history.
+var ErrVersionNotFound = errors.New("card version not found")
+
+// NomenclatureHistoryRepository хранит версии карточек, полученных из WB:
+// wildberries.nomenclatures_history - все версии, wildberries.cards_actual - последняя.
+type NomenclatureHistoryRepository struct {
+	db *sql.DB
+}
+
+func NewNomenclatureHistoryRepository(db *sql.DB) *NomenclatureHistoryRepository {
+	return &NomenclatureHistoryRepository{db: db}
+}
+
+// SaveSnapshots сохраняет карточки новыми версиями, если они отличаются от последней сохранённой,
+// и обновляет wildberries.cards_actual. Карточки, которых нет в wildberries.nomenclatures, пропускаются.
+func (r *NomenclatureHistoryRepository) SaveSnapshots(ctx context.Context, nomenclatures []response.Nomenclature) error {
+	if len(nomenclatures) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	historyStmt, err := tx.PrepareContext(ctx, `
+		WITH latest AS (
+			SELECT version, version_data
+			FROM wildberries.nomenclatures_history
+			WHERE nm_id = $1::int
+			ORDER BY version DESC
+			LIMIT 1
+		)
+		INSERT INTO wildberries.nomenclatures_history (global_id, nm_id, vendor_code, version, version_data)
+		SELECT n.global_id, n.nm_id, n.vendor_code, COALESCE((SELECT version FROM latest), 0) + 1, $2::jsonb
+		FROM wildberries.nomenclatures n
+		WHERE n.nm_id = $1::int
+		  AND NOT EXISTS (SELECT 1 FROM latest WHERE version_data = $2::jsonb)`)
+	if err != nil {
+		return fmt.Errorf("prepare nomenclatures history insert error: %w", err)
+	}
+	defer historyStmt.Close()
+
+	actualStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.cards_actual (global_id, nm_id, vendor_code, version, version_data)
+		SELECT global_id, nm_id, vendor_code, version, version_data
+		FROM wildberries.nomenclatures_history
+		WHERE nm_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+		ON CONFLICT (nm_id) DO UPDATE
+		SET global_id = EXCLUDED.global_id,
+		    vendor_code = EXCLUDED.vendor_code,
+		    version = EXCLUDED.version,
+		    version_data = EXCLUDED.version_data,
+		    updated_at = NOW()
+		WHERE cards_actual.version <> EXCLUDED.version`)
+	if err != nil {
+		return fmt.Errorf("prepare cards actual upsert error: %w", err)
+	}
+	defer actualStmt.Close()
+
+	for _, nomenclature := range nomenclatures {
+		data, err := json.Marshal(nomenclature)
+		if err != nil {
+			return fmt.Errorf("marshal card %d error: %w", nomenclature.NmID, err)
+		}
+		result, err := historyStmt.ExecContext(ctx, nomenclature.NmID, string(data))
+		if err != nil {
+			return fmt.Errorf("save version of card %d error: %w", nomenclature.NmID, err)
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 0 {
+			continue
+		}
+		if _, err := actualStmt.ExecContext(ctx, nomenclature.NmID); err != nil {
+			return fmt.Errorf("save actual card %d error: %w", nomenclature.NmID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// GetVersion возвращает карточку nmID версии version.
+func (r *NomenclatureHistoryRepository) GetVersion(ctx context.Context, nmID, version int) (*response.Nomenclature, error) {
+	return r.scanNomenclature(r.db.QueryRowContext(ctx, `
+		SELECT version_data FROM wildberries.nomenclatures_history
+		WHERE nm_id = $1 AND version = $2`, nmID, version))
+}
+
+func (r *NomenclatureHistoryRepository) scanNomenclature(row *sql.Row) (*response.Nomenclature, error) {
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVersionNotFound
+		}
+		return nil, fmt.Errorf("ошибка чтения версии карточки: %w", err)
+	}
+
+	var nomenclature response.Nomenclature
+	if err := json.Unmarshal(data, &nomenclature); err != nil {
+		return nil, fmt.Errorf("ошибка разбора версии карточки: %w", err)
+	}
+	return &nomenclature, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+)
+
+type PriceRepository struct {
+	db *sql.DB
+}
+
+func NewPriceRepository(db *sql.DB) *PriceRepository {
+	return &PriceRepository{db: db}
+}
+
+// GetPriceTargets возвращает наши карточки WB с последними выгруженными ценами.
+func (r *PriceRepository) GetPriceTargets(ctx context.Context) ([]models.PriceTarget, error) {
+	query := `
+		SELECT n.global_id, n.nm_id, p.price, p.discount
+		FROM wildberries.nomenclatures n
+		LEFT JOIN wildberries.prices_pushed p ON p.nm_id = n.nm_id
+		WHERE n.global_id IS NOT NULL AND n.nm_id IS NOT NULL`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения цен WB: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []models.PriceTarget
+	for rows.Next() {
+		var target models.PriceTarget
+		var price, discount sql.NullInt64
+		if err := rows.Scan(&target.GlobalID, &target.NmID, &price, &discount); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования цены WB: %w", err)
+		}
+		if price.Valid && discount.Valid {
+			lastPrice, lastDiscount := int(price.Int64), int(discount.Int64)
+			target.LastPrice, target.LastDiscount = &lastPrice, &lastDiscount
+		}
+		targets = append(targets, target)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return targets, nil
+}
+
+// SavePushed запоминает успешно выгруженные цены.
+func (r *PriceRepository) SavePushed(ctx context.Context, prices []models.PricePushed) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.prices_pushed (nm_id, global_id, price, discount, pushed_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (nm_id) DO UPDATE
+		SET global_id = EXCLUDED.global_id,
+		    price = EXCLUDED.price,
+		    discount = EXCLUDED.discount,
+		    pushed_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare pushed prices insert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, price := range prices {
+		if _, err := stmt.ExecContext(ctx, price.NmID, price.GlobalID, price.Price, price.Discount); err != nil {
+			return fmt.Errorf("save pushed price %d error: %w", price.NmID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
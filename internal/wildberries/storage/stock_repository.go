@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+)
+
+type StockRepository struct {
+	db *sql.DB
+}
+
+func NewStockRepository(db *sql.DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+// UpsertSizes сохраняет размеры карточек и их баркоды.
+func (r *StockRepository) UpsertSizes(ctx context.Context, sizes []models.NomenclatureSize) error {
+	if len(sizes) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.nomenclature_sizes (chrt_id, nm_id, tech_size, skus, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (chrt_id) DO UPDATE
+		SET nm_id = EXCLUDED.nm_id,
+		    tech_size = EXCLUDED.tech_size,
+		    skus = EXCLUDED.skus,
+		    updated_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare sizes upsert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, size := range sizes {
+		if _, err := stmt.ExecContext(ctx, size.ChrtID, size.NmID, size.TechSize, pq.Array(size.Skus)); err != nil {
+			return fmt.Errorf("upsert size %d error: %w", size.ChrtID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// SyncSizesFromCards сохраняет размеры карточек wildberries.nomenclatures из их последних версий
+// в wildberries.cards_actual, которые обновляет синхронизация карточек.
+func (r *StockRepository) SyncSizesFromCards(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO wildberries.nomenclature_sizes (chrt_id, nm_id, tech_size, skus, updated_at)
+		SELECT (size->>'chrtID')::int, a.nm_id, COALESCE(size->>'techSize', ''),
+		       ARRAY(SELECT jsonb_array_elements_text(
+		           CASE WHEN jsonb_typeof(size->'skus') = 'array' THEN size->'skus' ELSE '[]'::jsonb END)),
+		       NOW()
+		FROM wildberries.cards_actual a
+		JOIN wildberries.nomenclatures n ON n.nm_id = a.nm_id
+		CROSS JOIN LATERAL jsonb_array_elements(
+		    CASE WHEN jsonb_typeof(a.version_data->'sizes') = 'array' THEN a.version_data->'sizes' ELSE '[]'::jsonb END) AS size
+		WHERE n.global_id IS NOT NULL AND (size->>'chrtID') IS NOT NULL
+		ON CONFLICT (chrt_id) DO UPDATE
+		SET nm_id = EXCLUDED.nm_id,
+		    tech_size = EXCLUDED.tech_size,
+		    skus = EXCLUDED.skus,
+		    updated_at = NOW()
+		WHERE nomenclature_sizes.nm_id <> EXCLUDED.nm_id
+		   OR nomenclature_sizes.tech_size IS DISTINCT FROM EXCLUDED.tech_size
+		   OR nomenclature_sizes.skus <> EXCLUDED.skus`)
+	if err != nil {
+		return fmt.Errorf("sync sizes from cards error: %w", err)
+	}
+	return nil
+}
+
+// GetCardsWithoutSizes возвращает nmID карточек wildberries.nomenclatures, размеров которых ещё нет.
+func (r *StockRepository) GetCardsWithoutSizes(ctx context.Context) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT n.nm_id
+		FROM wildberries.nomenclatures n
+		WHERE n.global_id IS NOT NULL AND n.nm_id IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM wildberries.nomenclature_sizes s WHERE s.nm_id = n.nm_id)
+		ORDER BY n.nm_id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса карточек без размеров: %w", err)
+	}
+	defer rows.Close()
+
+	var nmIDs []int
+	for rows.Next() {
+		var nmID int
+		if err := rows.Scan(&nmID); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования карточки: %w", err)
+		}
+		nmIDs = append(nmIDs, nmID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return nmIDs, nil
+}
+
+// GetStockTargets сопоставляет global_id -> nmID -> chrtID -> баркод через wildberries.nomenclatures
+// и размеры карточек, подтягивая остатки wholesaler и последний выгруженный на склад остаток.
+func (r *StockRepository) GetStockTargets(ctx context.Context, warehouseID int64) ([]models.StockTarget, error) {
+	query := `
+		SELECT n.global_id, n.nm_id, s.chrt_id, sku.value, COALESCE(ws.stocks, 0), p.amount
+		FROM wildberries.nomenclature_sizes s
+		JOIN wildberries.nomenclatures n ON n.nm_id = s.nm_id
+		CROSS JOIN LATERAL unnest(s.skus) AS sku(value)
+		LEFT JOIN wholesaler.stocks ws ON ws.global_id = n.global_id
+		LEFT JOIN wildberries.stocks_pushed p ON p.warehouse_id = $1 AND p.sku = sku.value
+		WHERE n.global_id IS NOT NULL AND sku.value <> ''`
+
+	rows, err := r.db.QueryContext(ctx, query, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения остатков WB: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []models.StockTarget
+	for rows.Next() {
+		var target models.StockTarget
+		var lastPushed sql.NullInt64
+		if err := rows.Scan(&target.GlobalID, &target.NmID, &target.ChrtID, &target.Sku, &target.Stocks, &lastPushed); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования остатка WB: %w", err)
+		}
+		if lastPushed.Valid {
+			amount := int(lastPushed.Int64)
+			target.LastPushed = &amount
+		}
+		targets = append(targets, target)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return targets, nil
+}
+
+// SavePushed запоминает успешно выгруженные остатки: sku -> amount.
+func (r *StockRepository) SavePushed(ctx context.Context, warehouseID int64, amounts map[string]int) error {
+	if len(amounts) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.stocks_pushed (warehouse_id, sku, amount, pushed_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (warehouse_id, sku) DO UPDATE
+		SET amount = EXCLUDED.amount, pushed_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare pushed stocks insert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for sku, amount := range amounts {
+		if _, err := stmt.ExecContext(ctx, warehouseID, sku, amount); err != nil {
+			return fmt.Errorf("save pushed stock %s error: %w", sku, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	models "gomarketplace_api/internal/wildberries/business/models/get"
+)
+
+// ErrRunNotFound - запуска нет в wildberries.update_runs.
+var ErrRunNotFound = errors.New("update run not found")
+
+// failureOutcomes - исходы карточек, которые считаются ошибками запуска.
+var failureOutcomes = []string{models.OutcomeSkippedInvalid, models.OutcomeRejected, models.OutcomeBanned}
+
+type UpdateRunRepository struct {
+	db *sql.DB
+}
+
+func NewUpdateRunRepository(db *sql.DB) *UpdateRunRepository {
+	return &UpdateRunRepository{db: db}
+}
+
+// StartRun сохраняет начатый запуск.
+func (r *UpdateRunRepository) StartRun(ctx context.Context, run models.UpdateRun) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO wildberries.update_runs (run_id, operation, settings, status, started_at)
+		VALUES ($1, $2, $3::jsonb, $4, $5)`,
+		run.RunID, run.Operation, nullableJSON(run.Settings), run.Status, run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("save update run %s error: %w", run.RunID, err)
+	}
+	return nil
+}
+
+// FinishRun сохраняет статус и ошибку завершённого запуска.
+func (r *UpdateRunRepository) FinishRun(ctx context.Context, runID, status, runErr string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE wildberries.update_runs
+		SET status = $2, error = NULLIF($3, ''), finished_at = NOW()
+		WHERE run_id = $1`, runID, status, runErr)
+	if err != nil {
+		return fmt.Errorf("finish update run %s error: %w", runID, err)
+	}
+	return nil
+}
+
+// SaveCards сохраняет исходы карточек. Для карточки хранится последний исход запуска.
+func (r *UpdateRunRepository) SaveCards(ctx context.Context, cards []models.UpdateRunCard) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.update_run_cards (run_id, nm_id, vendor_code, outcome, error, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6)
+		ON CONFLICT (run_id, nm_id) DO UPDATE
+		SET vendor_code = COALESCE(EXCLUDED.vendor_code, update_run_cards.vendor_code),
+		    outcome = EXCLUDED.outcome,
+		    error = EXCLUDED.error,
+		    updated_at = EXCLUDED.updated_at`)
+	if err != nil {
+		return fmt.Errorf("prepare update run cards insert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, card := range cards {
+		if _, err := stmt.ExecContext(ctx, card.RunID, card.NmID, card.VendorCode, card.Outcome, card.Error, card.UpdatedAt); err != nil {
+			return fmt.Errorf("save outcome of card %d error: %w", card.NmID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// ListRuns возвращает последние limit запусков с числом карточек по исходам.
+func (r *UpdateRunRepository) ListRuns(ctx context.Context, limit int) ([]models.UpdateRun, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT run_id, operation, COALESCE(settings::text, ''), status, COALESCE(error, ''), started_at, finished_at
+		FROM wildberries.update_runs
+		ORDER BY started_at DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса запусков обновления: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.UpdateRun{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var run models.UpdateRun
+		var settings string
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.RunID, &run.Operation, &settings, &run.Status, &run.Error, &run.StartedAt, &finishedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования запуска обновления: %w", err)
+		}
+		if settings != "" {
+			run.Settings = []byte(settings)
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		run.Outcomes = map[string]int{}
+		index[run.RunID] = len(runs)
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	if len(runs) == 0 {
+		return runs, nil
+	}
+
+	ids := make([]string, len(runs))
+	for i, run := range runs {
+		ids[i] = run.RunID
+	}
+	outcomeRows, err := r.db.QueryContext(ctx, `
+		SELECT run_id, outcome, COUNT(*)
+		FROM wildberries.update_run_cards
+		WHERE run_id = ANY($1)
+		GROUP BY run_id, outcome`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса исходов карточек: %w", err)
+	}
+	defer outcomeRows.Close()
+
+	for outcomeRows.Next() {
+		var runID, outcome string
+		var count int
+		if err := outcomeRows.Scan(&runID, &outcome, &count); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования исходов карточек: %w", err)
+		}
+		runs[index[runID]].Outcomes[outcome] = count
+	}
+	if err := outcomeRows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return runs, nil
+}
+
+// ListFailures возвращает карточки запуска runID, которые не прошли проверку, отклонены WB или забанены.
+func (r *UpdateRunRepository) ListFailures(ctx context.Context, runID string, limit int) ([]models.UpdateRunCard, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM wildberries.update_runs WHERE run_id = $1)`, runID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("ошибка проверки запуска %s: %w", runID, err)
+	}
+	if !exists {
+		return nil, ErrRunNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT run_id, nm_id, COALESCE(vendor_code, ''), outcome, COALESCE(error, ''), updated_at
+		FROM wildberries.update_run_cards
+		WHERE run_id = $1 AND outcome = ANY($2)
+		ORDER BY nm_id
+		LIMIT $3`, runID, pq.Array(failureOutcomes), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса ошибок запуска %s: %w", runID, err)
+	}
+	defer rows.Close()
+
+	cards := []models.UpdateRunCard{}
+	for rows.Next() {
+		var card models.UpdateRunCard
+		if err := rows.Scan(&card.RunID, &card.NmID, &card.VendorCode, &card.Outcome, &card.Error, &card.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования исхода карточки: %w", err)
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return cards, nil
+}
+
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -32,3 +33,31 @@ func (r *WbCategoriesRepository) CategoryNameByID(id int) (*response.Category, e
 	}
 	return &category, nil
 }
+
+// SaveCategories добавляет предметы WB в wildberries.categories и обновляет названия уже известных.
+func (r *WbCategoriesRepository) SaveCategories(ctx context.Context, categories []response.Category) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO wildberries.categories (category_id, category, parent_category_id, parent_category_name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (category_id) DO UPDATE SET
+		    category = EXCLUDED.category,
+		    parent_category_id = EXCLUDED.parent_category_id,
+		    parent_category_name = EXCLUDED.parent_category_name`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare categories insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, category := range categories {
+		if _, err := stmt.ExecContext(ctx, category.SubjectID, category.SubjectName, category.ParentID, category.ParentName); err != nil {
+			return fmt.Errorf("failed to save category %d: %w", category.SubjectID, err)
+		}
+	}
+	return tx.Commit()
+}
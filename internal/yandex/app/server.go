@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/time/rate"
+	"gomarketplace_api/config"
+	"gomarketplace_api/internal/core"
+	coreservices "gomarketplace_api/internal/core/services"
+	registry "gomarketplace_api/internal/registry/business"
+	registrystorage "gomarketplace_api/internal/registry/storage"
+	"gomarketplace_api/internal/yandex/business/models"
+	"gomarketplace_api/internal/yandex/business/services"
+	"gomarketplace_api/internal/yandex/business/services/get"
+	"gomarketplace_api/internal/yandex/business/services/update"
+	"gomarketplace_api/internal/yandex/business/services/update/operations/domain"
+	"gomarketplace_api/internal/yandex/pkg/clients"
+	"gomarketplace_api/internal/yandex/storage"
+	"gomarketplace_api/migrations/infrastructure"
+	"gomarketplace_api/migrations/marketplaces/yandex"
+	"gomarketplace_api/pkg/dbconnect"
+	"gomarketplace_api/pkg/dbconnect/migration"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"time"
+)
+
+const (
+	pricesBatchSize = 500
+	stocksBatchSize = 2000
+)
+
+type YandexServer struct {
+	dbconnect.Database
+	config.YandexConfig
+	wsClientUrl string
+	marginGuard *config.MarginGuardConfig
+	pricing     *config.PricingConfig
+	log         logger.Logger
+	writer      io.Writer
+}
+
+func NewYandexServer(connector dbconnect.Database, yandexConfig config.YandexConfig, wsClientUrl string, writer io.Writer) *YandexServer {
+	_log := logger.NewLogger(writer, "[YandexServer]")
+	return &YandexServer{Database: connector, YandexConfig: yandexConfig, wsClientUrl: wsClientUrl, log: _log, writer: writer}
+}
+
+// WithMarginGuard включает проверку маржи цен перед выгрузкой в Маркет.
+func (s *YandexServer) WithMarginGuard(guard *config.MarginGuardConfig, pricing *config.PricingConfig) *YandexServer {
+	s.marginGuard, s.pricing = guard, pricing
+	return s
+}
+
+func (s *YandexServer) Run() {
+	if err := s.Sync(context.Background()); err != nil {
+		s.log.Log("Yandex sync failed: %v", err)
+	}
+}
+
+// Sync применяет миграции, синхронизирует товары площадки и выгружает цены и остатки.
+func (s *YandexServer) Sync(ctx context.Context) error {
+	auth := services.NewApiKeyAuth(s.ApiKey)
+	if auth == nil || s.BusinessID == 0 {
+		s.log.Log("Yandex api_key/business_id not configured. Skipping.")
+		return nil
+	}
+
+	db, err := s.Connect()
+	if err != nil {
+		return fmt.Errorf("error connecting to PostgreSQL: %w", err)
+	}
+	defer db.Close()
+
+	migrationApply := []migration.MigrationInterface{
+		&yandex.CreateYandexSchema{},
+		&yandex.YandexOffers{},
+		&yandex.YandexOfferErrors{},
+		&infrastructure.RegistrySchema{},
+		&infrastructure.RegistrySupplierTable{},
+		&infrastructure.RegistryArticularAccountingTable{},
+		&infrastructure.RegistryArticularSequence{},
+		&core.CoreSuppliers{},
+		&core.CoreProducts{},
+		&core.CoreProductsSupplierArticul{},
+		&core.CoreProductGroups{},
+	}
+	for _, _migration := range migrationApply {
+		if err := _migration.UpMigration(db); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	}
+	s.log.Log("Yandex migrations applied successfully!")
+
+	client := clients.NewYandexClient(s.BaseURL, s.BusinessID, s.CampaignID, auth, s.writer)
+	wsClient, err := clients.NewWServiceClient(s.wsClientUrl, s.writer)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler client: %w", err)
+	}
+	repo := storage.NewOfferRepository(db)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	defer cancel()
+
+	articular := registry.NewArticularService(registrystorage.NewArticularRepository(db), s.YandexIdentity.Code, s.YandexIdentity.Supplier)
+	syncService := get.NewOfferSyncService(client, repo, articular, s.writer)
+	if _, err := syncService.Sync(ctx); err != nil {
+		return fmt.Errorf("yandex offers sync failed: %w", err)
+	}
+
+	offers, err := repo.GetOffers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load yandex offers: %w", err)
+	}
+	ids := make([]int, 0, len(offers))
+	for _, offer := range offers {
+		ids = append(ids, offer.GlobalID)
+	}
+	if len(ids) == 0 {
+		s.log.Log("No yandex offers linked to our articuls. Nothing to update.")
+		return nil
+	}
+
+	guard, guardErr := coreservices.LoadMarginGuard(db, s.marginGuard, s.pricing, s.writer)
+	priceOperation := domain.NewPriceUpdateOperation(wsClient, client, guard)
+	if guardErr != nil {
+		s.log.Log("Yandex prices update skipped, margin guard failed: %v", guardErr)
+	} else if _, err := priceOperation.LoadPrices(ctx, offers); err != nil {
+		s.log.Log("Failed to load prices: %v", err)
+	} else {
+		// businesses/{businessId}/offer-prices/updates: до 10 000 товаров в минуту
+		priceService := update.NewUpdateService(priceOperation, priceOperation, pricesBatchSize,
+			rate.NewLimiter(rate.Every(time.Minute/20), 1), 5, repo, s.writer)
+		if _, err := priceService.Update(ctx, s.offersChannel(ctx, syncService)); err != nil {
+			s.log.Log("Yandex prices update failed: %v", err)
+		}
+	}
+
+	if s.CampaignID == 0 {
+		s.log.Log("Yandex campaign_id not configured. Skipping stocks.")
+		return nil
+	}
+	stockOperation := domain.NewStockUpdateOperation(wsClient, client)
+	if _, err := stockOperation.LoadStocks(ctx, ids); err != nil {
+		return fmt.Errorf("failed to load stocks: %w", err)
+	}
+	// campaigns/{campaignId}/offers/stocks: 100 000 товаров в минуту
+	stockService := update.NewUpdateService(stockOperation, stockOperation, stocksBatchSize,
+		rate.NewLimiter(rate.Every(time.Minute/50), 1), 5, repo, s.writer)
+	if _, err := stockService.Update(ctx, s.offersChannel(ctx, syncService)); err != nil {
+		s.log.Log("Yandex stocks update failed: %v", err)
+	}
+	return nil
+}
+
+func (s *YandexServer) offersChannel(ctx context.Context, syncService *get.OfferSyncService) <-chan models.Offer {
+	offerCh := make(chan models.Offer)
+	go func() {
+		if err := syncService.OffersIntoChannel(ctx, offerCh); err != nil {
+			s.log.Log("Failed to stream yandex offers: %v", err)
+		}
+	}()
+	return offerCh
+}
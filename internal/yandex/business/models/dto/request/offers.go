@@ -0,0 +1,46 @@
+package request
+
+// OfferMappingsRequest - тело запроса POST /businesses/{businessId}/offer-mappings.
+type OfferMappingsRequest struct {
+	OfferIDs []string `json:"offerIds,omitempty"`
+	Archived bool     `json:"archived"`
+}
+
+// OfferPricesRequest - тело запроса POST /businesses/{businessId}/offer-prices/updates.
+type OfferPricesRequest struct {
+	Offers []OfferPrice `json:"offers"`
+}
+
+type OfferPrice struct {
+	OfferID string `json:"offerId"`
+	Price   Price  `json:"price"`
+}
+
+func (p OfferPrice) Key() string {
+	return p.OfferID
+}
+
+type Price struct {
+	Value        float64 `json:"value"`
+	CurrencyID   string  `json:"currencyId"`
+	DiscountBase float64 `json:"discountBase,omitempty"`
+}
+
+// StocksRequest - тело запроса PUT /campaigns/{campaignId}/offers/stocks.
+type StocksRequest struct {
+	Skus []SkuStock `json:"skus"`
+}
+
+type SkuStock struct {
+	Sku   string      `json:"sku"`
+	Items []StockItem `json:"items"`
+}
+
+func (s SkuStock) Key() string {
+	return s.Sku
+}
+
+type StockItem struct {
+	Count     int    `json:"count"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// Model - элемент пакетного запроса к Partner API.
+type Model interface {
+	// Key возвращает offer_id (shopSku), к которому относится элемент.
+	Key() string
+}
@@ -0,0 +1,49 @@
+package response
+
+type OfferMappingsResponse struct {
+	Status string              `json:"status"`
+	Result OfferMappingsResult `json:"result"`
+}
+
+type OfferMappingsResult struct {
+	Paging        Paging               `json:"paging"`
+	OfferMappings []OfferMappingResult `json:"offerMappings"`
+}
+
+type Paging struct {
+	NextPageToken string `json:"nextPageToken"`
+}
+
+type OfferMappingResult struct {
+	Offer   Offer   `json:"offer"`
+	Mapping Mapping `json:"mapping"`
+}
+
+type Offer struct {
+	OfferID    string      `json:"offerId"`
+	Name       string      `json:"name"`
+	Barcodes   []string    `json:"barcodes"`
+	BasicPrice *BasicPrice `json:"basicPrice"`
+	Archived   bool        `json:"archived"`
+}
+
+type BasicPrice struct {
+	Value      float64 `json:"value"`
+	CurrencyID string  `json:"currencyId"`
+}
+
+type Mapping struct {
+	MarketSku     int64  `json:"marketSku"`
+	MarketSkuName string `json:"marketSkuName"`
+}
+
+// ApiResponse - общий ответ Partner API для операций обновления.
+type ApiResponse struct {
+	Status string     `json:"status"`
+	Errors []ApiError `json:"errors"`
+}
+
+type ApiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
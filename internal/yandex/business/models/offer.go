@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Offer - товар продавца на Яндекс Маркете (таблица yandex.offers).
+type Offer struct {
+	OfferID   string    `json:"offer_id"`
+	GlobalID  int       `json:"global_id"` // внутренний артикул из offer_id, 0 если offer_id не наш
+	MarketSKU int64     `json:"market_sku"`
+	Name      string    `json:"name"`
+	Barcodes  []string  `json:"barcodes"`
+	Price     float64   `json:"price"`
+	Archived  bool      `json:"archived"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OfferError - ошибка обработки конкретного оффера (таблица yandex.offer_errors).
+type OfferError struct {
+	OfferID   string    `json:"offer_id"`
+	Operation string    `json:"operation"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
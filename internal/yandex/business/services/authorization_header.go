@@ -0,0 +1,30 @@
+package services
+
+import (
+	"net/http"
+)
+
+type AuthEngine interface {
+	GetApiKey() string
+	SetApiKey(request *http.Request)
+}
+
+// ApiKeyAuth авторизует запросы к Partner API заголовком Api-Key.
+type ApiKeyAuth struct {
+	apiKey string
+}
+
+func (a *ApiKeyAuth) GetApiKey() string {
+	return a.apiKey
+}
+
+func (a *ApiKeyAuth) SetApiKey(request *http.Request) {
+	request.Header.Set("Api-Key", a.apiKey)
+}
+
+func NewApiKeyAuth(apiKey string) *ApiKeyAuth {
+	if apiKey == "" {
+		return nil
+	}
+	return &ApiKeyAuth{apiKey: apiKey}
+}
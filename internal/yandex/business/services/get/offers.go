@@ -0,0 +1,100 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	registry "gomarketplace_api/internal/registry/business"
+	"gomarketplace_api/internal/yandex/business/models"
+	"gomarketplace_api/internal/yandex/pkg/clients"
+	"gomarketplace_api/internal/yandex/storage"
+	"gomarketplace_api/pkg/logger"
+	"io"
+)
+
+const offerMappingsLimit = 200
+
+// OfferSyncService выгружает офферы и их карточки на Маркете в yandex.offers.
+type OfferSyncService struct {
+	client    *clients.YandexClient
+	repo      *storage.OfferRepository
+	articular *registry.ArticularService
+	log       logger.Logger
+}
+
+func NewOfferSyncService(client *clients.YandexClient, repo *storage.OfferRepository, articular *registry.ArticularService, writer io.Writer) *OfferSyncService {
+	return &OfferSyncService{
+		client:    client,
+		repo:      repo,
+		articular: articular,
+		log:       logger.NewLogger(writer, "[Yandex OfferSync]"),
+	}
+}
+
+// Sync загружает все офферы кабинета постранично. Возвращает количество офферов.
+func (s *OfferSyncService) Sync(ctx context.Context) (int, error) {
+	synced := 0
+	pageToken := ""
+	for {
+		page, err := s.client.OfferMappings(ctx, pageToken, offerMappingsLimit)
+		if err != nil {
+			return synced, fmt.Errorf("failed to get offer mappings: %w", err)
+		}
+
+		offerIDs := make([]string, len(page.Result.OfferMappings))
+		for i, mapping := range page.Result.OfferMappings {
+			offerIDs[i] = mapping.Offer.OfferID
+		}
+		// global_id - артикул поставщика карточек; у офферов других поставщиков он остаётся 0
+		globalIDs, err := s.articular.SupplierArticuls(offerIDs)
+		if err != nil {
+			return synced, fmt.Errorf("failed to resolve offer ids: %w", err)
+		}
+
+		offers := make([]models.Offer, 0, len(page.Result.OfferMappings))
+		for _, mapping := range page.Result.OfferMappings {
+			offer := models.Offer{
+				OfferID:   mapping.Offer.OfferID,
+				MarketSKU: mapping.Mapping.MarketSku,
+				Name:      mapping.Offer.Name,
+				Barcodes:  mapping.Offer.Barcodes,
+				Archived:  mapping.Offer.Archived,
+				GlobalID:  globalIDs[mapping.Offer.OfferID],
+			}
+			if mapping.Offer.BasicPrice != nil {
+				offer.Price = mapping.Offer.BasicPrice.Value
+			}
+			offers = append(offers, offer)
+		}
+
+		if err := s.repo.UpsertOffers(ctx, offers); err != nil {
+			return synced, err
+		}
+		synced += len(offers)
+
+		pageToken = page.Result.Paging.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	s.log.Log("Synced %d yandex offers", synced)
+	return synced, nil
+}
+
+// OffersIntoChannel отправляет сохранённые офферы в канал и закрывает его.
+func (s *OfferSyncService) OffersIntoChannel(ctx context.Context, offerCh chan<- models.Offer) error {
+	defer close(offerCh)
+
+	offers, err := s.repo.GetOffers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, offer := range offers {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case offerCh <- offer:
+		}
+	}
+	return nil
+}
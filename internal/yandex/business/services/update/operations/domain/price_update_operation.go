@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	coremodels "gomarketplace_api/internal/core/models"
+	coreservices "gomarketplace_api/internal/core/services"
+	"gomarketplace_api/internal/suppliers/wholesaler/business"
+	pkg2 "gomarketplace_api/internal/suppliers/wholesaler/pkg"
+	"gomarketplace_api/internal/yandex/business/models"
+	"gomarketplace_api/internal/yandex/business/models/dto/request"
+	"gomarketplace_api/internal/yandex/pkg/clients"
+)
+
+const PriceOperationName = "price"
+
+// marketplaceName - площадка для выбора профиля цен PriceEngine.
+const marketplaceName = "yandex"
+
+// PriceUpdateOperation выставляет офферам цены PriceEngine: Z - цена продажи, Y - цена до скидки.
+// Цены, задержанные MarginGuard, офферам не выставляются; nil guard пропускает все цены.
+type PriceUpdateOperation struct {
+	prices   map[int]business.PriceResult
+	allowed  map[string]coremodels.PriceUpdate // по offerID
+	held     map[string]struct{}               // offerID цен, задержанных MarginGuard
+	guard    *coreservices.MarginGuard
+	wsclient *clients.WServiceClient
+	client   *clients.YandexClient
+}
+
+func NewPriceUpdateOperation(wsclient *clients.WServiceClient, client *clients.YandexClient, guard *coreservices.MarginGuard) *PriceUpdateOperation {
+	return &PriceUpdateOperation{
+		prices:   make(map[int]business.PriceResult),
+		allowed:  make(map[string]coremodels.PriceUpdate),
+		held:     make(map[string]struct{}),
+		guard:    guard,
+		wsclient: wsclient,
+		client:   client,
+	}
+}
+
+// LoadPrices загружает цены wholesaler для офферов и проверяет их MarginGuard.
+// Возвращает количество офферов, которым можно выставить цену.
+func (op *PriceUpdateOperation) LoadPrices(ctx context.Context, offers []models.Offer) (int, error) {
+	ids := make([]int, 0, len(offers))
+	for _, offer := range offers {
+		ids = append(ids, offer.GlobalID)
+	}
+	prices, err := pkg2.FetchPrices(ctx, op.wsclient.FetcherChain, marketplaceName, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching prices: %w", err)
+	}
+	op.prices = prices
+
+	updates := make([]coremodels.PriceUpdate, 0, len(offers))
+	for _, offer := range offers {
+		price, ok := prices[offer.GlobalID]
+		if !ok || price.Z <= 0 {
+			continue
+		}
+		updates = append(updates, coremodels.PriceUpdate{
+			GlobalID:        offer.GlobalID,
+			ExternalID:      offer.OfferID,
+			Price:           price.Y,
+			DiscountedPrice: price.Z,
+		})
+	}
+	allowed, err := op.guard.Check(ctx, marketplaceName, updates)
+	if err != nil {
+		return 0, fmt.Errorf("margin guard failed: %w", err)
+	}
+	op.allowed = make(map[string]coremodels.PriceUpdate, len(allowed))
+	for _, update := range allowed {
+		op.allowed[update.ExternalID] = update
+	}
+	op.held = make(map[string]struct{})
+	for _, update := range updates {
+		if _, ok := op.allowed[update.ExternalID]; !ok {
+			op.held[update.ExternalID] = struct{}{}
+		}
+	}
+	return len(op.allowed), nil
+}
+
+// Validate проверяет, что у оффера есть globalID, для него рассчитана цена и она прошла проверку маржи.
+func (op *PriceUpdateOperation) Validate(offer models.Offer) bool {
+	if offer.GlobalID == 0 {
+		return false
+	}
+	_, ok := op.allowed[offer.OfferID]
+	return ok
+}
+
+// Held сообщает, что цена оффера рассчитана, но задержана MarginGuard.
+func (op *PriceUpdateOperation) Held(offer models.Offer) bool {
+	_, ok := op.held[offer.OfferID]
+	return ok
+}
+
+func (op *PriceUpdateOperation) Process(ctx context.Context, offer models.Offer) (request.Model, error) {
+	price, ok := op.prices[offer.GlobalID]
+	if !ok {
+		return nil, fmt.Errorf("price for %d not loaded", offer.GlobalID)
+	}
+	model := request.OfferPrice{
+		OfferID: offer.OfferID,
+		Price: request.Price{
+			Value:      float64(price.Z),
+			CurrencyID: "RUR",
+		},
+	}
+	if price.Y > price.Z {
+		model.Price.DiscountBase = float64(price.Y)
+	}
+	return model, nil
+}
+
+func (op *PriceUpdateOperation) Name() string {
+	return PriceOperationName
+}
+
+func (op *PriceUpdateOperation) Upload(ctx context.Context, batch []request.Model) error {
+	prices := make([]request.OfferPrice, 0, len(batch))
+	for _, model := range batch {
+		price, ok := model.(request.OfferPrice)
+		if !ok {
+			return fmt.Errorf("unexpected model type %T", model)
+		}
+		prices = append(prices, price)
+	}
+	if err := op.client.UpdatePrices(ctx, prices); err != nil {
+		return err
+	}
+
+	pushed := make([]coremodels.PriceUpdate, 0, len(prices))
+	for _, price := range prices {
+		pushed = append(pushed, op.allowed[price.OfferID])
+	}
+	return op.guard.Confirm(ctx, marketplaceName, pushed)
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	pkg2 "gomarketplace_api/internal/suppliers/wholesaler/pkg"
+	"gomarketplace_api/internal/yandex/business/models"
+	"gomarketplace_api/internal/yandex/business/models/dto/request"
+	"gomarketplace_api/internal/yandex/pkg/clients"
+	"time"
+)
+
+const StockOperationName = "stock"
+
+// StockUpdateOperation передаёт остатки wholesaler на склад магазина.
+// Офферы, которых нет в остатках wholesaler, обнуляются.
+type StockUpdateOperation struct {
+	stocks   map[int]int
+	wsclient *clients.WServiceClient
+	client   *clients.YandexClient
+}
+
+func NewStockUpdateOperation(wsclient *clients.WServiceClient, client *clients.YandexClient) *StockUpdateOperation {
+	return &StockUpdateOperation{
+		stocks:   make(map[int]int),
+		wsclient: wsclient,
+		client:   client,
+	}
+}
+
+// LoadStocks загружает остатки wholesaler для указанных товаров.
+func (op *StockUpdateOperation) LoadStocks(ctx context.Context, ids []int) (int, error) {
+	stocks, err := pkg2.FetchStocks(ctx, op.wsclient.FetcherChain, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching stocks: %w", err)
+	}
+	op.stocks = stocks
+	return len(stocks), nil
+}
+
+func (op *StockUpdateOperation) Validate(offer models.Offer) bool {
+	return offer.GlobalID != 0
+}
+
+func (op *StockUpdateOperation) Process(ctx context.Context, offer models.Offer) (request.Model, error) {
+	count := op.stocks[offer.GlobalID]
+	if count < 0 {
+		count = 0
+	}
+	return request.SkuStock{
+		Sku: offer.OfferID,
+		Items: []request.StockItem{
+			{Count: count, UpdatedAt: time.Now().Format(time.RFC3339)},
+		},
+	}, nil
+}
+
+func (op *StockUpdateOperation) Name() string {
+	return StockOperationName
+}
+
+func (op *StockUpdateOperation) Upload(ctx context.Context, batch []request.Model) error {
+	stocks := make([]request.SkuStock, 0, len(batch))
+	for _, model := range batch {
+		stock, ok := model.(request.SkuStock)
+		if !ok {
+			return fmt.Errorf("unexpected model type %T", model)
+		}
+		stocks = append(stocks, stock)
+	}
+	return op.client.UpdateStocks(ctx, stocks)
+}
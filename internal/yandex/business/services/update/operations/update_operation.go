@@ -0,0 +1,27 @@
+package operations
+
+import (
+	"context"
+	"gomarketplace_api/internal/yandex/business/models"
+	"gomarketplace_api/internal/yandex/business/models/dto/request"
+)
+
+type UpdateOperation interface {
+	// Validate проверяет, подходит ли данный оффер для операции.
+	Validate(offer models.Offer) bool
+	// Process обрабатывает оффер и возвращает модель запроса для обновления.
+	Process(ctx context.Context, offer models.Offer) (request.Model, error)
+}
+
+// Holder - операция, которая задерживает офферы, например цены, задержанные проверкой маржи.
+type Holder interface {
+	// Held сообщает, что оффер не прошёл Validate, потому что задержан операцией.
+	Held(offer models.Offer) bool
+}
+
+// Uploader отправляет пачку моделей в Partner API.
+type Uploader interface {
+	// Name - имя операции, под которым сохраняются ошибки офферов.
+	Name() string
+	Upload(ctx context.Context, batch []request.Model) error
+}
@@ -0,0 +1,156 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/time/rate"
+	"gomarketplace_api/internal/yandex/business/models"
+	"gomarketplace_api/internal/yandex/business/models/dto/request"
+	"gomarketplace_api/internal/yandex/business/services/update/operations"
+	"gomarketplace_api/internal/yandex/storage"
+	"gomarketplace_api/metrics"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"sync"
+)
+
+type Service struct {
+	operation   operations.UpdateOperation
+	uploader    operations.Uploader
+	batchSize   int
+	rateLimiter *rate.Limiter
+	workerCount int
+	metrics     *metrics.UpdateMetrics
+	repo        *storage.OfferRepository
+	log         logger.Logger
+
+	mu        sync.Mutex
+	errs      map[string]string
+	succeeded []string
+}
+
+// NewUpdateService создает сервис обновления офферов Маркета.
+// Ошибки по офферам сохраняются в yandex.offer_errors под именем операции uploader'а.
+func NewUpdateService(
+	operation operations.UpdateOperation,
+	uploader operations.Uploader,
+	batchSize int,
+	rateLimiter *rate.Limiter,
+	workerCount int,
+	repo *storage.OfferRepository,
+	writer io.Writer,
+) *Service {
+	return &Service{
+		operation:   operation,
+		uploader:    uploader,
+		batchSize:   batchSize,
+		rateLimiter: rateLimiter,
+		workerCount: workerCount,
+		metrics:     &metrics.UpdateMetrics{},
+		repo:        repo,
+		log:         logger.NewLogger(writer, fmt.Sprintf("[Yandex Update %s]", uploader.Name())),
+	}
+}
+
+// Update обрабатывает офферы из канала и выгружает их пачками. Возвращает количество обновлённых офферов.
+func (s *Service) Update(ctx context.Context, offerCh <-chan models.Offer) (int, error) {
+	s.errs = make(map[string]string)
+	s.succeeded = nil
+	uploadChan := make(chan request.Model)
+
+	var processWg sync.WaitGroup
+	for i := 0; i < s.workerCount; i++ {
+		processWg.Add(1)
+		go func() {
+			defer processWg.Done()
+			for offer := range offerCh {
+				if !s.operation.Validate(offer) {
+					if holder, ok := s.operation.(operations.Holder); ok && holder.Held(offer) {
+						s.fail(offer.OfferID, "цена задержана проверкой маржи")
+						continue
+					}
+					s.fail(offer.OfferID, "оффер не прошёл валидацию")
+					continue
+				}
+
+				model, err := s.operation.Process(ctx, offer)
+				if err != nil {
+					s.fail(offer.OfferID, err.Error())
+					continue
+				}
+				uploadChan <- model
+				s.metrics.ProcessedCount.Add(1)
+			}
+		}()
+	}
+
+	var uploadWg sync.WaitGroup
+	uploadWg.Add(1)
+	go func() {
+		defer uploadWg.Done()
+		s.uploadWorker(ctx, uploadChan)
+	}()
+
+	processWg.Wait()
+	close(uploadChan)
+	uploadWg.Wait()
+
+	if err := s.repo.ClearOfferErrors(ctx, s.uploader.Name(), s.succeeded); err != nil {
+		return int(s.metrics.UpdatedCount.Load()), err
+	}
+	if err := s.repo.SaveOfferErrors(ctx, s.uploader.Name(), s.errs); err != nil {
+		return int(s.metrics.UpdatedCount.Load()), err
+	}
+
+	s.log.Log("Processed %d, updated %d, errored %d offers",
+		s.metrics.ProcessedCount.Load(), s.metrics.UpdatedCount.Load(), s.metrics.ErroredNomenclatures.Load())
+	return int(s.metrics.UpdatedCount.Load()), nil
+}
+
+// uploadWorker собирает модели в пачки по batchSize и отправляет их с учетом rate limiter'а.
+func (s *Service) uploadWorker(ctx context.Context, uploadChan <-chan request.Model) {
+	batch := make([]request.Model, 0, s.batchSize)
+	for model := range uploadChan {
+		batch = append(batch, model)
+		if len(batch) >= s.batchSize {
+			s.upload(ctx, batch)
+			batch = make([]request.Model, 0, s.batchSize)
+		}
+	}
+	if len(batch) > 0 {
+		s.upload(ctx, batch)
+	}
+}
+
+func (s *Service) upload(ctx context.Context, batch []request.Model) {
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		s.failBatch(batch, fmt.Sprintf("limiter error: %s", err))
+		return
+	}
+
+	if err := s.uploader.Upload(ctx, batch); err != nil {
+		s.log.Log("Error during upload: %s", err)
+		s.failBatch(batch, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	for _, model := range batch {
+		s.succeeded = append(s.succeeded, model.Key())
+	}
+	s.mu.Unlock()
+	s.metrics.UpdatedCount.Add(int32(len(batch)))
+}
+
+func (s *Service) fail(offerID, message string) {
+	s.mu.Lock()
+	s.errs[offerID] = message
+	s.mu.Unlock()
+	s.metrics.ErroredNomenclatures.Add(1)
+}
+
+func (s *Service) failBatch(batch []request.Model, message string) {
+	for _, model := range batch {
+		s.fail(model.Key(), message)
+	}
+}
@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"fmt"
+	pkg2 "gomarketplace_api/internal/suppliers/wholesaler/pkg"
+	clients2 "gomarketplace_api/internal/suppliers/wholesaler/pkg/clients"
+	"gomarketplace_api/pkg/logger"
+	"io"
+)
+
+type WServiceClient struct {
+	FetcherChain *pkg2.FetcherChain
+}
+
+func NewWServiceClient(host string, writer io.Writer) (*WServiceClient, error) {
+	log := logger.NewLogger(writer, "[Yandex WServiceClient]")
+
+	fetcherChain := pkg2.NewFetcherChain(log)
+
+	if err := registerClients(fetcherChain, host, writer); err != nil {
+		return nil, fmt.Errorf("failed to register clients: %w", err)
+	}
+
+	return &WServiceClient{
+		FetcherChain: fetcherChain,
+	}, nil
+}
+
+func registerClients(fetcherChain *pkg2.FetcherChain, host string, writer io.Writer) error {
+	clientsToRegister := []struct {
+		name    string
+		fetcher pkg2.Fetcher
+	}{
+		{"globalIDs", clients2.NewGlobalIDsClient(host, writer)},
+		{"prices", clients2.NewPriceClient(host, writer)},
+		{"stocks", clients2.NewStocksClient(host, writer)},
+	}
+
+	for _, client := range clientsToRegister {
+		if err := fetcherChain.Register(client.name, client.fetcher); err != nil {
+			return fmt.Errorf("failed to register client '%s': %w", client.name, err)
+		}
+	}
+
+	return nil
+}
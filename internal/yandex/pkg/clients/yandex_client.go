@@ -0,0 +1,130 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gomarketplace_api/internal/yandex/business/models/dto/request"
+	"gomarketplace_api/internal/yandex/business/models/dto/response"
+	"gomarketplace_api/internal/yandex/business/services"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.partner.market.yandex.ru"
+
+// ApiError - ошибка Partner API. Errors содержит разобранное тело ответа, если оно есть.
+type ApiError struct {
+	StatusCode int
+	Errors     []response.ApiError
+}
+
+func (e *ApiError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, apiErr := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", apiErr.Code, apiErr.Message))
+	}
+	return fmt.Sprintf("yandex api status %d: %s", e.StatusCode, strings.Join(messages, "; "))
+}
+
+// YandexClient - клиент Partner API Яндекс Маркета. baseURL можно подменить на адрес локального тестового сервера.
+type YandexClient struct {
+	baseURL    string
+	businessID int64
+	campaignID int64
+	auth       services.AuthEngine
+	client     *http.Client
+	log        logger.Logger
+}
+
+func NewYandexClient(baseURL string, businessID, campaignID int64, auth services.AuthEngine, writer io.Writer) *YandexClient {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &YandexClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		businessID: businessID,
+		campaignID: campaignID,
+		auth:       auth,
+		client:     &http.Client{Timeout: 60 * time.Second},
+		log:        logger.NewLogger(writer, "[YandexClient]"),
+	}
+}
+
+// OfferMappings возвращает страницу офферов кабинета вместе с карточками Маркета.
+func (c *YandexClient) OfferMappings(ctx context.Context, pageToken string, limit int) (*response.OfferMappingsResponse, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	if pageToken != "" {
+		query.Set("page_token", pageToken)
+	}
+	path := fmt.Sprintf("/businesses/%d/offer-mappings?%s", c.businessID, query.Encode())
+
+	var resp response.OfferMappingsResponse
+	if err := c.doRequest(ctx, http.MethodPost, path, request.OfferMappingsRequest{}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdatePrices устанавливает базовые цены офферов во всех магазинах кабинета.
+func (c *YandexClient) UpdatePrices(ctx context.Context, prices []request.OfferPrice) error {
+	path := fmt.Sprintf("/businesses/%d/offer-prices/updates", c.businessID)
+	var resp response.ApiResponse
+	return c.doRequest(ctx, http.MethodPost, path, request.OfferPricesRequest{Offers: prices}, &resp)
+}
+
+// UpdateStocks передаёт остатки на складе магазина.
+func (c *YandexClient) UpdateStocks(ctx context.Context, stocks []request.SkuStock) error {
+	path := fmt.Sprintf("/campaigns/%d/offers/stocks", c.campaignID)
+	var resp response.ApiResponse
+	return c.doRequest(ctx, http.MethodPut, path, request.StocksRequest{Skus: stocks}, &resp)
+}
+
+func (c *YandexClient) doRequest(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.auth != nil {
+		c.auth.SetApiKey(req)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &ApiError{StatusCode: resp.StatusCode}
+		var errorResponse response.ApiResponse
+		if json.Unmarshal(bodyBytes, &errorResponse) == nil {
+			apiErr.Errors = errorResponse.Errors
+		}
+		c.log.Log("Request %s failed: %s", path, apiErr)
+		return apiErr
+	}
+
+	if err := json.Unmarshal(bodyBytes, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
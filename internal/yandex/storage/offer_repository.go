@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"gomarketplace_api/internal/yandex/business/models"
+)
+
+type OfferRepository struct {
+	db *sql.DB
+}
+
+func NewOfferRepository(db *sql.DB) *OfferRepository {
+	return &OfferRepository{db: db}
+}
+
+// UpsertOffers записывает офферы в yandex.offers одной транзакцией.
+func (r *OfferRepository) UpsertOffers(ctx context.Context, offers []models.Offer) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO yandex.offers (offer_id, global_id, market_sku, name, barcodes, price, archived, updated_at)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, NOW())
+		ON CONFLICT (offer_id) DO UPDATE
+		SET global_id = EXCLUDED.global_id,
+		    market_sku = EXCLUDED.market_sku,
+		    name = EXCLUDED.name,
+		    barcodes = EXCLUDED.barcodes,
+		    price = EXCLUDED.price,
+		    archived = EXCLUDED.archived,
+		    updated_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare upsert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, o := range offers {
+		if _, err := stmt.ExecContext(ctx, o.OfferID, o.GlobalID, o.MarketSKU, o.Name,
+			pq.Array(o.Barcodes), o.Price, o.Archived); err != nil {
+			return fmt.Errorf("upsert yandex offer %s error: %w", o.OfferID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// GetOffers возвращает неархивные офферы, связанные с нашими артикулами.
+func (r *OfferRepository) GetOffers(ctx context.Context) ([]models.Offer, error) {
+	query := `
+		SELECT offer_id, global_id, COALESCE(market_sku, 0), COALESCE(name, ''), COALESCE(barcodes, '{}'),
+		       COALESCE(price, 0), archived, updated_at
+		FROM yandex.offers
+		WHERE global_id IS NOT NULL AND NOT archived`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса для получения офферов yandex: %w", err)
+	}
+	defer rows.Close()
+
+	var offers []models.Offer
+	for rows.Next() {
+		var o models.Offer
+		if err := rows.Scan(&o.OfferID, &o.GlobalID, &o.MarketSKU, &o.Name, pq.Array(&o.Barcodes),
+			&o.Price, &o.Archived, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования оффера yandex: %w", err)
+		}
+		offers = append(offers, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по строкам: %w", err)
+	}
+	return offers, nil
+}
+
+// SaveOfferErrors сохраняет ошибки офферов для операции, заменяя предыдущие.
+func (r *OfferRepository) SaveOfferErrors(ctx context.Context, operation string, errs map[string]string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO yandex.offer_errors (offer_id, operation, message, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (offer_id, operation) DO UPDATE
+		SET message = EXCLUDED.message, created_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("prepare offer errors insert error: %w", err)
+	}
+	defer stmt.Close()
+
+	for offerID, message := range errs {
+		if _, err := stmt.ExecContext(ctx, offerID, operation, message); err != nil {
+			return fmt.Errorf("insert offer error %s: %w", offerID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit error: %w", err)
+	}
+	return nil
+}
+
+// ClearOfferErrors удаляет ошибки офферов, успешно обработанных операцией.
+func (r *OfferRepository) ClearOfferErrors(ctx context.Context, operation string, offerIDs []string) error {
+	if len(offerIDs) == 0 {
+		return nil
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM yandex.offer_errors WHERE operation = $1 AND offer_id = ANY($2)`,
+		operation, pq.Array(offerIDs))
+	if err != nil {
+		return fmt.Errorf("failed to clear offer errors: %w", err)
+	}
+	return nil
+}
+
+// GetOfferErrors возвращает ошибки офферов. Если operation пустая - по всем операциям.
+func (r *OfferRepository) GetOfferErrors(ctx context.Context, operation string) ([]models.OfferError, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT offer_id, operation, message, created_at FROM yandex.offer_errors
+		WHERE $1 = '' OR operation = $1
+		ORDER BY created_at DESC`, operation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get offer errors: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.OfferError
+	for rows.Next() {
+		var e models.OfferError
+		if err := rows.Scan(&e.OfferID, &e.Operation, &e.Message, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan offer error: %w", err)
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
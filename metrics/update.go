@@ -7,4 +7,6 @@ type UpdateMetrics struct {
 	ProcessedCount       atomic.Int32
 	ErroredNomenclatures atomic.Int32
 	GoroutinesNmsCount   atomic.Int32
+	// UnchangedCount - карточки, не отправленные в WB, потому что обновление ничего в них не меняет
+	UnchangedCount atomic.Int32
 }
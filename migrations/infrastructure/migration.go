@@ -319,3 +319,242 @@ func (m *Metadata) UpMigration(db *sql.DB) error {
 	log.Println("Migration 'metadata' completed successfully.")
 	return nil
 }
+
+type MetadataValidators struct{}
+
+// UpMigration добавляет в metadata версию файла импорта (ETag и Last-Modified) и URL, к которому она относится.
+func (m *MetadataValidators) UpMigration(db *sql.DB) error {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = 'metadata_validators')").Scan(&migrationExists)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Println("Migration 'metadata_validators' already completed. Skipping.")
+		return nil
+	}
+	query :=
+		`
+		ALTER TABLE metadata
+		    ADD COLUMN IF NOT EXISTS source_url TEXT,
+		    ADD COLUMN IF NOT EXISTS etag TEXT,
+		    ADD COLUMN IF NOT EXISTS last_modified TEXT;
+		`
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to add validators to metadata table: %w", err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ('metadata_validators', current_timestamp)")
+	if err != nil {
+		return fmt.Errorf("failed to mark metadata_validators migration as complete: %w", err)
+	}
+
+	log.Println("Migration 'metadata_validators' completed successfully.")
+	return nil
+}
+
+type WholesalerPricingProfiles struct{}
+
+// UpMigration создаёт таблицу профилей ценообразования. profile - values.PricingProfile в JSON.
+func (m *WholesalerPricingProfiles) UpMigration(db *sql.DB) error {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = 'wholesaler.pricing_profiles')").Scan(&migrationExists)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Println("Migration 'wholesaler.pricing_profiles' already completed. Skipping.")
+		return nil
+	}
+	query :=
+		`
+		CREATE TABLE IF NOT EXISTS wholesaler.pricing_profiles (
+		    name VARCHAR(255) PRIMARY KEY,
+		    marketplace VARCHAR(64) NOT NULL DEFAULT '',
+		    category TEXT NOT NULL DEFAULT '',
+		    profile JSONB NOT NULL,
+		    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+		    UNIQUE (marketplace, category)
+		);
+		`
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler.pricing_profiles table: %w", err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ('wholesaler.pricing_profiles', current_timestamp)")
+	if err != nil {
+		return fmt.Errorf("failed to mark wholesaler.pricing_profiles migration as complete: %w", err)
+	}
+
+	log.Println("Migration 'wholesaler.pricing_profiles' completed successfully.")
+	return nil
+}
+
+type WholesalerPriceHistory struct{}
+
+// UpMigration создаёт журнал изменений закупочных цен. Строка пишется при импорте,
+// если цена товара в файле поставщика отличается от сохранённой в wholesaler.price.
+func (m *WholesalerPriceHistory) UpMigration(db *sql.DB) error {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = 'wholesaler.price_history')").Scan(&migrationExists)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Println("Migration 'wholesaler.price_history' already completed. Skipping.")
+		return nil
+	}
+	query :=
+		`
+		CREATE TABLE IF NOT EXISTS wholesaler.price_history (
+		    id BIGSERIAL PRIMARY KEY,
+		    global_id INT NOT NULL,
+		    old_price INT,
+		    new_price INT,
+		    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_price_history_global_id ON wholesaler.price_history (global_id, changed_at);
+		CREATE INDEX IF NOT EXISTS idx_price_history_changed_at ON wholesaler.price_history (changed_at);
+		`
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler.price_history table: %w", err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ('wholesaler.price_history', current_timestamp)")
+	if err != nil {
+		return fmt.Errorf("failed to mark wholesaler.price_history migration as complete: %w", err)
+	}
+
+	log.Println("Migration 'wholesaler.price_history' completed successfully.")
+	return nil
+}
+
+type WholesalerChangeEvents struct{}
+
+// UpMigration создаёт журнал изменений строк при импорте фидов и курсоры его потребителей.
+// key_value - значение ключевой колонки строки, changed_columns - изменившиеся колонки для kind = 'updated'.
+func (m *WholesalerChangeEvents) UpMigration(db *sql.DB) error {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = 'wholesaler.change_events')").Scan(&migrationExists)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Println("Migration 'wholesaler.change_events' already completed. Skipping.")
+		return nil
+	}
+	query :=
+		`
+		CREATE TABLE IF NOT EXISTS wholesaler.change_events (
+		    id BIGSERIAL PRIMARY KEY,
+		    table_name VARCHAR(255) NOT NULL,
+		    key_value TEXT NOT NULL,
+		    kind VARCHAR(16) NOT NULL,
+		    changed_columns TEXT[] NOT NULL DEFAULT '{}',
+		    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_change_events_key ON wholesaler.change_events (table_name, key_value, id);
+
+		CREATE TABLE IF NOT EXISTS wholesaler.change_consumers (
+		    consumer VARCHAR(255) PRIMARY KEY,
+		    last_event_id BIGINT NOT NULL DEFAULT 0,
+		    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+		`
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler.change_events table: %w", err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ('wholesaler.change_events', current_timestamp)")
+	if err != nil {
+		return fmt.Errorf("failed to mark wholesaler.change_events migration as complete: %w", err)
+	}
+
+	log.Println("Migration 'wholesaler.change_events' completed successfully.")
+	return nil
+}
+
+type WholesalerImportRuns struct{}
+
+// UpMigration создаёт журнал запусков импорта фидов: время, источники, время inf-файла,
+// счётчики строк, примеры причин пропуска строк и итоговый статус.
+func (m *WholesalerImportRuns) UpMigration(db *sql.DB) error {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = 'wholesaler.import_runs')").Scan(&migrationExists)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Println("Migration 'wholesaler.import_runs' already completed. Skipping.")
+		return nil
+	}
+	query :=
+		`
+		CREATE TABLE IF NOT EXISTS wholesaler.import_runs (
+		    id BIGSERIAL PRIMARY KEY,
+		    job_name VARCHAR(255) NOT NULL,
+		    inf_url TEXT,
+		    csv_url TEXT,
+		    inf_time TIMESTAMP,
+		    status VARCHAR(16) NOT NULL,
+		    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		    finished_at TIMESTAMP WITH TIME ZONE,
+		    rows_read INT NOT NULL DEFAULT 0,
+		    rows_rejected INT NOT NULL DEFAULT 0,
+		    reject_samples TEXT[] NOT NULL DEFAULT '{}',
+		    rows_inserted BIGINT NOT NULL DEFAULT 0,
+		    rows_updated BIGINT NOT NULL DEFAULT 0,
+		    rows_deleted BIGINT NOT NULL DEFAULT 0,
+		    error TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_import_runs_job ON wholesaler.import_runs (job_name, started_at DESC);
+		`
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler.import_runs table: %w", err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ('wholesaler.import_runs', current_timestamp)")
+	if err != nil {
+		return fmt.Errorf("failed to mark wholesaler.import_runs migration as complete: %w", err)
+	}
+
+	log.Println("Migration 'wholesaler.import_runs' completed successfully.")
+	return nil
+}
+
+type WholesalerProductQuality struct{}
+
+// UpMigration создаёт таблицу результатов проверок качества товаров: статус товара
+// и список нарушенных правил (issues - массив {rule, severity, message}).
+func (m *WholesalerProductQuality) UpMigration(db *sql.DB) error {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = 'wholesaler.product_quality')").Scan(&migrationExists)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Println("Migration 'wholesaler.product_quality' already completed. Skipping.")
+		return nil
+	}
+	query :=
+		`
+		CREATE TABLE IF NOT EXISTS wholesaler.product_quality (
+		    global_id INT PRIMARY KEY,
+		    status VARCHAR(16) NOT NULL,
+		    issues JSONB NOT NULL DEFAULT '[]',
+		    checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_product_quality_status ON wholesaler.product_quality (status);
+		`
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create wholesaler.product_quality table: %w", err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ('wholesaler.product_quality', current_timestamp)")
+	if err != nil {
+		return fmt.Errorf("failed to mark wholesaler.product_quality migration as complete: %w", err)
+	}
+
+	log.Println("Migration 'wholesaler.product_quality' completed successfully.")
+	return nil
+}
@@ -10,6 +10,8 @@ const (
 	RegistrySchemaMigration     = "registry.schema"
 	RegistryArticularAccounting = "registry.articular"
 	RegistrySupplierMigration   = "registry.supplier"
+
+	RegistryArticularSequenceMigration = "registry.articular_seq"
 )
 
 type RegistrySchema struct{}
@@ -127,3 +129,37 @@ func (m *RegistrySupplierTable) UpMigration(db *sql.DB) error {
 	log.Printf("Migration '%s' completed successfully.", RegistrySupplierMigration)
 	return nil
 }
+
+type RegistryArticularSequence struct{}
+
+// UpMigration создаёт последовательность внутренних артикулов для поставщиков, у которых нет собственных числовых артикулов.
+// Начинается с 10 000 000, чтобы не пересекаться с артикулами wholesaler, которые используются как внутренние как есть.
+func (m *RegistryArticularSequence) UpMigration(db *sql.DB) error {
+	var migrationExists bool
+
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = $1)", RegistryArticularSequenceMigration).Scan(&migrationExists)
+	if err != nil {
+		return fmt.Errorf("failed to check migration status: %w", err)
+	}
+
+	if migrationExists {
+		log.Printf("Migration '%s' already completed. Skipping.", RegistryArticularSequenceMigration)
+		return nil
+	}
+
+	query := `
+        CREATE SEQUENCE IF NOT EXISTS registry.internal_articul_seq START WITH 10000000;
+    `
+	_, err = db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create %s sequence: %w", RegistryArticularSequenceMigration, err)
+	}
+
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ($1, current_timestamp)", RegistryArticularSequenceMigration)
+	if err != nil {
+		return fmt.Errorf("failed to mark '%s' migration as complete: %w", RegistryArticularSequenceMigration, err)
+	}
+
+	log.Printf("Migration '%s' completed successfully.", RegistryArticularSequenceMigration)
+	return nil
+}
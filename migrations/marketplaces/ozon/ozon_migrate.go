@@ -0,0 +1,97 @@
+package ozon
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+type CreateOzonSchema struct{}
+
+func (m *CreateOzonSchema) UpMigration(db *sql.DB) error {
+	query := `
+	CREATE SCHEMA IF NOT EXISTS ozon;`
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create schema ozon: %w", err)
+	}
+	return nil
+}
+
+type OzonProducts struct{}
+
+func (m *OzonProducts) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "ozon.products"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	query := `
+	CREATE TABLE IF NOT EXISTS ozon.products (
+		product_id BIGINT PRIMARY KEY,
+		offer_id VARCHAR(100) UNIQUE NOT NULL,
+		global_id INT,
+		sku BIGINT,
+		name TEXT,
+		barcodes TEXT[],
+		price NUMERIC(12, 2),
+		old_price NUMERIC(12, 2),
+		stocks INT DEFAULT 0,
+		archived BOOLEAN DEFAULT FALSE,
+		updated_at TIMESTAMP DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_ozon_products_global_id ON ozon.products (global_id);`
+	if err := executeAndMarkMigration(db, query, "ozon.products"); err != nil {
+		return err
+	}
+	log.Println("Migration 'ozon.products' completed successfully.")
+	return nil
+}
+
+type OzonImportTasks struct{}
+
+func (m *OzonImportTasks) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "ozon.import_tasks"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	query := `
+	CREATE TABLE IF NOT EXISTS ozon.import_tasks (
+		task_id BIGINT PRIMARY KEY,
+		items_count INT NOT NULL,
+		status VARCHAR(32) NOT NULL DEFAULT 'pending',
+		created_at TIMESTAMP DEFAULT NOW(),
+		updated_at TIMESTAMP DEFAULT NOW()
+	);`
+	if err := executeAndMarkMigration(db, query, "ozon.import_tasks"); err != nil {
+		return err
+	}
+	log.Println("Migration 'ozon.import_tasks' completed successfully.")
+	return nil
+}
+
+func checkAndSkipMigration(db *sql.DB, migrationName string) (bool, error) {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = $1)", migrationName).Scan(&migrationExists)
+	if err != nil {
+		return migrationExists, fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Printf("Migration '%s' already completed. Skipping.\n", migrationName)
+		return migrationExists, nil
+	}
+	return migrationExists, nil
+}
+
+func executeAndMarkMigration(db *sql.DB, query string, migrationName string) error {
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to execute migration '%s': %w", migrationName, err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ($1, current_timestamp)", migrationName)
+	if err != nil {
+		return fmt.Errorf("failed to mark migration '%s' as complete: %w", migrationName, err)
+	}
+	return nil
+}
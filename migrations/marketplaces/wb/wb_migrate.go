@@ -194,18 +194,20 @@ func (m *WBCardsHistory) UpMigration(db *sql.DB) error {
 
 	query := `
 		CREATE TABLE IF NOT EXISTS wildberries.cards_history (
-		version_id SERIAL PRIMARY KEY,
-		global_id INT,
-		nm_id INT NOT NULL, -- ID номенклатуры
-		vendor_code VARCHAR(255),
-		version INT NOT NULL, -- Версия карточки
-		version_data JSONB, -- Полный JSON объект карточки
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-		updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-		FOREIGN KEY(global_id) REFERENCES wildberries.nomenclatures(global_id)
-);
-
+			version_id SERIAL PRIMARY KEY,
+			global_id INT,
+			nm_id INT NOT NULL, -- ID номенклатуры
+			vendor_code VARCHAR(255),
+			version INT NOT NULL, -- Версия карточки
+			version_data JSONB, -- Отправленный в WB запрос по карточке
+			run_id VARCHAR(64), -- Запуск обновления, в котором отправлен запрос
+			base_version INT, -- Версия wildberries.nomenclatures_history, к которой применено обновление
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			FOREIGN KEY(global_id) REFERENCES wildberries.nomenclatures(global_id)
 		);
+		CREATE INDEX IF NOT EXISTS idx_cards_history_nm_id ON wildberries.cards_history(nm_id);
+		CREATE INDEX IF NOT EXISTS idx_cards_history_run_id ON wildberries.cards_history(run_id);
 	`
 	if err := executeAndMarkMigration(db, query, "wildberries.cards_history"); err != nil {
 		return err
@@ -214,6 +216,65 @@ func (m *WBCardsHistory) UpMigration(db *sql.DB) error {
 	return nil
 }
 
+// WBCardsActualIndex - одна строка wildberries.cards_actual на карточку и поиск версий в nomenclatures_history.
+type WBCardsActualIndex struct{}
+
+func (m *WBCardsActualIndex) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "wildberries.cards_actual_nm_id"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	query := `
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_cards_actual_nm_id ON wildberries.cards_actual(nm_id);
+		CREATE INDEX IF NOT EXISTS idx_nomenclatures_history_nm_id ON wildberries.nomenclatures_history(nm_id, version);
+	`
+	if err := executeAndMarkMigration(db, query, "wildberries.cards_actual_nm_id"); err != nil {
+		return err
+	}
+	log.Println("Migration 'wildberries.cards_actual_nm_id' completed successfully.")
+	return nil
+}
+
+type WBUpdateRuns struct{}
+
+func (m *WBUpdateRuns) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "wildberries.update_runs"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS wildberries.update_runs (
+			run_id VARCHAR(64) PRIMARY KEY,
+			operation VARCHAR(64) NOT NULL,  -- naming, packages, media, brand, rollback...
+			settings JSONB,                  -- выборка карточек запуска
+			status VARCHAR(16) NOT NULL,     -- running, success, failed
+			error TEXT,
+			started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMP WITH TIME ZONE
+		);
+		CREATE TABLE IF NOT EXISTS wildberries.update_run_cards (
+			run_id VARCHAR(64) NOT NULL,
+			nm_id INT NOT NULL,
+			vendor_code VARCHAR(255),
+			outcome VARCHAR(32) NOT NULL,    -- skipped_invalid, unchanged, processed, uploaded, rejected, banned
+			error TEXT,                      -- ответ WB для rejected
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (run_id, nm_id),
+			FOREIGN KEY (run_id) REFERENCES wildberries.update_runs(run_id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_update_runs_started_at ON wildberries.update_runs(started_at);
+	`
+	if err := executeAndMarkMigration(db, query, "wildberries.update_runs"); err != nil {
+		return err
+	}
+	log.Println("Migration 'wildberries.update_runs' completed successfully.")
+	return nil
+}
+
 type WBCharacteristics struct{}
 
 func (m *WBCharacteristics) UpMigration(db *sql.DB) error {
@@ -245,6 +306,82 @@ func (m *WBCharacteristics) UpMigration(db *sql.DB) error {
 	return nil
 }
 
+type WBNomenclatureSizes struct{}
+
+func (m *WBNomenclatureSizes) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "wildberries.nomenclature_sizes"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS wildberries.nomenclature_sizes (
+			chrt_id INT PRIMARY KEY,      -- ID размера
+			nm_id INT NOT NULL,           -- ID номенклатуры
+			tech_size VARCHAR(255),
+			skus TEXT[] NOT NULL DEFAULT '{}', -- баркоды размера
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_nomenclature_sizes_nm_id ON wildberries.nomenclature_sizes(nm_id);
+	`
+	if err := executeAndMarkMigration(db, query, "wildberries.nomenclature_sizes"); err != nil {
+		return err
+	}
+	log.Println("Migration 'wildberries.nomenclature_sizes' completed successfully.")
+	return nil
+}
+
+type WBStocksPushed struct{}
+
+func (m *WBStocksPushed) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "wildberries.stocks_pushed"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS wildberries.stocks_pushed (
+			warehouse_id BIGINT NOT NULL,
+			sku VARCHAR(255) NOT NULL,    -- баркод размера
+			amount INT NOT NULL,          -- последний выгруженный остаток
+			pushed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (warehouse_id, sku)
+		);
+	`
+	if err := executeAndMarkMigration(db, query, "wildberries.stocks_pushed"); err != nil {
+		return err
+	}
+	log.Println("Migration 'wildberries.stocks_pushed' completed successfully.")
+	return nil
+}
+
+type WBPricesPushed struct{}
+
+func (m *WBPricesPushed) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "wildberries.prices_pushed"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	query := `
+		CREATE TABLE IF NOT EXISTS wildberries.prices_pushed (
+			nm_id INT PRIMARY KEY,        -- ID номенклатуры
+			global_id INT,
+			price INT NOT NULL,           -- последняя выгруженная цена до скидки
+			discount INT NOT NULL,        -- последняя выгруженная скидка, %
+			pushed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+	`
+	if err := executeAndMarkMigration(db, query, "wildberries.prices_pushed"); err != nil {
+		return err
+	}
+	log.Println("Migration 'wildberries.prices_pushed' completed successfully.")
+	return nil
+}
+
 func checkAndSkipMigration(db *sql.DB, migrationName string) (bool, error) {
 	var migrationExists bool
 	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = $1)", migrationName).Scan(&migrationExists)
@@ -0,0 +1,94 @@
+package yandex
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+type CreateYandexSchema struct{}
+
+func (m *CreateYandexSchema) UpMigration(db *sql.DB) error {
+	query := `
+	CREATE SCHEMA IF NOT EXISTS yandex;`
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to create schema yandex: %w", err)
+	}
+	return nil
+}
+
+type YandexOffers struct{}
+
+func (m *YandexOffers) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "yandex.offers"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	query := `
+	CREATE TABLE IF NOT EXISTS yandex.offers (
+		offer_id VARCHAR(255) PRIMARY KEY,
+		global_id INT,
+		market_sku BIGINT,
+		name TEXT,
+		barcodes TEXT[],
+		price NUMERIC(12, 2),
+		archived BOOLEAN DEFAULT FALSE,
+		updated_at TIMESTAMP DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_yandex_offers_global_id ON yandex.offers (global_id);`
+	if err := executeAndMarkMigration(db, query, "yandex.offers"); err != nil {
+		return err
+	}
+	log.Println("Migration 'yandex.offers' completed successfully.")
+	return nil
+}
+
+type YandexOfferErrors struct{}
+
+func (m *YandexOfferErrors) UpMigration(db *sql.DB) error {
+	if ok, err := checkAndSkipMigration(db, "yandex.offer_errors"); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+	query := `
+	CREATE TABLE IF NOT EXISTS yandex.offer_errors (
+		offer_id VARCHAR(255) NOT NULL,
+		operation VARCHAR(32) NOT NULL,
+		message TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		PRIMARY KEY (offer_id, operation)
+	);`
+	if err := executeAndMarkMigration(db, query, "yandex.offer_errors"); err != nil {
+		return err
+	}
+	log.Println("Migration 'yandex.offer_errors' completed successfully.")
+	return nil
+}
+
+func checkAndSkipMigration(db *sql.DB, migrationName string) (bool, error) {
+	var migrationExists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM migrations.migrations WHERE name = $1)", migrationName).Scan(&migrationExists)
+	if err != nil {
+		return migrationExists, fmt.Errorf("failed to check migration status: %w", err)
+	}
+	if migrationExists {
+		log.Printf("Migration '%s' already completed. Skipping.\n", migrationName)
+		return migrationExists, nil
+	}
+	return migrationExists, nil
+}
+
+func executeAndMarkMigration(db *sql.DB, query string, migrationName string) error {
+	_, err := db.Exec(query)
+	if err != nil {
+		return fmt.Errorf("failed to execute migration '%s': %w", migrationName, err)
+	}
+	_, err = db.Exec("INSERT INTO migrations.migrations (name, time) VALUES ($1, current_timestamp)", migrationName)
+	if err != nil {
+		return fmt.Errorf("failed to mark migration '%s' as complete: %w", migrationName, err)
+	}
+	return nil
+}
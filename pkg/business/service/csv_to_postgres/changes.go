@@ -0,0 +1,180 @@
+package csv_to_postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	ChangeInserted = "inserted"
+	ChangeUpdated  = "updated"
+	ChangeDeleted  = "deleted"
+
+	// changeEventsTable - журнал изменений в схеме целевой таблицы (см. infrastructure.WholesalerChangeEvents).
+	changeEventsTable = "change_events"
+)
+
+// ChangeSummary - количество ключей, добавленных, изменённых и пропавших из фида за импорт.
+type ChangeSummary struct {
+	Inserted int64
+	Updated  int64
+	Deleted  int64
+}
+
+// changeTracker пишет события изменений строк таблицы при слиянии временной таблицы с основной.
+// Строки физически не удаляются: пропавший из фида ключ отмечается событием deleted один раз,
+// а вернувшийся в фид - событием inserted.
+type changeTracker struct {
+	u       *PostgresUpdater
+	tx      *sql.Tx
+	temp    string
+	summary ChangeSummary
+}
+
+func (c *changeTracker) table() string {
+	return c.u.Schema + "." + c.u.TableName
+}
+
+func (c *changeTracker) events() string {
+	return c.u.Schema + "." + changeEventsTable
+}
+
+// latestKind - подзапрос последнего события ключа main.<key>.
+func (c *changeTracker) latestKind() string {
+	return fmt.Sprintf(`(SELECT e.kind FROM %s e WHERE e.table_name = $1::text AND e.key_value = main.%s::text ORDER BY e.id DESC LIMIT 1)`,
+		c.events(), c.u.key())
+}
+
+// valueColumns - колонки, изменения которых отслеживаются (все, кроме ключа).
+func (c *changeTracker) valueColumns() []string {
+	cols := make([]string, 0, len(c.u.Columns))
+	for _, col := range c.u.Columns {
+		if col != c.u.key() {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// recordInserted вызывается до вставки новых строк: новые ключи и ключи, вернувшиеся в фид.
+func (c *changeTracker) recordInserted(ctx context.Context) error {
+	key := c.u.key()
+	newKeys := fmt.Sprintf(`
+		INSERT INTO %s (table_name, key_value, kind)
+		SELECT DISTINCT $1::text, temp.%s::text, $2::text
+		FROM %s AS temp
+		LEFT JOIN %s AS main ON temp.%s = main.%s
+		WHERE main.%s IS NULL AND temp.%s IS NOT NULL
+	`, c.events(), key, c.temp, c.table(), key, key, key, key)
+	inserted, err := c.exec(ctx, newKeys, ChangeInserted)
+	if err != nil {
+		return fmt.Errorf("record inserted keys error: %w", err)
+	}
+
+	returned := fmt.Sprintf(`
+		INSERT INTO %s (table_name, key_value, kind)
+		SELECT DISTINCT $1::text, main.%s::text, $2::text
+		FROM %s AS main
+		JOIN %s AS temp ON temp.%s = main.%s
+		WHERE %s = '%s'
+	`, c.events(), key, c.table(), c.temp, key, key, c.latestKind(), ChangeDeleted)
+	restored, err := c.exec(ctx, returned, ChangeInserted)
+	if err != nil {
+		return fmt.Errorf("record restored keys error: %w", err)
+	}
+
+	c.summary.Inserted = inserted + restored
+	return nil
+}
+
+// recordUpdated вызывается до обновления строк: ключи с изменившимися колонками. Повторы ключа
+// удалены из временной таблицы заранее (dedupeQuery), поэтому событие совпадает с записанным значением.
+func (c *changeTracker) recordUpdated(ctx context.Context) error {
+	cols := c.valueColumns()
+	if len(cols) == 0 {
+		return nil
+	}
+	key := c.u.key()
+	cases := make([]string, 0, len(cols))
+	for _, col := range cols {
+		cases = append(cases, fmt.Sprintf(`CASE WHEN main.%s IS DISTINCT FROM temp.%s THEN '%s' END`, col, col, col))
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (table_name, key_value, kind, changed_columns)
+		SELECT $1::text, diff.key_value, $2::text, diff.changed_columns
+		FROM (
+			SELECT main.%s::text AS key_value,
+			       ARRAY_REMOVE(ARRAY[%s]::TEXT[], NULL) AS changed_columns
+			FROM %s AS main
+			JOIN %s AS temp ON temp.%s = main.%s
+		) AS diff
+		WHERE cardinality(diff.changed_columns) > 0
+	`, c.events(), key, strings.Join(cases, ", "), c.table(), c.temp, key, key)
+	updated, err := c.exec(ctx, query, ChangeUpdated)
+	if err != nil {
+		return fmt.Errorf("record updated keys error: %w", err)
+	}
+	c.summary.Updated = updated
+	return nil
+}
+
+// applyUpdates переносит изменившиеся значения из временной таблицы в основную.
+func (c *changeTracker) applyUpdates(ctx context.Context) error {
+	cols := c.valueColumns()
+	if len(cols) == 0 {
+		return nil
+	}
+	key := c.u.key()
+	sets := make([]string, 0, len(cols))
+	diffs := make([]string, 0, len(cols))
+	for _, col := range cols {
+		sets = append(sets, fmt.Sprintf("%s = temp.%s", col, col))
+		diffs = append(diffs, fmt.Sprintf("main.%s IS DISTINCT FROM temp.%s", col, col))
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %s AS main
+		SET %s
+		FROM %s AS temp
+		WHERE temp.%s = main.%s AND (%s)
+	`, c.table(), strings.Join(sets, ", "), c.temp, key, key, strings.Join(diffs, " OR "))
+	if _, err := c.tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("update execution error: %w", err)
+	}
+	return nil
+}
+
+// recordDeleted отмечает ключи, пропавшие из фида. Пустой фид не считается удалением всех строк.
+func (c *changeTracker) recordDeleted(ctx context.Context, copied int) error {
+	if copied == 0 {
+		log.Printf("Фид %s пустой, удаления не отмечаются", c.table())
+		return nil
+	}
+	key := c.u.key()
+	query := fmt.Sprintf(`
+		INSERT INTO %s (table_name, key_value, kind)
+		SELECT $1::text, main.%s::text, $2::text
+		FROM %s AS main
+		WHERE main.%s IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM %s AS temp WHERE temp.%s = main.%s)
+		  AND COALESCE(%s, '') <> '%s'
+	`, c.events(), key, c.table(), key, c.temp, key, key, c.latestKind(), ChangeDeleted)
+	deleted, err := c.exec(ctx, query, ChangeDeleted)
+	if err != nil {
+		return fmt.Errorf("record deleted keys error: %w", err)
+	}
+	c.summary.Deleted = deleted
+	return nil
+}
+
+func (c *changeTracker) exec(ctx context.Context, query, kind string) (int64, error) {
+	res, err := c.tx.ExecContext(ctx, query, c.table(), kind)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -2,16 +2,26 @@ package csv_to_postgres
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/transform"
 	"io"
 	"log"
+	"strings"
+)
+
+const (
+	EncodingWindows1251 = "windows-1251"
+	EncodingUTF8        = "utf-8"
 )
 
 // Processor отвечает за чтение и фильтрацию CSV данных.
+// Пустые Encoding и Delimiter - Windows-1251 и ';', как в фидах sexoptovik.
 type Processor struct {
-	Columns []string
+	Columns   []string
+	Encoding  string
+	Delimiter rune
 }
 
 // NewProcessor создаёт новый Processor.
@@ -27,61 +37,162 @@ func (p *Processor) SetNewColumnNaming(columns []string) *Processor {
 	return p
 }
 
-// ProcessCSV читает CSV данные из reader, декодируя из Windows-1251, и возвращает двумерный срез строк.
-func (p *Processor) ProcessCSV(reader io.Reader, renaming []string) ([][]string, error) {
-	decoder := transform.NewReader(reader, charmap.Windows1251.NewDecoder())
+// Parse реализует Parser для CSV.
+func (p *Processor) Parse(reader io.Reader) (RowSource, error) {
+	return p.Stream(reader)
+}
+
+// RowSource отдаёт строки по одной. По окончании данных Next возвращает io.EOF.
+type RowSource interface {
+	Next() ([]string, error)
+}
+
+// RowStream построчно читает CSV и отдаёт строки, разложенные по колонкам Processor.
+// В памяти держится только текущая строка. Строки, которые не удалось разобрать, пропускаются.
+type RowStream struct {
+	reader  *csv.Reader
+	index   []int
+	pending []string
+
+	ParseStats
+}
+
+// Stream начинает потоковое чтение CSV из reader. Если первая строка - заголовок, колонки ищутся по именам,
+// иначе колонки документа должны идти в порядке Columns.
+func (p *Processor) Stream(reader io.Reader) (*RowStream, error) {
+	decoder, err := decodeReader(reader, p.Encoding)
+	if err != nil {
+		return nil, err
+	}
 	csvReader := csv.NewReader(decoder)
 	csvReader.Comma = ';'
+	if p.Delimiter != 0 {
+		csvReader.Comma = p.Delimiter
+	}
 	csvReader.LazyQuotes = true
 	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
 
-	allRows, err := csvReader.ReadAll()
+	s := &RowStream{reader: csvReader}
+	first, err := s.readRecord()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("csv data is empty")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("csv read error: %w", err)
 	}
-	if len(allRows) == 0 {
-		return nil, fmt.Errorf("csv data is empty")
+
+	s.index = make([]int, len(p.Columns))
+	if p.isHeader(first) {
+		for i, col := range p.Columns {
+			s.index[i] = indexOf(first, col)
+		}
+	} else {
+		for i := range p.Columns {
+			s.index[i] = i
+		}
+		s.pending = s.mapRow(first)
 	}
+	return s, nil
+}
 
-	var header []string
-	var data [][]string
+func decodeReader(reader io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToLower(encoding) {
+	case "", EncodingWindows1251, "cp1251":
+		return transform.NewReader(reader, charmap.Windows1251.NewDecoder()), nil
+	case EncodingUTF8, "utf8":
+		return reader, nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
 
-	if len(allRows) > 0 && p.isHeader(allRows[0]) {
-		header = allRows[0]
-		data = allRows[1:]
-	} else {
-		header = p.Columns
-		data = allRows
+// Next возвращает следующую строку в порядке колонок Processor. Отсутствующие значения - пустые строки.
+func (s *RowStream) Next() ([]string, error) {
+	if s.pending != nil {
+		row := s.pending
+		s.pending = nil
+		s.Read++
+		return row, nil
 	}
 
-	columnMap := make(map[string]int)
-	for i, col := range header {
-		columnMap[col] = i
+	record, err := s.readRecord()
+	if err != nil {
+		return nil, err
 	}
+	s.Read++
+	return s.mapRow(record), nil
+}
 
-	filteredRows := [][]string{p.Columns}
-	for _, row := range data {
-		filteredRow := make([]string, len(p.Columns))
-		for i, col := range p.Columns {
-			if idx, ok := columnMap[col]; ok && idx < len(row) {
-				filteredRow[i] = row[idx]
-			} else {
-				filteredRow[i] = ""
-			}
+// readRecord читает запись CSV, пропуская и логируя битые строки.
+func (s *RowStream) readRecord() ([]string, error) {
+	for {
+		record, err := s.reader.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			s.reject(fmt.Sprintf("строка CSV %d: %v", parseErr.StartLine, parseErr.Err))
+			log.Printf("Пропущена строка CSV %d: %v", parseErr.StartLine, parseErr.Err)
+			continue
 		}
-		filteredRows = append(filteredRows, filteredRow)
+		return record, err
+	}
+}
+
+func (s *RowStream) mapRow(record []string) []string {
+	row := make([]string, len(s.index))
+	for i, idx := range s.index {
+		if idx >= 0 && idx < len(record) {
+			row[i] = record[idx]
+		}
+	}
+	return row
+}
+
+// ProcessCSV читает CSV данные из reader в кодировке Encoding и возвращает двумерный срез строк.
+// Первая строка результата - заголовок (Columns или renaming). Для больших файлов используйте Stream.
+func (p *Processor) ProcessCSV(reader io.Reader, renaming []string) ([][]string, error) {
+	stream, err := p.Stream(reader)
+	if err != nil {
+		return nil, err
 	}
 
+	header := append([]string(nil), p.Columns...)
 	if renaming != nil && len(renaming) == len(p.Columns) {
-		for i := range filteredRows[0] {
-			log.Printf("Переименование колонки [%s] в [%s]", filteredRows[0][i], renaming[i])
-			filteredRows[0][i] = renaming[i]
+		for i := range header {
+			log.Printf("Переименование колонки [%s] в [%s]", header[i], renaming[i])
+			header[i] = renaming[i]
 		}
 	}
 
+	filteredRows := [][]string{header}
+	for {
+		row, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv read error: %w", err)
+		}
+		filteredRows = append(filteredRows, row)
+	}
 	return filteredRows, nil
 }
 
+// sliceSource отдаёт строки уже прочитанного CSV, пропуская заголовок.
+type sliceSource struct {
+	rows [][]string
+	pos  int
+}
+
+func (s *sliceSource) Next() ([]string, error) {
+	if s.pos >= len(s.rows) {
+		return nil, io.EOF
+	}
+	row := s.rows[s.pos]
+	s.pos++
+	return row, nil
+}
+
 func (p *Processor) isHeader(row []string) bool {
 	for _, col := range p.Columns {
 		if indexOf(row, col) >= 0 {
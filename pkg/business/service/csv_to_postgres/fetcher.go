@@ -1,34 +1,196 @@
 package csv_to_postgres
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
+	"gomarketplace_api/config"
 	"io"
-	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
+// defaultDialTimeout - таймаут соединения источников, для которых он не задан в конфиге.
+const defaultDialTimeout = 30 * time.Second
+
+// ErrNotModified возвращается, если источник сообщил, что файл не изменился с прошлой загрузки.
+var ErrNotModified = errors.New("source not modified")
+
 // Fetcher определяет интерфейс для получения данных по URL
 type Fetcher interface {
-	Fetch(url string) (io.ReadCloser, error)
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// ModTimeFetcher - источник, который знает время изменения файла. Нужен импортам без inf-файла.
+type ModTimeFetcher interface {
+	ModTime(ctx context.Context, url string) (time.Time, error)
 }
 
-type HTTPFetcher struct {
-	Client *http.Client
+// Validators - версия файла из ответа источника: ETag и Last-Modified.
+type Validators struct {
+	ETag         string
+	LastModified string
 }
 
-func NewHTTPFetcher() *HTTPFetcher {
-	return &HTTPFetcher{
-		Client: &http.Client{},
+// ConditionalFetcher - источник с условной загрузкой. FetchIfModified возвращает ErrNotModified, если файл
+// не изменился с версии validators, иначе - файл и его новую версию. Версии хранит вызывающий.
+type ConditionalFetcher interface {
+	FetchIfModified(ctx context.Context, url string, validators Validators) (io.ReadCloser, Validators, error)
+}
+
+// configValidator - источник, которому для работы нужны настройки из конфига.
+type configValidator interface {
+	Validate() error
+}
+
+// SchemeFetcher выбирает источник по схеме URL. Путь без схемы считается локальным файлом.
+type SchemeFetcher struct {
+	fetchers map[string]Fetcher
+}
+
+// NewFetcher создаёт источники по конфигу. S3 доступен, только если он описан в конфиге.
+func NewFetcher(cfg *config.FetchersConfig) *SchemeFetcher {
+	if cfg == nil {
+		cfg = &config.FetchersConfig{}
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+
+	httpFetcher := NewHTTPFetcherFromConfig(cfg.HTTP)
+	f := &SchemeFetcher{fetchers: map[string]Fetcher{
+		"http":  httpFetcher,
+		"https": httpFetcher,
+		"file":  NewFileFetcher(cfg.FileRoot),
+		"ftp":   NewFTPFetcher(timeout),
+		"sftp":  NewSFTPFetcher(cfg.SFTP, timeout),
+	}}
+	if cfg.S3 != nil {
+		f.fetchers["s3"] = NewS3Fetcher(*cfg.S3, httpFetcher)
 	}
+	return f
 }
 
-func (f *HTTPFetcher) Fetch(url string) (io.ReadCloser, error) {
-	resp, err := f.Client.Get(url)
+func (f *SchemeFetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	fetcher, err := f.fetcher(rawURL)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	return fetcher.Fetch(ctx, rawURL)
+}
+
+func (f *SchemeFetcher) ModTime(ctx context.Context, rawURL string) (time.Time, error) {
+	fetcher, err := f.fetcher(rawURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	modTimer, ok := fetcher.(ModTimeFetcher)
+	if !ok {
+		return time.Time{}, fmt.Errorf("source %s does not report modification time, inf_url is required", RedactURL(rawURL))
+	}
+	return modTimer.ModTime(ctx, rawURL)
+}
+
+// FetchIfModified загружает файл условно, если источник это умеет, иначе - целиком без версии.
+func (f *SchemeFetcher) FetchIfModified(ctx context.Context, rawURL string, validators Validators) (io.ReadCloser, Validators, error) {
+	fetcher, err := f.fetcher(rawURL)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	if conditional, ok := fetcher.(ConditionalFetcher); ok {
+		return conditional.FetchIfModified(ctx, rawURL, validators)
+	}
+	body, err := fetcher.Fetch(ctx, rawURL)
+	return body, Validators{}, err
+}
+
+// Supports сообщает, есть ли для схемы URL источник и настроен ли он.
+func (f *SchemeFetcher) Supports(rawURL string) error {
+	fetcher, err := f.fetcher(rawURL)
+	if err != nil {
+		return err
 	}
-	return resp.Body, nil
+	if validator, ok := fetcher.(configValidator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+func (f *SchemeFetcher) fetcher(rawURL string) (Fetcher, error) {
+	scheme := "file"
+	if i := strings.Index(rawURL, "://"); i > 0 {
+		scheme = strings.ToLower(rawURL[:i])
+	}
+	fetcher, ok := f.fetchers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source %q: no fetcher for scheme %s", RedactURL(rawURL), scheme)
+	}
+	return fetcher, nil
+}
+
+// parseSourceURL разбирает URL источника с обязательным хостом.
+func parseSourceURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("invalid source url %q: %w", RedactURL(rawURL), err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid source url %q: host is required", u.Redacted())
+	}
+	return u, nil
+}
+
+// RedactURL заменяет пароль в URL источника на xxxxx. URL импортов попадают в логи, metadata
+// и журнал импорта, а логин и пароль ftp/sftp задаются прямо в них.
+func RedactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Redacted()
+	}
+	// URL не разбирается - userinfo вырезается целиком
+	i := strings.Index(rawURL, "://")
+	if i < 0 {
+		return rawURL
+	}
+	rest := rawURL[i+3:]
+	authority := rest
+	if j := strings.IndexAny(rest, "/?#"); j >= 0 {
+		authority = rest[:j]
+	}
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		return rawURL[:i+3] + "xxxxx" + rest[at:]
+	}
+	return rawURL
+}
+
+// gunzip распаковывает gzip-файлы (определяются по сигнатуре), остальные отдаёт как есть.
+func gunzip(body io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	magic, err := buffered.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return readCloser{Reader: buffered, closer: body}, nil
+	}
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("gzip error: %w", err)
+	}
+	return readCloser{Reader: gz, closer: body}, nil
+}
+
+// readCloser читает из Reader и закрывает исходный поток.
+type readCloser struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (r readCloser) Close() error {
+	return r.closer.Close()
 }
@@ -0,0 +1,108 @@
+package csv_to_postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileFetcher читает файлы с локального диска: file:///path/to/file.csv или путь без схемы.
+// Если путь - каталог, берётся последний изменённый файл каталога (для выгрузок, которые кладут вручную).
+// Root ограничивает доступные пути каталогом; пустой Root - без ограничения.
+type FileFetcher struct {
+	Root string
+}
+
+func NewFileFetcher(root string) *FileFetcher {
+	return &FileFetcher{Root: root}
+}
+
+func (f *FileFetcher) Fetch(_ context.Context, rawURL string) (io.ReadCloser, error) {
+	path, err := f.resolve(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return gunzip(file)
+}
+
+func (f *FileFetcher) ModTime(_ context.Context, rawURL string) (time.Time, error) {
+	path, err := f.resolve(rawURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// resolve возвращает путь к файлу, проверяя, что он внутри Root.
+func (f *FileFetcher) resolve(rawURL string) (string, error) {
+	path := rawURL
+	if strings.HasPrefix(strings.ToLower(rawURL), "file://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid file url %q: %w", rawURL, err)
+		}
+		path = u.Path
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	if f.Root != "" {
+		root, err := filepath.Abs(f.Root)
+		if err != nil {
+			return "", err
+		}
+		if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("file %s is outside of %s", path, root)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+	return latestFile(path)
+}
+
+// latestFile возвращает последний изменённый файл каталога, пропуская скрытые файлы.
+func latestFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = filepath.Join(dir, entry.Name()), info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("directory %s has no files", dir)
+	}
+	return latest, nil
+}
@@ -0,0 +1,161 @@
+package csv_to_postgres
+
+import (
+	"context"
+	"fmt"
+	"gomarketplace_api/config"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultHTTPRetries = 3
+	defaultHTTPBackoff = time.Second
+)
+
+// HTTPFetcher загружает файлы по HTTP с повторами. Fetch загружает файл всегда, FetchIfModified - условным
+// запросом с If-None-Match и If-Modified-Since. Тело ответа не ограничено по времени - оно читается потоково
+// во время импорта.
+type HTTPFetcher struct {
+	Client  *http.Client
+	Retries int
+	Backoff time.Duration
+}
+
+func NewHTTPFetcher() *HTTPFetcher {
+	return NewHTTPFetcherFromConfig(nil)
+}
+
+func NewHTTPFetcherFromConfig(cfg *config.HTTPFetcherConfig) *HTTPFetcher {
+	if cfg == nil {
+		cfg = &config.HTTPFetcherConfig{}
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	retries := defaultHTTPRetries
+	if cfg.Retries != nil && *cfg.Retries >= 0 {
+		retries = *cfg.Retries
+	}
+	backoff := cfg.Backoff
+	if backoff <= 0 {
+		backoff = defaultHTTPBackoff
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	return &HTTPFetcher{
+		Client:  &http.Client{Transport: transport},
+		Retries: retries,
+		Backoff: backoff,
+	}
+}
+
+// Fetch загружает файл. Сжатие gzip (Content-Encoding или .gz-файл) снимается.
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	body, _, err := f.FetchIfModified(ctx, url, Validators{})
+	return body, err
+}
+
+// FetchIfModified загружает файл, если он изменился с версии validators; на ответ 304 возвращает ErrNotModified.
+func (f *HTTPFetcher) FetchIfModified(ctx context.Context, url string, validators Validators) (io.ReadCloser, Validators, error) {
+	resp, err := f.do(ctx, http.MethodGet, url, validators)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, validators, ErrNotModified
+	}
+	body, err := gunzip(resp.Body)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	return body, Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}, nil
+}
+
+// ModTime возвращает время из заголовка Last-Modified.
+func (f *HTTPFetcher) ModTime(ctx context.Context, url string) (time.Time, error) {
+	resp, err := f.do(ctx, http.MethodHead, url, Validators{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+
+	lastModified := resp.Header.Get("Last-Modified")
+	if lastModified == "" {
+		return time.Time{}, fmt.Errorf("%s: no Last-Modified header, inf_url is required", RedactURL(url))
+	}
+	return http.ParseTime(lastModified)
+}
+
+// do выполняет запрос с повторами. Непустые validators отправляются условными заголовками.
+// Возвращает ответ 200 или 304.
+func (f *HTTPFetcher) do(ctx context.Context, method, url string, validators Validators) (*http.Response, error) {
+	resp, err := f.retry(ctx, url, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source url %q", RedactURL(url))
+		}
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// retry отправляет запрос из newRequest с повторами при сетевых ошибках, 429 и 5xx; запрос строится
+// заново на каждую попытку. Остальные ответы возвращаются как есть - статус проверяет вызывающий.
+// Отмена ctx прерывает и запрос, и ожидание повтора.
+func (f *HTTPFetcher) retry(ctx context.Context, url string, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	backoff := f.Backoff
+	var lastErr error
+	for attempt := 0; attempt <= f.Retries; attempt++ {
+		if attempt > 0 {
+			log.Printf("Повтор %d/%d загрузки %s через %v: %v", attempt, f.Retries, RedactURL(url), backoff, lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := f.Client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("fetch %s failed after %d attempts: %w", RedactURL(url), f.Retries+1, lastErr)
+}
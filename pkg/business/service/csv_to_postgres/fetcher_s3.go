@@ -0,0 +1,137 @@
+package csv_to_postgres
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gomarketplace_api/config"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash - SHA-256 пустого тела запроса для подписи GET и HEAD.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Fetcher читает объекты S3-совместимого хранилища по URL вида s3://bucket/path/to/key.
+// Запросы подписываются AWS Signature V4 и повторяются так же, как в HTTPFetcher.
+type S3Fetcher struct {
+	cfg  config.S3FetcherConfig
+	http *HTTPFetcher
+}
+
+// NewS3Fetcher создаёт источник S3 с клиентом и повторами httpFetcher; nil - настройки HTTP по умолчанию.
+func NewS3Fetcher(cfg config.S3FetcherConfig, httpFetcher *HTTPFetcher) *S3Fetcher {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3.amazonaws.com"
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if httpFetcher == nil {
+		httpFetcher = NewHTTPFetcher()
+	}
+	return &S3Fetcher{cfg: cfg, http: httpFetcher}
+}
+
+func (f *S3Fetcher) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	resp, err := f.do(ctx, http.MethodGet, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return gunzip(resp.Body)
+}
+
+func (f *S3Fetcher) ModTime(ctx context.Context, rawURL string) (time.Time, error) {
+	resp, err := f.do(ctx, http.MethodHead, rawURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+	return http.ParseTime(resp.Header.Get("Last-Modified"))
+}
+
+func (f *S3Fetcher) do(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	// подпись содержит время, поэтому запрос подписывается заново на каждую попытку
+	resp, err := f.http.retry(ctx, rawURL, func() (*http.Request, error) {
+		return f.request(ctx, method, rawURL)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: unexpected status code %d: %s", method, RedactURL(rawURL), resp.StatusCode, body)
+	}
+	return resp, nil
+}
+
+// request строит подписанный запрос к объекту s3://bucket/key.
+func (f *S3Fetcher) request(ctx context.Context, method, rawURL string) (*http.Request, error) {
+	source, err := parseSourceURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := url.Parse(f.cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %q: %w", f.cfg.Endpoint, err)
+	}
+
+	bucket, key := source.Host, strings.TrimPrefix(source.Path, "/")
+	target := *endpoint
+	if f.cfg.PathStyle {
+		target.Path = "/" + bucket + "/" + key
+	} else {
+		target.Host = bucket + "." + endpoint.Host
+		target.Path = "/" + key
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	f.sign(req, time.Now().UTC())
+	return req, nil
+}
+
+// sign добавляет заголовки AWS Signature V4.
+func (f *S3Fetcher) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + f.cfg.Region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + emptyPayloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+f.cfg.SecretKey), date)
+	key = hmacSHA256(key, f.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		f.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,96 @@
+package csv_to_postgres
+
+import (
+	"context"
+	"gomarketplace_api/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer отвечает статусами из statuses по очереди, последний повторяется; 200 - с телом body.
+func statusServer(t *testing.T, body string, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&attempts, 1))
+		status := statuses[len(statuses)-1]
+		if n <= len(statuses) {
+			status = statuses[n-1]
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			io.WriteString(w, body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func TestHTTPFetcherRetries(t *testing.T) {
+	retries := func(n int) *int { return &n }
+	tests := []struct {
+		name         string
+		retries      *int
+		statuses     []int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "по умолчанию", statuses: []int{http.StatusServiceUnavailable}, wantAttempts: defaultHTTPRetries + 1, wantErr: true},
+		{name: "без повторов", retries: retries(0), statuses: []int{http.StatusServiceUnavailable}, wantAttempts: 1, wantErr: true},
+		{name: "два повтора", retries: retries(2), statuses: []int{http.StatusTooManyRequests}, wantAttempts: 3, wantErr: true},
+		{name: "успех после повтора", retries: retries(2), statuses: []int{http.StatusBadGateway, http.StatusOK}, wantAttempts: 2},
+		{name: "404 не повторяется", retries: retries(2), statuses: []int{http.StatusNotFound}, wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, attempts := statusServer(t, "feed", tt.statuses...)
+			fetcher := NewHTTPFetcherFromConfig(&config.HTTPFetcherConfig{Retries: tt.retries, Backoff: time.Millisecond})
+
+			body, err := fetcher.Fetch(context.Background(), server.URL+"/feed.csv")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				data, _ := io.ReadAll(body)
+				body.Close()
+				if string(data) != "feed" {
+					t.Errorf("body = %q, want feed", data)
+				}
+			}
+			if got := atomic.LoadInt32(attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestS3FetcherRetries(t *testing.T) {
+	retries := 1
+	httpFetcher := NewHTTPFetcherFromConfig(&config.HTTPFetcherConfig{Retries: &retries, Backoff: time.Millisecond})
+
+	server, attempts := statusServer(t, "feed", http.StatusInternalServerError, http.StatusOK)
+	fetcher := NewS3Fetcher(config.S3FetcherConfig{Endpoint: server.URL, PathStyle: true}, httpFetcher)
+	body, err := fetcher.Fetch(context.Background(), "s3://bucket/feed.csv")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "feed" || atomic.LoadInt32(attempts) != 2 {
+		t.Errorf("body = %q after %d attempts, want feed after 2", data, atomic.LoadInt32(attempts))
+	}
+
+	server, attempts = statusServer(t, "", http.StatusForbidden)
+	fetcher = NewS3Fetcher(config.S3FetcherConfig{Endpoint: server.URL, PathStyle: true}, httpFetcher)
+	if _, err := fetcher.Fetch(context.Background(), "s3://bucket/feed.csv"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Fetch error = %v, want status 403", err)
+	}
+	if got := atomic.LoadInt32(attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1 (403 is not retried)", got)
+	}
+}
@@ -0,0 +1,279 @@
+package csv_to_postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gomarketplace_api/config"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// identifierPattern - допустимые имена схем, таблиц и колонок: они подставляются в SQL через fmt.Sprintf.
+var identifierPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// JobRunner выполняет импорты, описанные в конфиге (config.ImportJobConfig), одним общим Updater'ом.
+// Каждый запуск записывается в журнал <схема>.import_runs.
+type JobRunner struct {
+	db      *sql.DB
+	fetcher Fetcher
+	journal *RunJournal
+	jobs    []config.ImportJobConfig
+}
+
+// NewJobRunner проверяет описания импортов и создаёт runner. Ошибки всех импортов возвращаются разом.
+func NewJobRunner(db *sql.DB, fetcher Fetcher, jobs []config.ImportJobConfig) (*JobRunner, error) {
+	if err := ValidateJobs(jobs); err != nil {
+		return nil, err
+	}
+	if schemes, ok := fetcher.(*SchemeFetcher); ok {
+		if err := validateSources(schemes, jobs); err != nil {
+			return nil, err
+		}
+	}
+	return &JobRunner{db: db, fetcher: fetcher, journal: NewRunJournal(db), jobs: jobs}, nil
+}
+
+// ValidateJobs проверяет описания импортов без обращения к БД.
+func ValidateJobs(jobs []config.ImportJobConfig) error {
+	var errs []error
+	names := make(map[string]bool, len(jobs))
+	metadataKeys := make(map[string]string, len(jobs))
+	for i, job := range jobs {
+		if job.Name == "" {
+			errs = append(errs, fmt.Errorf("import #%d: name is required", i+1))
+			continue
+		}
+		if names[job.Name] {
+			errs = append(errs, fmt.Errorf("import %q: duplicate name", job.Name))
+		}
+		names[job.Name] = true
+
+		if other, ok := metadataKeys[job.MetadataKey]; ok && job.MetadataKey != "" {
+			errs = append(errs, fmt.Errorf("import %q: metadata_key %q already used by %q", job.Name, job.MetadataKey, other))
+		}
+		metadataKeys[job.MetadataKey] = job.Name
+
+		for _, err := range validateJob(job) {
+			errs = append(errs, fmt.Errorf("import %q: %w", job.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateSources проверяет, что для inf_url и csv_url импортов есть источники.
+func validateSources(fetcher *SchemeFetcher, jobs []config.ImportJobConfig) error {
+	var errs []error
+	for _, job := range jobs {
+		for _, source := range []string{job.InfURL, job.CSVURL} {
+			if source == "" {
+				continue
+			}
+			if err := fetcher.Supports(source); err != nil {
+				errs = append(errs, fmt.Errorf("import %q: %w", job.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateJob(job config.ImportJobConfig) []error {
+	var errs []error
+	if job.CSVURL == "" {
+		errs = append(errs, errors.New("csv_url is required"))
+	}
+	if job.MetadataKey == "" {
+		errs = append(errs, errors.New("metadata_key is required"))
+	}
+	if _, err := newJobParser(job); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := decodeReader(strings.NewReader(""), job.Encoding); err != nil {
+		errs = append(errs, err)
+	}
+	if job.Delimiter != "" && utf8.RuneCountInString(job.Delimiter) != 1 {
+		errs = append(errs, fmt.Errorf("delimiter %q must be a single character", job.Delimiter))
+	}
+	for _, identifier := range []struct{ field, value string }{{"schema", job.Schema}, {"table", job.Table}} {
+		if !identifierPattern.MatchString(identifier.value) {
+			errs = append(errs, fmt.Errorf("invalid %s %q", identifier.field, identifier.value))
+		}
+	}
+
+	if len(job.Columns) == 0 {
+		errs = append(errs, errors.New("columns are required"))
+		return errs
+	}
+	targets := make(map[string]bool, len(job.Columns))
+	for _, column := range job.Columns {
+		if column.Source == "" {
+			errs = append(errs, fmt.Errorf("column %q: source is required", column.Target))
+		}
+		if !identifierPattern.MatchString(column.Target) {
+			errs = append(errs, fmt.Errorf("invalid target column %q", column.Target))
+		}
+		if targets[column.Target] {
+			errs = append(errs, fmt.Errorf("duplicate target column %q", column.Target))
+		}
+		targets[column.Target] = true
+	}
+	if job.KeyColumn != "" && !targets[job.KeyColumn] {
+		errs = append(errs, fmt.Errorf("key_column %q is not among target columns", job.KeyColumn))
+	}
+	if job.History != nil {
+		if !identifierPattern.MatchString(job.History.Table) {
+			errs = append(errs, fmt.Errorf("invalid history table %q", job.History.Table))
+		}
+		if !targets[job.History.Column] || job.History.Column == keyColumn(job) {
+			errs = append(errs, fmt.Errorf("history column %q must be a non-key target column", job.History.Column))
+		}
+	}
+	return errs
+}
+
+// CheckTables проверяет, что целевые таблицы и колонки импортов существуют в БД.
+// Ловит импорты, направленные не в ту таблицу.
+func (r *JobRunner) CheckTables(ctx context.Context) error {
+	var errs []error
+	for _, job := range r.jobs {
+		existing, err := r.tableColumns(ctx, job.Schema, job.Table)
+		if err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			errs = append(errs, fmt.Errorf("import %q: table %s.%s does not exist", job.Name, job.Schema, job.Table))
+			continue
+		}
+		for _, column := range job.Columns {
+			if !existing[column.Target] {
+				errs = append(errs, fmt.Errorf("import %q: column %q not found in %s.%s", job.Name, column.Target, job.Schema, job.Table))
+			}
+		}
+
+		if job.TrackChanges {
+			events, err := r.tableColumns(ctx, job.Schema, changeEventsTable)
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				errs = append(errs, fmt.Errorf("import %q: change events table %s.%s does not exist", job.Name, job.Schema, changeEventsTable))
+			}
+		}
+
+		if job.History == nil {
+			continue
+		}
+		history, err := r.tableColumns(ctx, job.Schema, job.History.Table)
+		if err != nil {
+			return err
+		}
+		for _, column := range []string{keyColumn(job), "old_" + job.History.Column, "new_" + job.History.Column, "changed_at"} {
+			if !history[column] {
+				errs = append(errs, fmt.Errorf("import %q: column %q not found in history table %s.%s", job.Name, column, job.Schema, job.History.Table))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *JobRunner) tableColumns(ctx context.Context, schema, table string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2`, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s.%s: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		columns[column] = true
+	}
+	return columns, rows.Err()
+}
+
+// Names возвращает имена импортов в порядке конфига.
+func (r *JobRunner) Names() []string {
+	names := make([]string, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		names = append(names, job.Name)
+	}
+	return names
+}
+
+// Run выполняет импорт с указанным именем.
+func (r *JobRunner) Run(ctx context.Context, name string) error {
+	for _, job := range r.jobs {
+		if job.Name == name {
+			return r.run(ctx, job)
+		}
+	}
+	return fmt.Errorf("import %q not found", name)
+}
+
+// RunAll выполняет все импорты по порядку, каждый со своим таймаутом. Упавший импорт не останавливает остальные.
+func (r *JobRunner) RunAll(ctx context.Context, timeout time.Duration) error {
+	var errs []error
+	for _, job := range r.jobs {
+		jobCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := r.run(jobCtx, job); err != nil {
+			log.Printf("Импорт %s завершился ошибкой: %v", job.Name, err)
+			errs = append(errs, fmt.Errorf("import %q: %w", job.Name, err))
+		}
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+func (r *JobRunner) run(ctx context.Context, job config.ImportJobConfig) error {
+	targets := make([]string, 0, len(job.Columns))
+	for _, column := range job.Columns {
+		targets = append(targets, column.Target)
+	}
+
+	parser, err := newJobParser(job)
+	if err != nil {
+		return err
+	}
+
+	dbUpdater := NewPostgresUpdater(r.db, job.Schema, job.Table, targets)
+	dbUpdater.KeyColumn = keyColumn(job)
+	dbUpdater.TrackChanges = job.TrackChanges
+	if job.History != nil {
+		dbUpdater.SetHistory(&HistoryTracking{Table: job.History.Table, Column: job.History.Column})
+	}
+
+	log.Printf("Импорт %s: %s -> %s.%s", job.Name, RedactURL(job.CSVURL), job.Schema, job.Table)
+	return NewUpdater(job.InfURL, job.CSVURL, job.MetadataKey, r.fetcher, nil, dbUpdater).
+		SetParser(parser).
+		SetJournal(r.journal, job.Name).
+		Execute(ctx, nil, r.db)
+}
+
+// newJobParser создаёт парсер файла импорта по format, encoding, delimiter и sheet.
+func newJobParser(job config.ImportJobConfig) (Parser, error) {
+	sources := make([]string, 0, len(job.Columns))
+	for _, column := range job.Columns {
+		sources = append(sources, column.Source)
+	}
+	opts := ParserOptions{Format: job.Format, Columns: sources, Encoding: job.Encoding, Sheet: job.Sheet}
+	if job.Delimiter != "" {
+		opts.Delimiter, _ = utf8.DecodeRuneInString(job.Delimiter)
+	}
+	return NewParser(opts)
+}
+
+func keyColumn(job config.ImportJobConfig) string {
+	if job.KeyColumn != "" || len(job.Columns) == 0 {
+		return job.KeyColumn
+	}
+	return job.Columns[0].Target
+}
@@ -0,0 +1,83 @@
+package csv_to_postgres
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Форматы файлов поставщиков.
+const (
+	FormatCSV   = "csv"
+	FormatXLSX  = "xlsx"
+	FormatJSONL = "jsonl"
+	FormatYML   = "yml"
+)
+
+// Parser разбирает файл поставщика в поток строк, разложенных по колонкам парсера.
+// Отсутствующие в файле значения - пустые строки.
+type Parser interface {
+	Parse(reader io.Reader) (RowSource, error)
+}
+
+// ParserOptions - настройки парсера из описания импорта. Columns - имена колонок в файле:
+// заголовки CSV и XLSX, ключи JSON (вложенные через точку), элементы и атрибуты предложений YML.
+type ParserOptions struct {
+	Format    string
+	Columns   []string
+	Encoding  string
+	Delimiter rune
+	Sheet     string
+}
+
+// NewParser создаёт парсер формата opts.Format. Пустой формат - CSV.
+func NewParser(opts ParserOptions) (Parser, error) {
+	switch strings.ToLower(opts.Format) {
+	case "", FormatCSV:
+		processor := NewProcessor(opts.Columns)
+		processor.Encoding = opts.Encoding
+		processor.Delimiter = opts.Delimiter
+		return processor, nil
+	case FormatXLSX:
+		return NewXLSXParser(opts.Columns, opts.Sheet), nil
+	case FormatJSONL:
+		return NewJSONLParser(opts.Columns, opts.Encoding), nil
+	case FormatYML, "xml":
+		return NewYMLParser(opts.Columns), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", opts.Format)
+	}
+}
+
+// ParseStats - счётчики разбора файла: отданные строки, пропущенные строки и первые причины пропуска.
+type ParseStats struct {
+	Read          int
+	Skipped       int
+	RejectSamples []string
+}
+
+// Stats возвращает счётчики разбора; через него PostgresUpdater учитывает пропущенные парсером строки.
+func (s *ParseStats) Stats() *ParseStats {
+	return s
+}
+
+func (s *ParseStats) reject(reason string) {
+	s.Skipped++
+	if len(s.RejectSamples) < maxRejectSamples {
+		s.RejectSamples = append(s.RejectSamples, reason)
+	}
+}
+
+// StatsSource - поток строк, который считает пропущенные строки.
+type StatsSource interface {
+	Stats() *ParseStats
+}
+
+// lookupRow собирает строку из значений колонок.
+func lookupRow(columns []string, value func(column string) string) []string {
+	row := make([]string, len(columns))
+	for i, col := range columns {
+		row[i] = value(col)
+	}
+	return row
+}
@@ -0,0 +1,104 @@
+package csv_to_postgres
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxJSONLineSize - максимальная длина строки JSON-lines.
+const maxJSONLineSize = 16 * 1024 * 1024
+
+// JSONLParser разбирает JSON-lines: по одному объекту на строку. Колонки - ключи объекта,
+// вложенные ключи через точку (price.value). Вложенные объекты и массивы отдаются как JSON.
+// Строки, которые не удалось разобрать, пропускаются.
+type JSONLParser struct {
+	Columns  []string
+	Encoding string
+}
+
+func NewJSONLParser(columns []string, encoding string) *JSONLParser {
+	if encoding == "" {
+		encoding = EncodingUTF8
+	}
+	return &JSONLParser{Columns: columns, Encoding: encoding}
+}
+
+func (p *JSONLParser) Parse(reader io.Reader) (RowSource, error) {
+	decoder, err := decodeReader(reader, p.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(decoder)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLineSize)
+	return &jsonlStream{parser: p, scanner: scanner}, nil
+}
+
+type jsonlStream struct {
+	parser  *JSONLParser
+	scanner *bufio.Scanner
+	line    int
+
+	ParseStats
+}
+
+func (s *jsonlStream) Next() ([]string, error) {
+	for s.scanner.Scan() {
+		s.line++
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			s.reject(fmt.Sprintf("строка JSON %d: %v", s.line, err))
+			continue
+		}
+		s.Read++
+		return lookupRow(s.parser.Columns, func(column string) string {
+			return jsonValue(object, column)
+		}), nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("jsonl read error: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// jsonValue возвращает значение по пути через точку строкой. null и отсутствующий ключ - пустая строка.
+func jsonValue(object map[string]interface{}, path string) string {
+	var value interface{} = object
+	for _, key := range strings.Split(path, ".") {
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = nested[key]
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
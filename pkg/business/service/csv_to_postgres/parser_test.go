@@ -0,0 +1,225 @@
+package csv_to_postgres
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readRows читает поток до конца.
+func readRows(t *testing.T, source RowSource) [][]string {
+	t.Helper()
+	var rows [][]string
+	for {
+		row, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func parseRows(t *testing.T, parser Parser, data []byte) ([][]string, *ParseStats) {
+	t.Helper()
+	source, err := parser.Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	rows := readRows(t, source)
+	return rows, source.(StatsSource).Stats()
+}
+
+func TestNewParserFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   Parser
+	}{
+		{format: "", want: &Processor{}},
+		{format: "CSV", want: &Processor{}},
+		{format: "xlsx", want: &XLSXParser{}},
+		{format: "jsonl", want: &JSONLParser{}},
+		{format: "yml", want: &YMLParser{}},
+		{format: "xml", want: &YMLParser{}},
+	}
+	for _, tt := range tests {
+		parser, err := NewParser(ParserOptions{Format: tt.format, Columns: []string{"id"}})
+		if err != nil {
+			t.Errorf("NewParser(%q): %v", tt.format, err)
+			continue
+		}
+		if reflect.TypeOf(parser) != reflect.TypeOf(tt.want) {
+			t.Errorf("NewParser(%q) = %T, want %T", tt.format, parser, tt.want)
+		}
+	}
+	for _, format := range []string{"json", "xls"} {
+		if _, err := NewParser(ParserOptions{Format: format}); err == nil {
+			t.Errorf("NewParser(%q) = nil error, want unsupported format", format)
+		}
+	}
+}
+
+func TestJSONLParserRows(t *testing.T) {
+	data := strings.Join([]string{
+		`{"id": 1, "name": "Кроссовки", "price": {"value": 1990.5}, "stock": true, "tags": ["a", "b"]}`,
+		``,
+		`{"id": 2, "name": null, "price": {"value": "500"}, "stock": false}`,
+		`{"id": 3, "name": "битая строка"`,
+		`[1, 2, 3]`,
+		`{"id": 4, "price": 7}`,
+	}, "\n")
+	parser := NewJSONLParser([]string{"id", "name", "price.value", "stock", "tags"}, "")
+
+	rows, stats := parseRows(t, parser, []byte(data))
+	want := [][]string{
+		{"1", "Кроссовки", "1990.5", "true", `["a","b"]`},
+		{"2", "", "500", "false", ""},
+		// price не объект - вложенного ключа нет
+		{"4", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+	if stats.Read != 3 || stats.Skipped != 2 {
+		t.Errorf("stats = %d read, %d skipped, want 3 and 2", stats.Read, stats.Skipped)
+	}
+	if len(stats.RejectSamples) != 2 || !strings.HasPrefix(stats.RejectSamples[0], "строка JSON 4:") {
+		t.Errorf("reject samples = %q, want JSON line 4 first", stats.RejectSamples)
+	}
+}
+
+func TestYMLParserRows(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<yml_catalog>
+  <shop>
+    <categories>
+      <category id="10">Обувь</category>
+      <category id="20" parentId="10">Кроссовки</category>
+    </categories>
+    <offers>
+      <offer id="A1" available="true">
+        <price>1990</price>
+        <categoryId>20</categoryId>
+        <picture>https://img/1.jpg</picture>
+        <picture>https://img/2.jpg</picture>
+        <param name="Размер"> 42 </param>
+        <param name="Цвет">белый</param>
+      </offer>
+      <offer id="A2" available="false">
+        <price>500</price>
+        <categoryId>99</categoryId>
+      </offer>
+    </offers>
+  </shop>
+</yml_catalog>`
+	parser := NewYMLParser([]string{"@id", "@available", "price", "category", "picture", "param:Размер", "vendorCode"})
+
+	rows, stats := parseRows(t, parser, []byte(data))
+	want := [][]string{
+		{"A1", "true", "1990", "Кроссовки", "https://img/1.jpg,https://img/2.jpg", "42", ""},
+		// неизвестная категория и отсутствующие элементы - пустые значения
+		{"A2", "false", "500", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+	if stats.Read != 2 {
+		t.Errorf("read = %d, want 2", stats.Read)
+	}
+}
+
+// xlsxFile собирает минимальную книгу Excel с листами name -> sheetData.
+func xlsxFile(t *testing.T, sharedStrings []string, sheets ...[2]string) []byte {
+	t.Helper()
+	var workbook, rels strings.Builder
+	files := map[string]string{}
+	for i, sheet := range sheets {
+		id := string(rune('1' + i))
+		workbook.WriteString(`<sheet name="` + sheet[0] + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		rels.WriteString(`<Relationship Id="rId` + id + `" Target="worksheets/sheet` + id + `.xml"/>`)
+		files["xl/worksheets/sheet"+id+".xml"] = `<worksheet><sheetData>` + sheet[1] + `</sheetData></worksheet>`
+	}
+	files["xl/workbook.xml"] = `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+		workbook.String() + `</sheets></workbook>`
+	files["xl/_rels/workbook.xml.rels"] = `<Relationships>` + rels.String() + `</Relationships>`
+	if sharedStrings != nil {
+		var si strings.Builder
+		for _, s := range sharedStrings {
+			si.WriteString(`<si><t>` + s + `</t></si>`)
+		}
+		files["xl/sharedStrings.xml"] = `<sst>` + si.String() + `</sst>`
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, content)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestXLSXParserRows(t *testing.T) {
+	shared := []string{"name", "id", "price", "Кроссовки"}
+	data := xlsxFile(t, shared,
+		[2]string{"Прочее", `<row r="1"><c r="A1"><v>1</v></c></row>`},
+		[2]string{"Товары", `
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="s"><v>2</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2"><v>101</v></c><c r="D2"><v>1990.5</v></c></row>
+			<row r="3"><c r="A3" t="inlineStr"><is><r><t>Кеды </t></r><r><t>белые</t></r></is></c><c r="B3"><v>102</v></c><c r="D3" t="e"><v>#N/A</v></c></row>
+			<row r="4"><c r="A4" t="inlineStr"><is><t> </t></is></c></row>
+			<row r="5"><c r="B5"><v>103</v></c></row>`})
+
+	// колонки ищутся по заголовку, пустые строки пропускаются
+	rows, stats := parseRows(t, NewXLSXParser([]string{"id", "name", "price"}, "Товары"), data)
+	want := [][]string{
+		{"101", "Кроссовки", "1990.5"},
+		{"102", "Кеды белые", ""},
+		{"103", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+	if stats.Read != 3 {
+		t.Errorf("read = %d, want 3", stats.Read)
+	}
+
+	// без заголовка колонки идут по порядку, первая строка - данные
+	rows, _ = parseRows(t, NewXLSXParser([]string{"id"}, ""), data)
+	if want := [][]string{{"1"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows without header = %q, want %q", rows, want)
+	}
+
+	if _, err := NewXLSXParser([]string{"id"}, "Нет такого").Parse(bytes.NewReader(data)); err == nil {
+		t.Error("Parse with unknown sheet = nil error")
+	}
+}
+
+func TestXLSXParserBeyondXFD(t *testing.T) {
+	data := xlsxFile(t, nil, [2]string{"Лист1", `
+		<row r="1"><c r="A1"><v>1</v></c></row>
+		<row r="2"><c r="XFE2"><v>2</v></c></row>`})
+
+	source, err := NewXLSXParser([]string{"id"}, "").Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := source.Next(); err != nil {
+		t.Fatalf("first row: %v", err)
+	}
+	if _, err := source.Next(); err == nil || !strings.Contains(err.Error(), "XFD") {
+		t.Errorf("Next = %v, want error about column XFD", err)
+	}
+}
@@ -0,0 +1,313 @@
+package csv_to_postgres
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxXLSXSize - максимальный размер файла XLSX. Архив читается в память целиком (zip требует
+// произвольного доступа), строки листа при этом разбираются потоково.
+const maxXLSXSize = 512 * 1024 * 1024
+
+// maxXLSXColumns - колонок на листе Excel: последняя - XFD.
+const maxXLSXColumns = 16384
+
+// XLSXParser разбирает лист книги Excel. Sheet - имя листа, пустое - первый лист.
+// Если первая непустая строка листа - заголовок, колонки ищутся по именам, иначе идут в порядке Columns.
+// Числа и даты отдаются так, как они хранятся в файле (даты - числом дней Excel).
+type XLSXParser struct {
+	Columns []string
+	Sheet   string
+}
+
+func NewXLSXParser(columns []string, sheet string) *XLSXParser {
+	return &XLSXParser{Columns: columns, Sheet: sheet}
+}
+
+func (p *XLSXParser) Parse(reader io.Reader) (RowSource, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, maxXLSXSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("xlsx read error: %w", err)
+	}
+	if len(data) > maxXLSXSize {
+		return nil, fmt.Errorf("xlsx file is larger than %d bytes", maxXLSXSize)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("xlsx open error: %w", err)
+	}
+
+	sheetPath, err := p.sheetPath(archive)
+	if err != nil {
+		return nil, err
+	}
+	sharedStrings, err := readSharedStrings(archive)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := archive.Open(sheetPath)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx sheet %s error: %w", sheetPath, err)
+	}
+
+	s := &xlsxStream{decoder: xml.NewDecoder(sheet), sharedStrings: sharedStrings}
+	first, err := s.readRow()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("xlsx sheet is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.index = make([]int, len(p.Columns))
+	header := NewProcessor(p.Columns).isHeader(first)
+	for i, col := range p.Columns {
+		if header {
+			s.index[i] = indexOf(first, col)
+		} else {
+			s.index[i] = i
+		}
+	}
+	if !header {
+		s.pending = s.mapRow(first)
+	}
+	return s, nil
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText - текст ячейки: простой (<t>) или из фрагментов с форматированием (<r><t>).
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+// sheetPath находит файл листа по имени через xl/workbook.xml и его связи.
+func (p *XLSXParser) sheetPath(archive *zip.Reader) (string, error) {
+	var workbook xlsxWorkbook
+	if err := readZipXML(archive, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	var rels xlsxRelationships
+	if err := readZipXML(archive, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+
+	for _, sheet := range workbook.Sheets {
+		if p.Sheet != "" && sheet.Name != p.Sheet {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if rel.ID != sheet.RID {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+		return "", fmt.Errorf("xlsx sheet %q has no relationship", sheet.Name)
+	}
+	return "", fmt.Errorf("xlsx sheet %q not found", p.Sheet)
+}
+
+func readSharedStrings(archive *zip.Reader) ([]string, error) {
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := readZipXML(archive, "xl/sharedStrings.xml", &shared); err != nil {
+		if errors.Is(err, errZipEntryNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	strs := make([]string, len(shared.Items))
+	for i, item := range shared.Items {
+		strs[i] = item.String()
+	}
+	return strs, nil
+}
+
+var errZipEntryNotFound = errors.New("zip entry not found")
+
+func readZipXML(archive *zip.Reader, name string, v interface{}) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("xlsx %s: %w", name, errZipEntryNotFound)
+	}
+	defer file.Close()
+	if err := xml.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("xlsx %s error: %w", name, err)
+	}
+	return nil
+}
+
+type xlsxStream struct {
+	decoder       *xml.Decoder
+	sharedStrings []string
+	index         []int
+	pending       []string
+
+	ParseStats
+}
+
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		Value  string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+func (s *xlsxStream) Next() ([]string, error) {
+	if s.pending != nil {
+		row := s.pending
+		s.pending = nil
+		s.Read++
+		return row, nil
+	}
+
+	record, err := s.readRow()
+	if err != nil {
+		return nil, err
+	}
+	s.Read++
+	return s.mapRow(record), nil
+}
+
+// readRow читает следующую непустую строку листа как срез значений по номерам колонок.
+func (s *xlsxStream) readRow() ([]string, error) {
+	for {
+		token, err := s.decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xlsx sheet read error: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := s.decoder.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("xlsx row error: %w", err)
+		}
+		record, err := s.cells(&row)
+		if err != nil {
+			return nil, err
+		}
+		if len(record) > 0 {
+			return record, nil
+		}
+	}
+}
+
+func (s *xlsxStream) cells(row *xlsxRow) ([]string, error) {
+	var record []string
+	empty := true
+	for i, cell := range row.Cells {
+		col := i
+		if cell.Ref != "" {
+			col = columnNumber(cell.Ref)
+		}
+		if col >= maxXLSXColumns {
+			return nil, fmt.Errorf("xlsx cell %q is beyond column XFD", cell.Ref)
+		}
+		if col < 0 {
+			continue
+		}
+
+		var value string
+		switch cell.Type {
+		case "s":
+			var idx int
+			if _, err := fmt.Sscan(cell.Value, &idx); err == nil && idx >= 0 && idx < len(s.sharedStrings) {
+				value = s.sharedStrings[idx]
+			}
+		case "inlineStr":
+			value = cell.Inline.String()
+		case "e":
+			value = ""
+		default:
+			value = cell.Value
+		}
+
+		for len(record) <= col {
+			record = append(record, "")
+		}
+		record[col] = value
+		if strings.TrimSpace(value) != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return nil, nil
+	}
+	return record, nil
+}
+
+func (s *xlsxStream) mapRow(record []string) []string {
+	row := make([]string, len(s.index))
+	for i, idx := range s.index {
+		if idx >= 0 && idx < len(record) {
+			row[i] = record[idx]
+		}
+	}
+	return row
+}
+
+// columnNumber переводит адрес ячейки (B12) в номер колонки с нуля.
+// Для колонок дальше XFD возвращает maxXLSXColumns.
+func columnNumber(ref string) int {
+	n := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		n = n*26 + int(r-'A'+1)
+		letters++
+		if n > maxXLSXColumns {
+			return maxXLSXColumns
+		}
+	}
+	if letters == 0 {
+		return -1
+	}
+	return n - 1
+}
@@ -0,0 +1,124 @@
+package csv_to_postgres
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// YMLParser разбирает каталоги YML (Yandex Market Language): одна строка на элемент <offer>.
+// Колонки:
+//   - имя дочернего элемента (price, vendorCode, barcode); повторяющиеся элементы (picture) - через запятую;
+//   - @атрибут предложения (@id, @available, @group_id);
+//   - param:Имя - значение <param name="Имя">;
+//   - category - название категории по categoryId из <categories> (категории должны идти до <offers>).
+type YMLParser struct {
+	Columns []string
+}
+
+func NewYMLParser(columns []string) *YMLParser {
+	return &YMLParser{Columns: columns}
+}
+
+func (p *YMLParser) Parse(reader io.Reader) (RowSource, error) {
+	decoder := xml.NewDecoder(reader)
+	decoder.Strict = false
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return decodeReader(input, charset)
+	}
+	return &ymlStream{parser: p, decoder: decoder, categories: make(map[string]string)}, nil
+}
+
+type ymlStream struct {
+	parser     *YMLParser
+	decoder    *xml.Decoder
+	categories map[string]string
+
+	ParseStats
+}
+
+type ymlCategory struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:",chardata"`
+}
+
+type ymlOffer struct {
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Fields []ymlField `xml:",any"`
+}
+
+type ymlField struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Value   string     `xml:",chardata"`
+}
+
+func (s *ymlStream) Next() ([]string, error) {
+	for {
+		token, err := s.decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("yml read error: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "category":
+			var category ymlCategory
+			if err := s.decoder.DecodeElement(&category, &start); err != nil {
+				return nil, fmt.Errorf("yml category error: %w", err)
+			}
+			s.categories[category.ID] = strings.TrimSpace(category.Name)
+		case "offer":
+			var offer ymlOffer
+			if err := s.decoder.DecodeElement(&offer, &start); err != nil {
+				return nil, fmt.Errorf("yml offer error: %w", err)
+			}
+			s.Read++
+			return lookupRow(s.parser.Columns, func(column string) string {
+				return s.value(&offer, column)
+			}), nil
+		}
+	}
+}
+
+func (s *ymlStream) value(offer *ymlOffer, column string) string {
+	switch {
+	case strings.HasPrefix(column, "@"):
+		return xmlAttr(offer.Attrs, column[1:])
+	case strings.HasPrefix(column, "param:"):
+		name := column[len("param:"):]
+		for _, field := range offer.Fields {
+			if field.XMLName.Local == "param" && xmlAttr(field.Attrs, "name") == name {
+				return strings.TrimSpace(field.Value)
+			}
+		}
+		return ""
+	case column == "category":
+		return s.categories[s.value(offer, "categoryId")]
+	}
+
+	var values []string
+	for _, field := range offer.Fields {
+		if field.XMLName.Local == column {
+			values = append(values, strings.TrimSpace(field.Value))
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+func xmlAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -3,10 +3,22 @@ package csv_to_postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/lib/pq"
+	"io"
 	"log"
 	"strings"
+	"time"
+)
+
+const (
+	defaultBatchSize = 5000
+	progressLogEvery = 50000
+	// maxRejectSamples - сколько причин пропуска строк сохраняется в статистике импорта.
+	maxRejectSamples = 10
+	// importRowColumn - номер строки фида во временной таблице: по нему из повторов ключа остаётся последняя строка.
+	importRowColumn = "import_row"
 )
 
 type PostgresUpdater struct {
@@ -14,6 +26,41 @@ type PostgresUpdater struct {
 	Schema    string
 	TableName string
 	Columns   []string
+	History   *HistoryTracking
+	// TrackChanges - обновлять изменившиеся строки и писать события изменений в <схема>.change_events.
+	// Без него в таблицу только добавляются новые строки.
+	TrackChanges bool
+	// LastChanges - изменения последнего импорта при TrackChanges.
+	LastChanges ChangeSummary
+	// LastStats - счётчики строк последнего импорта.
+	LastStats ImportStats
+	// KeyColumn - колонка, по которой строки файла сопоставляются со строками таблицы. Пустая - Columns[0].
+	KeyColumn string
+	// BatchSize - строк в одной пачке COPY. 0 - defaultBatchSize.
+	BatchSize int
+}
+
+// ImportStats - счётчики строк импорта. Rejected включает битые строки CSV и строки, которые не принял Postgres,
+// RejectSamples - первые maxRejectSamples причин пропуска.
+type ImportStats struct {
+	Copied        int
+	Rejected      int
+	RejectSamples []string
+	Inserted      int64
+	Updated       int64
+}
+
+// Upserted - количество добавленных и изменённых строк основной таблицы.
+func (s ImportStats) Upserted() int64 {
+	return s.Inserted + s.Updated
+}
+
+// HistoryTracking включает обновление существующих строк с записью старого и нового значения.
+// Table - таблица журнала в той же схеме с колонками (<ключ>, old_<Column>, new_<Column>, changed_at),
+// Column - отслеживаемая колонка основной таблицы.
+type HistoryTracking struct {
+	Table  string
+	Column string
 }
 
 func NewPostgresUpdater(db *sql.DB, schema, tableName string, columns []string) *PostgresUpdater {
@@ -32,6 +79,12 @@ func (u *PostgresUpdater) SetNewColumnNaming(columns []string) *PostgresUpdater
 	return u
 }
 
+// SetHistory задаёт отслеживание изменений; nil выключает его для следующих обновлений.
+func (u *PostgresUpdater) SetHistory(history *HistoryTracking) *PostgresUpdater {
+	u.History = history
+	return u
+}
+
 func (u *PostgresUpdater) SetNewSchema(schema string) *PostgresUpdater {
 	if schema == "" {
 		return u
@@ -49,7 +102,19 @@ func (u *PostgresUpdater) SetNewTableName(tableName string) *PostgresUpdater {
 }
 
 // UpdateData принимает подготовленные CSV данные и выполняет обновление через транзакцию.
+// Первая строка csvData - заголовок и не загружается.
 func (u *PostgresUpdater) UpdateData(csvData [][]string, ctx context.Context) error {
+	if len(csvData) == 0 {
+		return nil
+	}
+	return u.UpdateStream(ctx, &sliceSource{rows: csvData[1:]})
+}
+
+// UpdateStream загружает строки source во временную таблицу через COPY пачками по BatchSize строк
+// и переносит новые строки в основную таблицу. В памяти держится не больше одной пачки.
+// Строки, которые Postgres не принял, пропускаются и логируются.
+func (u *PostgresUpdater) UpdateStream(ctx context.Context, source RowSource) error {
+	u.LastStats, u.LastChanges = ImportStats{}, ChangeSummary{}
 	tx, err := u.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -58,29 +123,72 @@ func (u *PostgresUpdater) UpdateData(csvData [][]string, ctx context.Context) er
 
 	tempTableName := "temp_" + u.TableName
 	createTempTableQuery := fmt.Sprintf(`
-		CREATE TEMP TABLE %s AS
+		CREATE TEMP TABLE %s ON COMMIT DROP AS
 		SELECT * FROM %s.%s WHERE 1=0
 	`, tempTableName, u.Schema, u.TableName)
 	if _, err := tx.ExecContext(ctx, createTempTableQuery); err != nil {
 		return fmt.Errorf("create temp table error: %w", err)
 	}
+	// COPY заполняет номер строки из последовательности в порядке строк фида
+	addRowQuery := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s BIGSERIAL`, tempTableName, importRowColumn)
+	if _, err := tx.ExecContext(ctx, addRowQuery); err != nil {
+		return fmt.Errorf("add import row column error: %w", err)
+	}
 	log.Printf("Temp table %s создан", tempTableName)
 
-	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(tempTableName, u.Columns...))
-	if err != nil {
-		return fmt.Errorf("prepare copyin error: %w", err)
+	batchSize := u.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
 	}
-	for _, row := range csvData[1:] {
-		values := convertRowToInterfaceSlice(row)
-		if _, err := stmt.ExecContext(ctx, values...); err != nil {
-			return fmt.Errorf("exec copyin error: %w", err)
+	progress := copyProgress{table: u.TableName, started: time.Now()}
+	batch := make([][]string, 0, batchSize)
+	for {
+		row, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read row error: %w", err)
 		}
+		batch = append(batch, row)
+		if len(batch) < batchSize {
+			continue
+		}
+		if err := u.copyBatch(ctx, tx, tempTableName, batch, &progress); err != nil {
+			return err
+		}
+		batch = batch[:0]
 	}
-	if _, err = stmt.ExecContext(ctx); err != nil {
-		return fmt.Errorf("final exec copyin error: %w", err)
+	if err := u.copyBatch(ctx, tx, tempTableName, batch, &progress); err != nil {
+		return err
+	}
+	if stream, ok := source.(StatsSource); ok {
+		parsed := stream.Stats()
+		progress.skipped += parsed.Skipped
+		progress.samples = append(append([]string(nil), parsed.RejectSamples...), progress.samples...)
+	}
+	progress.log(true)
+	stats := ImportStats{Copied: progress.copied, Rejected: progress.skipped, RejectSamples: progress.samples}
+	if len(stats.RejectSamples) > maxRejectSamples {
+		stats.RejectSamples = stats.RejectSamples[:maxRejectSamples]
 	}
-	if err = stmt.Close(); err != nil {
-		return fmt.Errorf("close stmt error: %w", err)
+	// счётчики чтения доступны и при ошибке слияния с основной таблицей
+	u.LastStats = stats
+
+	res, err := tx.ExecContext(ctx, dedupeQuery(tempTableName, u.key()))
+	if err != nil {
+		return fmt.Errorf("dedupe temp table error: %w", err)
+	}
+	if duplicates, _ := res.RowsAffected(); duplicates > 0 {
+		log.Printf("Фид %s: %d повторов ключа, оставлены последние строки", u.TableName, duplicates)
+	}
+
+	var changes *changeTracker
+	if u.TrackChanges {
+		changes = &changeTracker{u: u, tx: tx, temp: tempTableName}
+		if err := changes.recordInserted(ctx); err != nil {
+			return err
+		}
 	}
 
 	insertQuery := fmt.Sprintf(`
@@ -95,22 +203,207 @@ func (u *PostgresUpdater) UpdateData(csvData [][]string, ctx context.Context) er
 		strings.Join(u.prefixedColumns("temp."), ","),
 		tempTableName,
 		u.Schema, u.TableName,
-		u.Columns[0], u.Columns[0],
-		u.Columns[0],
-		u.Columns[0])
+		u.key(), u.key(),
+		u.key(),
+		u.key())
 	log.Printf("Выполнение запроса: %s", insertQuery)
 
-	if _, err = tx.ExecContext(ctx, insertQuery); err != nil {
+	res, err = tx.ExecContext(ctx, insertQuery)
+	if err != nil {
 		return fmt.Errorf("insert execution error: %w", err)
 	}
+	stats.Inserted, _ = res.RowsAffected()
+
+	if changes != nil {
+		if err := changes.recordUpdated(ctx); err != nil {
+			return err
+		}
+	}
+
+	if u.History != nil {
+		if stats.Updated, err = u.updateWithHistory(ctx, tx, tempTableName); err != nil {
+			return err
+		}
+	}
+
+	if changes != nil {
+		if err := changes.applyUpdates(ctx); err != nil {
+			return err
+		}
+		if err := changes.recordDeleted(ctx, progress.copied); err != nil {
+			return err
+		}
+		// события updated учитывают все колонки, а не только колонку истории
+		stats.Updated = changes.summary.Updated
+	}
 
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("commit error: %w", err)
 	}
 
+	u.LastStats = stats
+	if changes != nil {
+		u.LastChanges = changes.summary
+		log.Printf("Изменения %s.%s: добавлено %d, изменено %d, удалено %d",
+			u.Schema, u.TableName, changes.summary.Inserted, changes.summary.Updated, changes.summary.Deleted)
+	}
 	return nil
 }
 
+// dedupeQuery удаляет из временной таблицы повторы ключа: остаётся последняя строка фида.
+// После него вставка, журнал изменений и обновление видят по одной строке на ключ.
+func dedupeQuery(tempTableName, key string) string {
+	return fmt.Sprintf(`
+		DELETE FROM %s AS older
+		USING %s AS newer
+		WHERE newer.%s = older.%s AND newer.%s > older.%s
+	`, tempTableName, tempTableName, key, key, importRowColumn, importRowColumn)
+}
+
+// copyBatch загружает пачку строк во временную таблицу. Если COPY пачки упал, пачка загружается
+// построчно, и строки с ошибкой пропускаются.
+func (u *PostgresUpdater) copyBatch(ctx context.Context, tx *sql.Tx, tempTableName string, batch [][]string, progress *copyProgress) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := u.copyWithSavepoint(ctx, tx, tempTableName, batch)
+	if err == nil {
+		progress.copied += len(batch)
+		progress.log(false)
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("copyin error: %w", err)
+	}
+
+	log.Printf("COPY пачки из %d строк в %s не удался (%v), загрузка по одной строке", len(batch), tempTableName, err)
+	for _, row := range batch {
+		if err := u.copyWithSavepoint(ctx, tx, tempTableName, [][]string{row}); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("copyin error: %w", err)
+			}
+			progress.reject(fmt.Sprintf("%v: %v", row, err))
+			log.Printf("Пропущена строка %v: %v", row, err)
+			continue
+		}
+		progress.copied++
+	}
+	progress.log(false)
+	return nil
+}
+
+// copyWithSavepoint выполняет COPY строк внутри savepoint, чтобы ошибка не прерывала всю транзакцию.
+func (u *PostgresUpdater) copyWithSavepoint(ctx context.Context, tx *sql.Tx, tempTableName string, rows [][]string) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT copy_batch"); err != nil {
+		return err
+	}
+
+	err := copyRows(ctx, tx, tempTableName, u.Columns, rows)
+	if err != nil {
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT copy_batch"); rbErr != nil {
+			return fmt.Errorf("%v; rollback to savepoint error: %w", err, rbErr)
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT copy_batch")
+	return err
+}
+
+func copyRows(ctx context.Context, tx *sql.Tx, tempTableName string, columns []string, rows [][]string) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(tempTableName, columns...))
+	if err != nil {
+		return fmt.Errorf("prepare copyin error: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, convertRowToInterfaceSlice(row)...); err != nil {
+			return fmt.Errorf("exec copyin error: %w", err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("final exec copyin error: %w", err)
+	}
+	return stmt.Close()
+}
+
+// copyProgress - прогресс загрузки во временную таблицу.
+type copyProgress struct {
+	table   string
+	started time.Time
+	copied  int
+	skipped int
+	samples []string
+	logged  int
+}
+
+// reject учитывает пропущенную строку и запоминает причину, пока не набрано maxRejectSamples причин.
+func (p *copyProgress) reject(reason string) {
+	p.skipped++
+	if len(p.samples) < maxRejectSamples {
+		p.samples = append(p.samples, reason)
+	}
+}
+
+// log пишет прогресс не чаще, чем раз в progressLogEvery строк, и всегда при final.
+func (p *copyProgress) log(final bool) {
+	if !final && p.copied-p.logged < progressLogEvery {
+		return
+	}
+	p.logged = p.copied
+	log.Printf("Загрузка %s: загружено %d строк, пропущено %d, прошло %v", p.table, p.copied, p.skipped, time.Since(p.started).Round(time.Millisecond))
+}
+
+// updateWithHistory пишет в журнал строки, у которых значение отслеживаемой колонки изменилось,
+// и затем обновляет их в основной таблице. Новые строки в журнал не попадают.
+// Возвращает количество изменённых строк.
+func (u *PostgresUpdater) updateWithHistory(ctx context.Context, tx *sql.Tx, tempTableName string) (int64, error) {
+	key, col := u.key(), u.History.Column
+
+	historyQuery := fmt.Sprintf(`
+		INSERT INTO %s.%s (%s, old_%s, new_%s, changed_at)
+		SELECT main.%s, main.%s, temp.%s, NOW()
+		FROM %s AS temp
+		JOIN %s.%s AS main ON temp.%s = main.%s
+		WHERE main.%s IS DISTINCT FROM temp.%s
+	`, u.Schema, u.History.Table, key, col, col,
+		key, col, col,
+		tempTableName,
+		u.Schema, u.TableName, key, key,
+		col, col)
+	res, err := tx.ExecContext(ctx, historyQuery)
+	if err != nil {
+		return 0, fmt.Errorf("history insert error: %w", err)
+	}
+	changed, _ := res.RowsAffected()
+
+	updateQuery := fmt.Sprintf(`
+		UPDATE %s.%s AS main
+		SET %s = temp.%s
+		FROM %s AS temp
+		WHERE temp.%s = main.%s
+		  AND main.%s IS DISTINCT FROM temp.%s
+	`, u.Schema, u.TableName,
+		col, col,
+		tempTableName,
+		key, key,
+		col, col)
+	if _, err = tx.ExecContext(ctx, updateQuery); err != nil {
+		return 0, fmt.Errorf("update execution error: %w", err)
+	}
+	log.Printf("Изменено значений %s.%s: %d", u.TableName, col, changed)
+	return changed, nil
+}
+
+func (u *PostgresUpdater) key() string {
+	if u.KeyColumn != "" {
+		return u.KeyColumn
+	}
+	return u.Columns[0]
+}
+
 func (u *PostgresUpdater) prefixedColumns(prefix string) []string {
 	cols := make([]string, len(u.Columns))
 	for i, col := range u.Columns {
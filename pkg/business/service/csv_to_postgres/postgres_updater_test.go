@@ -0,0 +1,18 @@
+package csv_to_postgres
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupeQueryKeepsLastRow(t *testing.T) {
+	updater := NewPostgresUpdater(nil, "wholesaler", "products", []string{"global_id", "price"})
+	query := strings.Join(strings.Fields(dedupeQuery("temp_products", updater.key())), " ")
+
+	// из пары строк с одним ключом удаляется та, у которой номер строки фида меньше
+	want := "DELETE FROM temp_products AS older USING temp_products AS newer " +
+		"WHERE newer.global_id = older.global_id AND newer.import_row > older.import_row"
+	if query != want {
+		t.Errorf("dedupeQuery =\n%s\nwant\n%s", query, want)
+	}
+}
@@ -0,0 +1,82 @@
+package csv_to_postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusSkipped = "skipped" // данные фида не изменились с прошлого импорта
+	RunStatusFailed  = "failed"
+
+	// importRunsTable - журнал запусков в схеме целевой таблицы (см. infrastructure.WholesalerImportRuns).
+	importRunsTable = "import_runs"
+)
+
+// RunJournal записывает запуски Updater.Execute в <схема>.import_runs: время, источники, время inf-файла,
+// счётчики строк и итоговый статус.
+type RunJournal struct {
+	db *sql.DB
+}
+
+func NewRunJournal(db *sql.DB) *RunJournal {
+	return &RunJournal{db: db}
+}
+
+// ImportRun - запись журнала об одном запуске импорта.
+type ImportRun struct {
+	ID       int64
+	Schema   string
+	JobName  string
+	InfURL   string
+	CSVURL   string
+	InfTime  time.Time
+	Status   string
+	Stats    ImportStats
+	Changes  ChangeSummary
+	Error    string
+	Started  time.Time
+	Finished time.Time
+}
+
+// Start создаёт запись со статусом running и заполняет run.ID.
+func (j *RunJournal) Start(ctx context.Context, run *ImportRun) error {
+	run.Status = RunStatusRunning
+	run.Started = time.Now()
+	query := fmt.Sprintf(`
+		INSERT INTO %s.%s (job_name, inf_url, csv_url, status, started_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`, run.Schema, importRunsTable)
+	err := j.db.QueryRowContext(ctx, query, run.JobName, run.InfURL, run.CSVURL, run.Status, run.Started).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to start import run %s: %w", run.JobName, err)
+	}
+	return nil
+}
+
+// Finish записывает итог запуска. Пишется без ctx импорта, чтобы запуск, прерванный таймаутом,
+// всё равно получил статус failed.
+func (j *RunJournal) Finish(run *ImportRun) error {
+	run.Finished = time.Now()
+	var infTime sql.NullTime
+	if !run.InfTime.IsZero() {
+		infTime = sql.NullTime{Time: run.InfTime, Valid: true}
+	}
+	query := fmt.Sprintf(`
+		UPDATE %s.%s
+		SET status = $2, inf_time = $3, finished_at = $4, rows_read = $5, rows_rejected = $6,
+		    reject_samples = $7, rows_inserted = $8, rows_updated = $9, rows_deleted = $10, error = NULLIF($11, '')
+		WHERE id = $1`, run.Schema, importRunsTable)
+	_, err := j.db.Exec(query, run.ID, run.Status, infTime, run.Finished,
+		run.Stats.Copied+run.Stats.Rejected, run.Stats.Rejected, pq.Array(run.Stats.RejectSamples),
+		run.Stats.Inserted, run.Stats.Updated, run.Changes.Deleted, run.Error)
+	if err != nil {
+		return fmt.Errorf("failed to finish import run %s: %w", run.JobName, err)
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 // документа по порядку.
 // При этом DBUpdater значения Columns должны содержать либо названия из оригинального документа, либо названия переименованные в случае,
 // если потребовалось переименование в методе Execute.
+// Файлы других форматов (XLSX, JSON-lines, YML) разбирает Parser; если он задан, CSVProcessor не используется.
 type Updater struct {
 	InfURL     string
 	CSVURL     string
@@ -24,7 +25,13 @@ type Updater struct {
 
 	Fetcher      Fetcher
 	CSVProcessor *Processor
+	Parser       Parser
 	DBUpdater    *PostgresUpdater
+
+	// Journal - журнал запусков. nil - запуски не записываются.
+	Journal *RunJournal
+	// JobName - имя импорта в журнале. Пустое - LastModCol.
+	JobName string
 }
 
 func NewUpdater(infURL, csvURL, lastModCol string, fetcher Fetcher, csvProc *Processor, dbUp *PostgresUpdater) *Updater {
@@ -38,6 +45,21 @@ func NewUpdater(infURL, csvURL, lastModCol string, fetcher Fetcher, csvProc *Pro
 	}
 }
 
+// SetParser задаёт парсер файла вместо CSVProcessor.
+func (u *Updater) SetParser(parser Parser) *Updater {
+	if parser != nil {
+		u.Parser = parser
+	}
+	return u
+}
+
+// SetJournal включает запись запусков в журнал под именем jobName.
+func (u *Updater) SetJournal(journal *RunJournal, jobName string) *Updater {
+	u.Journal = journal
+	u.JobName = jobName
+	return u
+}
+
 func (u *Updater) SetNewInfUrl(url string) *Updater {
 	if url != "" {
 		u.InfURL = url
@@ -77,8 +99,17 @@ func (u *Updater) SetNewUpdater(upd *PostgresUpdater) *Updater {
 // Обрабатывает ответ, в котором может быть несколько строк:
 // первая строка — время в формате "2006-01-02 15:04:05",
 // вторая строка — Unix-время (секунды).
+// Без InfURL берётся время изменения CSV-файла, если источник его знает (ModTimeFetcher).
 func (u *Updater) fetchInfTime(ctx context.Context) (time.Time, error) {
-	body, err := u.Fetcher.Fetch(u.InfURL)
+	if u.InfURL == "" {
+		modTimer, ok := u.Fetcher.(ModTimeFetcher)
+		if !ok {
+			return time.Time{}, fmt.Errorf("inf url is empty and fetcher does not report modification time")
+		}
+		return modTimer.ModTime(ctx, u.CSVURL)
+	}
+
+	body, err := u.Fetcher.Fetch(ctx, u.InfURL)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -113,64 +144,140 @@ func (u *Updater) fetchInfTime(ctx context.Context) (time.Time, error) {
 
 	return time.Time{}, fmt.Errorf("не удалось определить время из inf-файла")
 }
-func (u *Updater) getStoredTime(ctx context.Context, db *sql.DB) (time.Time, error) {
-	var storedTime time.Time
+
+// fetchCSV загружает CSVURL. Если источник поддерживает условную загрузку, отправляется версия validators,
+// сохранённая этим импортом, и ErrNotModified пропускает только его. Inf-файлы загружаются всегда.
+func (u *Updater) fetchCSV(ctx context.Context, validators Validators) (io.ReadCloser, Validators, error) {
+	if conditional, ok := u.Fetcher.(ConditionalFetcher); ok {
+		return conditional.FetchIfModified(ctx, u.CSVURL, validators)
+	}
+	body, err := u.Fetcher.Fetch(ctx, u.CSVURL)
+	return body, Validators{}, err
+}
+
+// getStoredState возвращает время последней загрузки импорта и версию загруженного файла.
+// Версия сохранена этим импортом и возвращается, только если он загружал тот же CSVURL.
+func (u *Updater) getStoredState(ctx context.Context, db *sql.DB) (time.Time, Validators, error) {
+	var storedTime sql.NullTime
+	var sourceURL, etag, lastModified sql.NullString
 	err := db.QueryRowContext(ctx,
-		"SELECT last_update FROM metadata WHERE key_name = $1",
-		u.LastModCol).Scan(&storedTime)
+		"SELECT last_update, source_url, etag, last_modified FROM metadata WHERE key_name = $1",
+		u.LastModCol).Scan(&storedTime, &sourceURL, &etag, &lastModified)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return time.Time{}, nil
+			return time.Time{}, Validators{}, nil
 		}
-		return time.Time{}, err
+		return time.Time{}, Validators{}, err
+	}
+	if sourceURL.String != RedactURL(u.CSVURL) {
+		return storedTime.Time, Validators{}, nil
 	}
-	return storedTime, nil
+	return storedTime.Time, Validators{ETag: etag.String, LastModified: lastModified.String}, nil
 }
 
-// Execute выполняет процесс обновления, если это необходимо.
+// Execute выполняет процесс обновления, если это необходимо. Файл читается потоково и загружается
+// в БД пачками, не попадая в память целиком. Колонки загружаются по DBUpdater.Columns, renaming
+// оставлен для совместимости и на загрузку не влияет.
+// Если задан Journal, запуск и его итог записываются в журнал; ошибка журнала не прерывает импорт.
 func (u *Updater) Execute(ctx context.Context, renaming []string, db *sql.DB) error {
-	modTime, err := u.fetchInfTime(ctx)
-	if err != nil {
+	if u.Journal == nil {
+		_, err := u.executeOnce(ctx, db, &ImportRun{})
 		return err
 	}
-	storedTime, err := u.getStoredTime(ctx, db)
-	if err != nil {
+
+	run := &ImportRun{Schema: u.DBUpdater.Schema, JobName: u.JobName, InfURL: RedactURL(u.InfURL), CSVURL: RedactURL(u.CSVURL)}
+	if run.JobName == "" {
+		run.JobName = u.LastModCol
+	}
+	if err := u.Journal.Start(ctx, run); err != nil {
+		log.Printf("Журнал импорта недоступен: %v", err)
+		_, err = u.executeOnce(ctx, db, run)
 		return err
 	}
 
-	if modTime.After(storedTime) {
-		log.Printf("Начало обновления данных с %s", u.CSVURL)
-		body, err := u.Fetcher.Fetch(u.CSVURL)
-		if err != nil {
-			return err
-		}
-		defer body.Close()
+	updated, err := u.executeOnce(ctx, db, run)
+	switch {
+	case err != nil:
+		run.Status = RunStatusFailed
+		run.Error = err.Error()
+	case updated:
+		run.Status = RunStatusSuccess
+	default:
+		run.Status = RunStatusSkipped
+	}
+	if journalErr := u.Journal.Finish(run); journalErr != nil {
+		log.Printf("Журнал импорта недоступен: %v", journalErr)
+	}
+	return err
+}
 
-		csvData, err := u.CSVProcessor.ProcessCSV(body, renaming)
-		if err != nil {
-			return err
-		}
+// executeOnce выполняет обновление. Неизменившийся источник (ErrNotModified) - не ошибка.
+func (u *Updater) executeOnce(ctx context.Context, db *sql.DB, run *ImportRun) (bool, error) {
+	updated, err := u.execute(ctx, db, run)
+	if errors.Is(err, ErrNotModified) {
+		log.Printf("Обновление не требуется, источник %s не изменился.", RedactURL(u.CSVURL))
+		return false, nil
+	}
+	return updated, err
+}
 
-		dbCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
-		defer cancel()
+// execute выполняет обновление и заполняет время inf-файла и счётчики run. Возвращает false,
+// если данные актуальны и загрузка не понадобилась.
+func (u *Updater) execute(ctx context.Context, db *sql.DB, run *ImportRun) (bool, error) {
+	modTime, err := u.fetchInfTime(ctx)
+	if err != nil {
+		return false, err
+	}
+	run.InfTime = modTime
+	storedTime, validators, err := u.getStoredState(ctx, db)
+	if err != nil {
+		return false, err
+	}
 
-		if err := u.DBUpdater.UpdateData(csvData, dbCtx); err != nil {
-			return err
-		}
+	if !modTime.After(storedTime) {
+		log.Printf("Обновление не требуется, данные актуальны.")
+		return false, nil
+	}
 
-		_, err = db.ExecContext(ctx, `
-			INSERT INTO metadata (key_name, value, last_update)
-			VALUES ($1, $2, $3)
-			ON CONFLICT (key_name) DO UPDATE SET last_update = EXCLUDED.last_update
-		`, u.LastModCol, u.LastModCol, modTime)
-		if err != nil {
-			return fmt.Errorf("metadata update error: %w", err)
-		}
+	log.Printf("Начало обновления данных с %s", RedactURL(u.CSVURL))
+	body, validators, err := u.fetchCSV(ctx, validators)
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
 
-		log.Printf("Обновление данных завершено успешно.")
-	} else {
-		log.Printf("Обновление не требуется, данные актуальны.")
+	var parser Parser = u.CSVProcessor
+	if u.Parser != nil {
+		parser = u.Parser
+	}
+	stream, err := parser.Parse(body)
+	if err != nil {
+		return false, err
+	}
+
+	err = u.DBUpdater.UpdateStream(ctx, stream)
+	run.Stats = u.DBUpdater.LastStats
+	run.Changes = u.DBUpdater.LastChanges
+	if err != nil {
+		return false, err
+	}
+	if parsed, ok := stream.(StatsSource); ok {
+		log.Printf("Прочитано строк: %d, пропущено битых: %d", parsed.Stats().Read, parsed.Stats().Skipped)
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO metadata (key_name, value, last_update, source_url, etag, last_modified)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key_name) DO UPDATE
+		SET last_update = EXCLUDED.last_update,
+		    source_url = EXCLUDED.source_url,
+		    etag = EXCLUDED.etag,
+		    last_modified = EXCLUDED.last_modified
+	`, u.LastModCol, u.LastModCol, modTime, RedactURL(u.CSVURL), validators.ETag, validators.LastModified)
+	if err != nil {
+		return false, fmt.Errorf("metadata update error: %w", err)
 	}
 
-	return nil
+	log.Printf("Обновление данных завершено успешно.")
+	return true, nil
 }
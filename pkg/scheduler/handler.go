@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// RunRequest - запрос ручного запуска задачи.
+type RunRequest struct {
+	Job string `json:"job"`
+}
+
+// NewHandler возвращает HTTP API планировщика:
+// /api/scheduler/jobs - состояние задач, /api/scheduler/run - ручной запуск задачи (POST {"job": "wb_media"}).
+func NewHandler(s *Scheduler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/scheduler/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(s.States()); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/api/scheduler/run", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var runReq RunRequest
+		if err := json.NewDecoder(r.Body).Decode(&runReq); err != nil {
+			http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		err := s.Trigger(runReq.Job)
+		switch {
+		case errors.Is(err, ErrUnknownJob):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, ErrJobRunning):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return mux
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@every 500ms",
+		"@every soon",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) = nil error, want error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2024-01-01 - понедельник
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{spec: "*/15 * * * *", from: at(1, 1, 10, 7).Add(30 * time.Second), want: at(1, 1, 10, 15)},
+		// следующий запуск строго после from
+		{spec: "0 * * * *", from: at(1, 1, 10, 0), want: at(1, 1, 11, 0)},
+		{spec: "5/20 * * * *", from: at(1, 1, 10, 6), want: at(1, 1, 10, 25)},
+		{spec: "0 8-20/4 * * *", from: at(1, 1, 12, 1), want: at(1, 1, 16, 0)},
+		{spec: "30 9 * * 1-5", from: at(1, 5, 10, 0), want: at(1, 8, 9, 30)},
+		// 7 - воскресенье
+		{spec: "0 0 * * 7", from: at(1, 1, 0, 0), want: at(1, 7, 0, 0)},
+		{spec: "0 0 1,15 * *", from: at(1, 2, 0, 0), want: at(1, 15, 0, 0)},
+		// заданы день месяца и день недели - достаточно любого: пятница 5-е раньше 13-го
+		{spec: "0 0 13 * 5", from: at(1, 1, 0, 0), want: at(1, 5, 0, 0)},
+		{spec: "0 0 29 2 *", from: at(3, 1, 0, 0), want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "@hourly", from: at(1, 1, 23, 30), want: at(1, 2, 0, 0)},
+		{spec: "@daily", from: at(1, 31, 23, 59), want: at(2, 1, 0, 0)},
+		{spec: "@weekly", from: at(1, 1, 0, 0), want: at(1, 7, 0, 0)},
+		{spec: "@monthly", from: at(12, 15, 0, 0), want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@every 90m", from: at(1, 1, 10, 7), want: at(1, 1, 11, 37)},
+		// 30 февраля не бывает - запуска нет
+		{spec: "0 0 30 2 *", from: at(1, 1, 0, 0), want: time.Time{}},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q after %v: Next = %v, want %v", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gomarketplace_api/pkg/logger"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobRunning = errors.New("job is already running")
+	ErrNotStarted = errors.New("scheduler is not started")
+)
+
+// JobFunc - тело задачи. Контекст отменяется по таймауту задачи и при остановке планировщика.
+type JobFunc func(ctx context.Context) error
+
+// Job - задача планировщика. Пустой Spec - задача запускается только вручную.
+type Job struct {
+	Name    string
+	Spec    string
+	Timeout time.Duration // 0 - без ограничения
+	Run     JobFunc
+}
+
+// JobState - состояние задачи: последний запуск хранится в StateStore и переживает перезапуск.
+type JobState struct {
+	Name          string     `json:"name"`
+	Spec          string     `json:"schedule,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	Trigger       string     `json:"trigger,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	Running       bool       `json:"running"`
+}
+
+// StateStore хранит состояние последнего запуска задач.
+type StateStore interface {
+	LoadStates(ctx context.Context) (map[string]JobState, error)
+	SaveState(ctx context.Context, state JobState) error
+}
+
+// Scheduler запускает задачи по расписанию. Одна задача не выполняется параллельно сама с собой:
+// запуск по расписанию или вручную, пока задача ещё работает, пропускается.
+type Scheduler struct {
+	store  StateStore
+	logger logger.Logger
+
+	mu    sync.Mutex
+	jobs  map[string]*entry
+	order []string
+	ctx   context.Context
+	wg    sync.WaitGroup
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	state    JobState
+}
+
+// New создаёт планировщик. store может быть nil - тогда состояние не сохраняется между запусками.
+func New(store StateStore, logWriter io.Writer) *Scheduler {
+	return &Scheduler{
+		store:  store,
+		logger: logger.NewLogger(logWriter, "[Scheduler]"),
+		jobs:   make(map[string]*entry),
+	}
+}
+
+// Register добавляет задачу. Задачи регистрируются до Start.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job name and func are required")
+	}
+
+	var schedule Schedule
+	if job.Spec != "" {
+		var err error
+		if schedule, err = ParseSchedule(job.Spec); err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	s.jobs[job.Name] = &entry{job: job, schedule: schedule, state: JobState{Name: job.Name, Spec: job.Spec}}
+	s.order = append(s.order, job.Name)
+	return nil
+}
+
+// Start загружает состояние задач и запускает их по расписанию до отмены ctx.
+// Задача, чей запуск был пропущен, пока приложение не работало (или которая ещё ни разу не запускалась),
+// выполняется сразу.
+func (s *Scheduler) Start(ctx context.Context) error {
+	var saved map[string]JobState
+	if s.store != nil {
+		var err error
+		if saved, err = s.store.LoadStates(ctx); err != nil {
+			return fmt.Errorf("load scheduler state: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil {
+		return fmt.Errorf("scheduler is already started")
+	}
+	s.ctx = ctx
+
+	now := time.Now()
+	for _, name := range s.order {
+		e := s.jobs[name]
+		if state, ok := saved[name]; ok {
+			e.state = state
+			e.state.Spec, e.state.Running = e.job.Spec, false
+		}
+		if e.schedule == nil {
+			continue
+		}
+
+		next := now
+		if e.state.StartedAt != nil {
+			if planned := e.schedule.Next(*e.state.StartedAt); planned.After(now) {
+				next = planned
+			}
+		}
+		e.state.NextRunAt = &next
+		s.wg.Add(1)
+		go s.loop(e, next)
+	}
+	s.logger.Log("Started with %d jobs", len(s.order))
+	return nil
+}
+
+// Wait ждёт завершения циклов и выполняющихся задач после отмены контекста Start.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// Trigger запускает задачу вне расписания, не дожидаясь её завершения.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	if s.ctx == nil || s.ctx.Err() != nil {
+		return ErrNotStarted
+	}
+	if e.state.Running {
+		return fmt.Errorf("%w: %s", ErrJobRunning, name)
+	}
+
+	s.begin(e, TriggerManual)
+	go s.execute(e)
+	return nil
+}
+
+// States возвращает состояние всех задач в порядке регистрации.
+func (s *Scheduler) States() []JobState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states := make([]JobState, 0, len(s.order))
+	for _, name := range s.order {
+		states = append(states, s.jobs[name].state)
+	}
+	return states
+}
+
+func (s *Scheduler) loop(e *entry, next time.Time) {
+	defer s.wg.Done()
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		started := !e.state.Running
+		if started {
+			s.begin(e, TriggerSchedule)
+		}
+		s.mu.Unlock()
+
+		if started {
+			s.execute(e)
+		} else {
+			s.logger.Log("Job %s is still running, scheduled run skipped", e.job.Name)
+		}
+
+		next = e.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Log("Job %s has no next run for schedule %q", e.job.Name, e.job.Spec)
+			return
+		}
+		s.mu.Lock()
+		e.state.NextRunAt = &next
+		s.mu.Unlock()
+	}
+}
+
+// begin отмечает задачу запущенной. Вызывается под s.mu.
+func (s *Scheduler) begin(e *entry, trigger string) {
+	now := time.Now()
+	e.state.Running = true
+	e.state.Status, e.state.Trigger, e.state.Error = StatusRunning, trigger, ""
+	e.state.StartedAt, e.state.FinishedAt = &now, nil
+	s.wg.Add(1)
+}
+
+// execute выполняет задачу, отмеченную begin, и сохраняет результат.
+func (s *Scheduler) execute(e *entry) {
+	defer s.wg.Done()
+
+	s.mu.Lock()
+	state := e.state
+	s.mu.Unlock()
+	s.save(state)
+	s.logger.Log("Job %s started (%s)", e.job.Name, state.Trigger)
+
+	ctx, cancel := s.ctx, context.CancelFunc(func() {})
+	if e.job.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+	}
+	err := s.run(ctx, e.job)
+	cancel()
+
+	s.mu.Lock()
+	now := time.Now()
+	e.state.Running, e.state.FinishedAt = false, &now
+	if err != nil {
+		e.state.Status, e.state.Error = StatusFailed, err.Error()
+	} else {
+		e.state.Status, e.state.LastSuccessAt = StatusSuccess, &now
+	}
+	state = e.state
+	s.mu.Unlock()
+	s.save(state)
+
+	if err != nil {
+		s.logger.Log("Job %s failed in %s: %v", e.job.Name, now.Sub(*state.StartedAt), err)
+		return
+	}
+	s.logger.Log("Job %s finished in %s", e.job.Name, now.Sub(*state.StartedAt))
+}
+
+// run вызывает задачу; паника задачи не останавливает планировщик.
+func (s *Scheduler) run(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) save(state JobState) {
+	if s.store == nil {
+		return
+	}
+	// состояние сохраняется и во время остановки, поэтому не от контекста планировщика
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.store.SaveState(ctx, state); err != nil {
+		s.logger.Log("Failed to save state of job %s: %v", state.Name, err)
+	}
+}